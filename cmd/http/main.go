@@ -4,11 +4,13 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	_ "time/tzdata" // tenant timezones must not depend on the zoneinfo of the host
 
 	"github.com/emanuelquerty/gymulty"
 	"github.com/emanuelquerty/gymulty/config"
 	"github.com/emanuelquerty/gymulty/http"
 	"github.com/emanuelquerty/gymulty/http/middleware"
+	"github.com/emanuelquerty/gymulty/mail"
 	"github.com/emanuelquerty/gymulty/postgres"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/joho/godotenv"
@@ -23,6 +25,13 @@ func main() {
 	}
 
 	dbconfig := config.LoadDB(logger)
+	authconfig, err := config.LoadAuth(logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mailconfig := config.LoadMail(logger)
+	tenancyconfig := config.LoadTenancy(logger)
+	exportconfig := config.LoadExport(logger)
 
 	err = postgres.CreateDBIfNotExists(*dbconfig)
 	if err != nil {
@@ -37,7 +46,20 @@ func main() {
 		log.Fatal(err)
 	}
 
-	server := http.NewServer(dbpool, logger)
+	mailer, err := mail.New(*mailconfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store := postgres.NewStore(dbpool)
+	if dbconfig.SchemaPerTenant {
+		store, err = postgres.NewTenantSchemaStore(dbpool, gymulty.EmbedMigrations)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	server := http.NewServer(store, logger, authconfig, tenancyconfig, exportconfig, mailer)
 
 	server.Use(middleware.Logger)
 	server.Use(middleware.SetHeader("Content-Type", "application/json"))
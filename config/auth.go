@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+type Auth struct {
+	AppURL          string // public url of the web app, used in emailed links
+	TokenSecret     string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	PlatformToken   string // bootstrap bearer token of the platform operators, disabled while empty
+}
+
+// minTokenSecretLength is the fewest bytes of AUTH_TOKEN_SECRET accepted,
+// the size of the HMAC-SHA256 key signing the tokens.
+const minTokenSecretLength = 32
+
+// LoadAuth reads the settings used to issue tokens. It fails when the token
+// secret is missing or too short, since anyone able to guess it can sign
+// tokens for any user.
+func LoadAuth(logger *slog.Logger) (*Auth, error) {
+	conf := new(Auth)
+
+	conf.AppURL = getEnvDefault("APP_URL", "http://localhost:3000")
+	conf.TokenSecret = getEnv(logger, "AUTH_TOKEN_SECRET")
+	conf.AccessTokenTTL = getDurationEnv(logger, "AUTH_ACCESS_TOKEN_TTL", 15*time.Minute)
+	conf.RefreshTokenTTL = getDurationEnv(logger, "AUTH_REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	conf.PlatformToken = getEnvDefault("PLATFORM_API_TOKEN", "")
+
+	if len(conf.TokenSecret) < minTokenSecretLength {
+		return nil, fmt.Errorf("environment variable: AUTH_TOKEN_SECRET must be at least %d bytes", minTokenSecretLength)
+	}
+	return conf, nil
+}
+
+// getDurationEnv parses an optional duration env variable such as "15m",
+// falling back to the given default when it is unset or invalid.
+func getDurationEnv(logger *slog.Logger, name string, fallback time.Duration) time.Duration {
+	env, ok := os.LookupEnv(name)
+	if !ok {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(env)
+	if err != nil {
+		err := fmt.Errorf("environment variable: invalid duration for %s: %w", name, err)
+		logger.Error(err.Error())
+		return fallback
+	}
+	return d
+}
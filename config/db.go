@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 )
 
 type Database struct {
@@ -12,6 +13,10 @@ type Database struct {
 	Name     string
 	User     string
 	Password string
+
+	// SchemaPerTenant stores the data of every tenant signing up in a
+	// Postgres schema of its own instead of the shared tables.
+	SchemaPerTenant bool
 }
 
 func LoadDB(logger *slog.Logger) *Database {
@@ -22,6 +27,7 @@ func LoadDB(logger *slog.Logger) *Database {
 	conf.Name = getEnv(logger, "DB_NAME")
 	conf.User = getEnv(logger, "DB_USER")
 	conf.Password = getEnv(logger, "DB_PASSWORD")
+	conf.SchemaPerTenant = getBoolEnv(logger, "DB_SCHEMA_PER_TENANT", false)
 	return conf
 }
 
@@ -33,3 +39,20 @@ func getEnv(logger *slog.Logger, name string) string {
 	}
 	return env
 }
+
+// getBoolEnv parses an optional boolean env variable such as "true" or "1",
+// falling back to the given default when it is unset or invalid.
+func getBoolEnv(logger *slog.Logger, name string, fallback bool) bool {
+	env, ok := os.LookupEnv(name)
+	if !ok {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(env)
+	if err != nil {
+		err := fmt.Errorf("environment variable: invalid boolean for %s: %w", name, err)
+		logger.Error(err.Error())
+		return fallback
+	}
+	return b
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"log/slog"
+	"time"
+)
+
+type Export struct {
+	Dir     string        // where archives are kept until they expire
+	TTL     time.Duration // how long an archive can be downloaded
+	LinkTTL time.Duration // how long a single download link works
+}
+
+// LoadExport reads the settings of tenant data exports.
+func LoadExport(logger *slog.Logger) *Export {
+	conf := new(Export)
+
+	conf.Dir = getEnvDefault("EXPORT_DIR", "exports")
+	conf.TTL = getDurationEnv(logger, "EXPORT_TTL", 7*24*time.Hour)
+	conf.LinkTTL = getDurationEnv(logger, "EXPORT_LINK_TTL", 15*time.Minute)
+	return conf
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+)
+
+type Mail struct {
+	Driver   string // "stdout", "file" or "smtp"
+	FilePath string
+	From     string
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
+
+// LoadMail reads the mail settings. Without MAIL_DRIVER emails are written
+// to stdout, which is what you want during local development.
+func LoadMail(logger *slog.Logger) *Mail {
+	conf := new(Mail)
+
+	conf.Driver = getEnvDefault("MAIL_DRIVER", "stdout")
+	conf.From = getEnvDefault("MAIL_FROM", "no-reply@gymulty.app")
+
+	switch conf.Driver {
+	case "file":
+		conf.FilePath = getEnv(logger, "MAIL_FILE_PATH")
+	case "smtp":
+		conf.Host = getEnv(logger, "SMTP_HOST")
+		conf.Port = getEnvDefault("SMTP_PORT", "587")
+		conf.Username = getEnv(logger, "SMTP_USERNAME")
+		conf.Password = getEnv(logger, "SMTP_PASSWORD")
+	}
+	return conf
+}
+
+func getEnvDefault(name string, fallback string) string {
+	env, ok := os.LookupEnv(name)
+	if !ok {
+		return fallback
+	}
+	return env
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"log/slog"
+	"time"
+)
+
+type Tenancy struct {
+	BaseDomain          string // tenants are served from <subdomain>.<BaseDomain>
+	CacheTTL            time.Duration
+	DeletionGracePeriod time.Duration // how long closed accounts can be restored before they are purged
+}
+
+// LoadTenancy reads the settings used to resolve tenants from the Host
+// header of a request and to close their accounts.
+func LoadTenancy(logger *slog.Logger) *Tenancy {
+	conf := new(Tenancy)
+
+	conf.BaseDomain = getEnvDefault("TENANT_BASE_DOMAIN", "gymulty.app")
+	conf.CacheTTL = getDurationEnv(logger, "TENANT_CACHE_TTL", 5*time.Minute)
+	conf.DeletionGracePeriod = getDurationEnv(logger, "TENANT_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	return conf
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// APIKey lets a tenant's integrations call the API without a user login.
+// Only the hash of the key is stored; the key itself is shown once.
+type APIKey struct {
+	ID         int        `json:"id"  bson:"id"`
+	TenantID   int        `json:"tenant_id"  bson:"tenant_id"`
+	Name       string     `json:"name"  bson:"name"`
+	Prefix     string     `json:"prefix"  bson:"prefix"` // first characters of the key, to tell keys apart
+	KeyHash    string     `json:"-"  bson:"key_hash"`
+	Scopes     []string   `json:"scopes"  bson:"scopes"`
+	CreatedBy  *int       `json:"created_by,omitempty"  bson:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"  bson:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"  bson:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"  bson:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"  bson:"created_at"`
+}
+
+type APIKeyRequestBody struct {
+	Name      string     `json:"name,omitempty"  bson:"name"`
+	Scopes    []string   `json:"scopes,omitempty"  bson:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"  bson:"expires_at"`
+}
+
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, tenantID int, key APIKey) (APIKey, error)
+	// GetAPIKeyByHash looks a key up across all tenants, the key itself
+	// being the only thing a caller presents.
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (APIKey, error)
+	GetAllAPIKeys(ctx context.Context, tenantID int) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, tenantID int, keyID int) error
+	// TouchAPIKey updates the last used timestamp of the key.
+	TouchAPIKey(ctx context.Context, keyID int) error
+}
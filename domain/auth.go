@@ -0,0 +1,18 @@
+package domain
+
+type LoginRequestBody struct {
+	Email    string `json:"email,omitempty"  bson:"email"`
+	Password string `json:"password,omitempty"  bson:"password"`
+}
+
+type RefreshRequestBody struct {
+	RefreshToken string `json:"refresh_token,omitempty"  bson:"refresh_token"`
+}
+
+type VerifyEmailRequestBody struct {
+	Token string `json:"token,omitempty"  bson:"token"`
+}
+
+type ResendVerificationRequestBody struct {
+	Email string `json:"email,omitempty"  bson:"email"`
+}
@@ -23,4 +23,7 @@ type ClassStore interface {
 	GetClassByID(ctx context.Context, tenantID int, classID int) (Class, error)
 	DeleteClassByID(ctx context.Context, tenantID int, classID int) error
 	GetAllClasses(ctx context.Context, tenantID int) ([]Class, error)
+	GetClassesByLocation(ctx context.Context, tenantID int, locationID int) ([]Class, error)
+	GetClassLocations(ctx context.Context, tenantID int, classID int) ([]Location, error)
+	SetClassLocations(ctx context.Context, tenantID int, classID int, locationIDs []int) ([]Location, error)
 }
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// DeletionCertificate records that all data of a tenant was purged. It is
+// kept after the tenant is gone, as proof for the gym and for audits.
+type DeletionCertificate struct {
+	ID             int            `json:"id"  bson:"id"`
+	TenantID       int            `json:"tenant_id"  bson:"tenant_id"`
+	BusinessName   string         `json:"business_name"  bson:"business_name"`
+	Subdomain      string         `json:"subdomain"  bson:"subdomain"`
+	RequestedBy    string         `json:"requested_by"  bson:"requested_by"`
+	RequestedAt    time.Time      `json:"requested_at"  bson:"requested_at"`
+	ScheduledFor   time.Time      `json:"scheduled_for"  bson:"scheduled_for"`
+	PurgedAt       time.Time      `json:"purged_at"  bson:"purged_at"`
+	DeletedRecords map[string]int `json:"deleted_records"  bson:"deleted_records"` // number of rows deleted per table
+}
+
+type DeletionStore interface {
+	// GetTenantsDueForPurge returns the tenants pending deletion whose grace
+	// period ended by now.
+	GetTenantsDueForPurge(ctx context.Context, now time.Time) ([]Tenant, error)
+	// PurgeTenant deletes the tenant with all its data and records the
+	// certificate, provided it is still due by now. A tenant restored in the
+	// meantime is not found.
+	PurgeTenant(ctx context.Context, tenantID int, now time.Time) (DeletionCertificate, error)
+	GetDeletionCertificate(ctx context.Context, tenantID int) (DeletionCertificate, error)
+}
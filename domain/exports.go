@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// An export is pending until a worker picks it up, and its archive can be
+// downloaded once it completed until it expires and is removed.
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+	ExportExpired   = "expired"
+)
+
+// Export is a job building an archive of all data of a tenant.
+type Export struct {
+	ID          int        `json:"id"  bson:"id"`
+	TenantID    int        `json:"tenant_id"  bson:"tenant_id"`
+	Status      string     `json:"status"  bson:"status"`
+	RequestedBy *int       `json:"requested_by,omitempty"  bson:"requested_by"` // nil when requested with an API key
+	CompletedAt *time.Time `json:"completed_at,omitempty"  bson:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"  bson:"expires_at"` // when the archive is removed
+	CreatedAt   time.Time  `json:"created_at"  bson:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"  bson:"updated_at"`
+}
+
+type ClassLocation struct {
+	ClassID    int `json:"class_id"  bson:"class_id"`
+	LocationID int `json:"location_id"  bson:"location_id"`
+}
+
+type UserLocation struct {
+	UserID     int `json:"user_id"  bson:"user_id"`
+	LocationID int `json:"location_id"  bson:"location_id"`
+}
+
+// TenantRecords is everything stored about a tenant that goes into its
+// export. Password hashes, token hashes and two-factor secrets never do.
+type TenantRecords struct {
+	Tenant         Tenant
+	Settings       TenantSettings
+	Users          []User // without passwords
+	Roles          []Role
+	Locations      []Location
+	Classes        []Class
+	ClassLocations []ClassLocation
+	UserLocations  []UserLocation
+	Invitations    []Invitation
+	APIKeys        []APIKey
+	Sessions       []Session
+	LoginAttempts  []LoginAttempt
+}
+
+type ExportStore interface {
+	CreateExport(ctx context.Context, tenantID int, export Export) (Export, error)
+	GetExportByID(ctx context.Context, tenantID int, exportID int) (Export, error)
+	// SetExportStatus moves the export to status. Completing it records when
+	// it completed and when its archive expires.
+	SetExportStatus(ctx context.Context, tenantID int, exportID int, status string, expiresAt *time.Time) (Export, error)
+	// ExpireExports marks the completed exports of all tenants whose archive
+	// expired before now as expired and returns them.
+	ExpireExports(ctx context.Context, now time.Time) ([]Export, error)
+	// GetTenantRecords reads all records of the tenant in one transaction.
+	GetTenantRecords(ctx context.Context, tenantID int) (TenantRecords, error)
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Invitation asks someone to join a tenant with a given role. The invitee
+// picks their own password when accepting through the emailed link, and
+// keeps the names of the invitation unless they pick others.
+type Invitation struct {
+	ID         int        `json:"id"  bson:"id"`
+	TenantID   int        `json:"tenant_id"  bson:"tenant_id"`
+	Email      string     `json:"email"  bson:"email"`
+	FirstName  string     `json:"first_name,omitempty"  bson:"first_name"`
+	LastName   string     `json:"last_name,omitempty"  bson:"last_name"`
+	Role       string     `json:"role"  bson:"role"`
+	TokenHash  string     `json:"-"  bson:"token_hash"`
+	InvitedBy  *int       `json:"invited_by,omitempty"  bson:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at"  bson:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"  bson:"accepted_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"  bson:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"  bson:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"  bson:"updated_at"`
+}
+
+type InvitationRequestBody struct {
+	Email string `json:"email,omitempty"  bson:"email"`
+	Role  string `json:"role,omitempty"  bson:"role"`
+}
+
+type AcceptInvitationRequestBody struct {
+	Token     string `json:"token,omitempty"  bson:"token"`
+	FirstName string `json:"first_name,omitempty"  bson:"first_name"`
+	LastName  string `json:"last_name,omitempty"  bson:"last_name"`
+	Password  string `json:"password,omitempty"  bson:"password"`
+}
+
+type InvitationStore interface {
+	CreateInvitation(ctx context.Context, tenantID int, invitation Invitation) (Invitation, error)
+	GetInvitationByID(ctx context.Context, tenantID int, invitationID int) (Invitation, error)
+	GetAllInvitations(ctx context.Context, tenantID int) ([]Invitation, error)
+	// RenewInvitation replaces the token of a pending invitation and extends its expiry.
+	RenewInvitation(ctx context.Context, tenantID int, invitationID int, tokenHash string, expiresAt time.Time) (Invitation, error)
+	RevokeInvitation(ctx context.Context, tenantID int, invitationID int) error
+	// AcceptInvitation creates user with the email and role of the pending,
+	// unexpired invitation with the given token hash and marks it accepted.
+	AcceptInvitation(ctx context.Context, tenantID int, tokenHash string, user User) (User, error)
+}
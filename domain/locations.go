@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrUnknownLocation = errors.New("location: one or more locations do not exist")
+
+// DefaultLocationName names the location every tenant starts out with.
+const DefaultLocationName = "Main location"
+
+// Location is one of the gyms a tenant runs. Classes, staff and members
+// belong to one or more of them.
+type Location struct {
+	ID           int            `json:"id,omitempty"  bson:"id"`
+	TenantID     int            `json:"tenant_id,omitempty"  bson:"tenant_id"`
+	Name         string         `json:"name,omitempty"  bson:"name"`
+	Address      string         `json:"address,omitempty"  bson:"address"`
+	City         string         `json:"city,omitempty"  bson:"city"`
+	PostalCode   string         `json:"postal_code,omitempty"  bson:"postal_code"`
+	Country      string         `json:"country,omitempty"  bson:"country"`   // ISO 3166 code, e.g. "PT"
+	Timezone     string         `json:"timezone,omitempty"  bson:"timezone"` // IANA name, e.g. "Europe/Lisbon"
+	OpeningHours []OpeningHours `json:"opening_hours"  bson:"opening_hours"`
+	CreatedAt    time.Time      `json:"created_at,omitempty"  bson:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at,omitempty"  bson:"updated_at"`
+}
+
+// LocationUpdate changes the fields of a location that are not nil.
+type LocationUpdate struct {
+	Name         *string         `json:"name,omitempty"  bson:"name"`
+	Address      *string         `json:"address,omitempty"  bson:"address"`
+	City         *string         `json:"city,omitempty"  bson:"city"`
+	PostalCode   *string         `json:"postal_code,omitempty"  bson:"postal_code"`
+	Country      *string         `json:"country,omitempty"  bson:"country"`
+	Timezone     *string         `json:"timezone,omitempty"  bson:"timezone"`
+	OpeningHours *[]OpeningHours `json:"opening_hours,omitempty"  bson:"opening_hours"`
+}
+
+// LocationsRequestBody replaces the locations a class or user belongs to.
+type LocationsRequestBody struct {
+	LocationIDs []int `json:"location_ids"  bson:"location_ids"`
+}
+
+type LocationStore interface {
+	CreateLocation(ctx context.Context, tenantID int, location Location) (Location, error)
+	GetLocationByID(ctx context.Context, tenantID int, locationID int) (Location, error)
+	GetAllLocations(ctx context.Context, tenantID int) ([]Location, error)
+	UpdateLocation(ctx context.Context, tenantID int, locationID int, updates LocationUpdate) (Location, error)
+	DeleteLocationByID(ctx context.Context, tenantID int, locationID int) error
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Outcomes of a login attempt.
+const (
+	LoginFailed    = "failed"
+	LoginSucceeded = "succeeded"
+	LoginBlocked   = "blocked"  // rejected without checking credentials because of a lockout
+	LoginUnlocked  = "unlocked" // an admin lifted the lockout of the account
+)
+
+type LoginAttempt struct {
+	ID        int       `json:"id"  bson:"id"`
+	TenantID  int       `json:"tenant_id"  bson:"tenant_id"`
+	Email     string    `json:"email"  bson:"email"`
+	IPAddress string    `json:"ip_address"  bson:"ip_address"`
+	Outcome   string    `json:"outcome"  bson:"outcome"`
+	CreatedAt time.Time `json:"created_at"  bson:"created_at"`
+}
+
+// LoginFailures summarizes the failed attempts counted against an account or IP.
+type LoginFailures struct {
+	Count  int
+	LastAt *time.Time
+}
+
+type LoginAttemptFilter struct {
+	Email   string
+	Outcome string
+	Limit   int
+}
+
+type LoginAttemptStore interface {
+	RecordLoginAttempt(ctx context.Context, tenantID int, attempt LoginAttempt) error
+	// CountAccountFailures counts failed attempts for email made after since
+	// and after the account last logged in successfully or was unlocked.
+	CountAccountFailures(ctx context.Context, tenantID int, email string, since time.Time) (LoginFailures, error)
+	// CountIPFailures counts failed attempts from ip against any tenant made after since.
+	CountIPFailures(ctx context.Context, ip string, since time.Time) (LoginFailures, error)
+	GetLoginAttempts(ctx context.Context, tenantID int, filter LoginAttemptFilter) ([]LoginAttempt, error)
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "context"
+
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional emails such as password reset links.
+type Mailer interface {
+	Send(ctx context.Context, email Email) error
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type ForgotPasswordRequestBody struct {
+	Email string `json:"email,omitempty"  bson:"email"`
+}
+
+type ResetPasswordRequestBody struct {
+	Token    string `json:"token,omitempty"  bson:"token"`
+	Password string `json:"password,omitempty"  bson:"password"`
+}
+
+type PasswordResetStore interface {
+	// CreatePasswordResetToken stores a new reset token for the user,
+	// invalidating any token issued to them before.
+	CreatePasswordResetToken(ctx context.Context, tenantID int, userID int, tokenHash string, expiresAt time.Time) error
+	// ConsumePasswordResetToken marks an unexpired, unused token as used and
+	// returns the id of the user it was issued to.
+	ConsumePasswordResetToken(ctx context.Context, tenantID int, tokenHash string) (int, error)
+}
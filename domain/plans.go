@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Plans every tenant can be on. New tenants start on the starter plan.
+const (
+	PlanStarter   = "starter"
+	PlanGrowth    = "growth"
+	PlanUnlimited = "unlimited"
+)
+
+// Plan limits what a tenant may create. Nil limits are unlimited.
+type Plan struct {
+	Code              string    `json:"code"  bson:"code"`
+	Name              string    `json:"name"  bson:"name"`
+	MaxMembers        *int      `json:"max_members"  bson:"max_members"`                   // users in the member role
+	MaxClassesPerWeek *int      `json:"max_classes_per_week"  bson:"max_classes_per_week"` // classes starting in one week of the tenant
+	CreatedAt         time.Time `json:"created_at,omitempty"  bson:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at,omitempty"  bson:"updated_at"`
+}
+
+type PlanRequestBody struct {
+	Plan string `json:"plan"  bson:"plan"`
+}
+
+type PlanStore interface {
+	GetAllPlans(ctx context.Context) ([]Plan, error)
+	GetTenantPlan(ctx context.Context, tenantID int) (Plan, error)
+	SetTenantPlan(ctx context.Context, tenantID int, code string) (Tenant, error)
+	// CountMembers counts the users with a member role, see IsMemberRole, and
+	// the pending invitations to one.
+	CountMembers(ctx context.Context, tenantID int) (int, error)
+	// CountClassesBetween counts the classes starting at or after from and before to.
+	CountClassesBetween(ctx context.Context, tenantID int, from time.Time, to time.Time) (int, error)
+}
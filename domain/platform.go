@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RolePlatformOperator is the role of the people running gymulty. Operators
+// are not users of any tenant and act across all of them.
+const RolePlatformOperator = "platform_operator"
+
+type PlatformOperator struct {
+	ID          int        `json:"id,omitempty"  bson:"id"`
+	Email       string     `json:"email,omitempty"  bson:"email"`
+	Name        string     `json:"name,omitempty"  bson:"name"`
+	Password    string     `json:"-"  bson:"password"`
+	Role        string     `json:"role,omitempty"  bson:"role"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"  bson:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"  bson:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at,omitempty"  bson:"updated_at"`
+}
+
+type PlatformOperatorRequestBody struct {
+	Email    string `json:"email"  bson:"email"`
+	Name     string `json:"name"  bson:"name"`
+	Password string `json:"password"  bson:"password"`
+}
+
+// TenantUsage is a tenant together with how much it uses the platform.
+type TenantUsage struct {
+	Tenant
+	UserCount      int        `json:"user_count"  bson:"user_count"`
+	ClassCount     int        `json:"class_count"  bson:"class_count"`
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty"  bson:"last_activity_at"` // last use of a session or API key, nil if never used
+}
+
+// TenantFilter narrows the tenants listed to the platform operator.
+type TenantFilter struct {
+	Search string // matched against the business name and subdomain
+	Status string
+	Limit  int
+	Offset int
+}
+
+type PlatformStore interface {
+	CreatePlatformOperator(ctx context.Context, operator PlatformOperator) (PlatformOperator, error)
+	GetPlatformOperatorByEmail(ctx context.Context, email string) (PlatformOperator, error)
+	RecordPlatformLogin(ctx context.Context, operatorID int) error
+	GetTenantsUsage(ctx context.Context, filter TenantFilter) ([]TenantUsage, error)
+	GetTenantUsage(ctx context.Context, tenantID int) (TenantUsage, error)
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Built-in roles seeded for every tenant. Tenants may define further roles.
+const (
+	RoleAdmin   = "admin"
+	RoleTrainer = "trainer"
+	RoleMember  = "member"
+)
+
+const (
+	PermAll = "*" // granted to the built-in admin role only
+
+	PermUsersRead  = "users:read"
+	PermUsersWrite = "users:write"
+
+	PermClassesRead   = "classes:read"
+	PermClassesWrite  = "classes:write"  // create and delete classes the caller trains
+	PermClassesManage = "classes:manage" // create and delete any class in the tenant
+
+	PermRolesRead  = "roles:read"
+	PermRolesWrite = "roles:write"
+
+	PermSecurityRead = "security:read" // login attempts and other security events
+
+	PermAPIKeysManage = "api_keys:manage"
+
+	PermTenantManage = "tenant:manage" // change, deactivate and close the tenant
+
+	PermLocationsManage = "locations:manage"
+
+	PermDataExport = "data:export" // export all data of the tenant, users included
+)
+
+// Permissions lists every permission a tenant-defined role may be granted.
+var Permissions = []string{
+	PermUsersRead, PermUsersWrite,
+	PermClassesRead, PermClassesWrite, PermClassesManage,
+	PermRolesRead, PermRolesWrite,
+	PermSecurityRead,
+	PermAPIKeysManage,
+	PermTenantManage,
+	PermLocationsManage,
+	PermDataExport,
+}
+
+func IsValidPermission(perm string) bool {
+	return slices.Contains(Permissions, perm)
+}
+
+// DefaultRolePermissions maps each built-in role to the permissions it is
+// seeded with. Every user may additionally read and update their own profile.
+var DefaultRolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermAll,
+	},
+	RoleTrainer: {
+		PermUsersRead,
+		PermClassesRead, PermClassesWrite,
+	},
+	RoleMember: {
+		PermClassesRead,
+	},
+}
+
+// IsMemberRole reports whether users with a role granting perms count as
+// members against the plan of their tenant: that is any role granting nothing
+// beyond the built-in member role, whatever it is called.
+func IsMemberRole(perms []string) bool {
+	for _, perm := range perms {
+		if !slices.Contains(DefaultRolePermissions[RoleMember], perm) {
+			return false
+		}
+	}
+	return true
+}
+
+type Role struct {
+	ID          int       `json:"id,omitempty"  bson:"id"`
+	TenantID    int       `json:"tenant_id,omitempty"  bson:"tenant_id"`
+	Name        string    `json:"name,omitempty"  bson:"name"`
+	Description string    `json:"description,omitempty"  bson:"description"`
+	Permissions []string  `json:"permissions"  bson:"permissions"`
+	CreatedAt   time.Time `json:"created_at,omitempty"  bson:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"  bson:"updated_at"`
+}
+
+// RoleUpdate enables admins to update one or more fields of a role
+// fields not nil are updated
+type RoleUpdate struct {
+	Name        *string   `json:"name,omitempty"  bson:"name"`
+	Description *string   `json:"description,omitempty"  bson:"description"`
+	Permissions *[]string `json:"permissions,omitempty"  bson:"permissions"`
+}
+
+type RoleStore interface {
+	CreateRole(ctx context.Context, tenantID int, role Role) (Role, error)
+	GetRoleByID(ctx context.Context, tenantID int, roleID int) (Role, error)
+	GetRoleByName(ctx context.Context, tenantID int, name string) (Role, error)
+	UpdateRole(ctx context.Context, tenantID int, roleID int, updates RoleUpdate) (Role, error)
+	DeleteRoleByID(ctx context.Context, tenantID int, roleID int) error
+	GetAllRoles(ctx context.Context, tenantID int) ([]Role, error)
+}
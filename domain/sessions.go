@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRefreshTokenReused  = errors.New("session: refresh token reuse detected")
+	ErrInvalidRefreshToken = errors.New("session: refresh token is expired or revoked")
+)
+
+// Session is a login on one device. Its refresh token is rotated on every
+// use; presenting an already rotated token revokes the whole session.
+type Session struct {
+	ID         int        `json:"id,omitempty"  bson:"id"`
+	TenantID   int        `json:"tenant_id,omitempty"  bson:"tenant_id"`
+	UserID     int        `json:"user_id,omitempty"  bson:"user_id"`
+	UserAgent  string     `json:"user_agent,omitempty"  bson:"user_agent"`
+	IPAddress  string     `json:"ip_address,omitempty"  bson:"ip_address"`
+	ExpiresAt  time.Time  `json:"expires_at,omitempty"  bson:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"  bson:"revoked_at"`
+	LastUsedAt time.Time  `json:"last_used_at,omitempty"  bson:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"  bson:"created_at"`
+	Current    bool       `json:"current"  bson:"-"  db:"-"`
+}
+
+type SessionStore interface {
+	CreateSession(ctx context.Context, tenantID int, session Session, tokenHash string) (Session, error)
+	RotateRefreshToken(ctx context.Context, tenantID int, oldHash string, newHash string, expiresAt time.Time) (Session, error)
+	GetActiveSessions(ctx context.Context, tenantID int, userID int) ([]Session, error)
+	GetSessionByID(ctx context.Context, tenantID int, sessionID int) (Session, error)
+	RevokeSession(ctx context.Context, tenantID int, userID int, sessionID int) error
+	RevokeAllSessions(ctx context.Context, tenantID int, userID int) error
+	// RevokeOtherSessions revokes the sessions of a user except the given one.
+	RevokeOtherSessions(ctx context.Context, tenantID int, userID int, sessionID int) error
+}
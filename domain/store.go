@@ -1,7 +1,28 @@
 package domain
 
+import "context"
+
 type Store interface {
 	TenantStore
 	UserStore
 	ClassStore
+	RoleStore
+	SessionStore
+	PasswordResetStore
+	TwoFactorStore
+	LoginAttemptStore
+	APIKeyStore
+	InvitationStore
+	TenantSettingsStore
+	LocationStore
+	PlatformStore
+	PlanStore
+	ExportStore
+	DeletionStore
+
+	// WithTx runs fn in a single transaction, so that everything fn does
+	// through tx is committed together or not at all. fn is run again when
+	// the transaction conflicts with a concurrent one, so it must not have
+	// side effects other than through tx.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
 }
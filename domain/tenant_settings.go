@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// OpeningHours are the hours a gym is open on a day of the week, in the
+// local time of the tenant. Days with split hours have several entries.
+type OpeningHours struct {
+	Day    string `json:"day"  bson:"day"`       // lowercase weekday, e.g. "monday"
+	Opens  string `json:"opens"  bson:"opens"`   // "06:00"
+	Closes string `json:"closes"  bson:"closes"` // "22:00"
+}
+
+type TenantSettings struct {
+	TenantID     int            `json:"tenant_id,omitempty"  bson:"tenant_id"`
+	Timezone     string         `json:"timezone,omitempty"  bson:"timezone"` // IANA name, e.g. "Europe/Lisbon"
+	Locale       string         `json:"locale,omitempty"  bson:"locale"`     // BCP 47 tag, e.g. "pt-PT"
+	Currency     string         `json:"currency,omitempty"  bson:"currency"` // ISO 4217 code, e.g. "EUR"
+	WeekStart    string         `json:"week_start,omitempty"  bson:"week_start"`
+	OpeningHours []OpeningHours `json:"opening_hours"  bson:"opening_hours"`
+	CreatedAt    time.Time      `json:"created_at,omitempty"  bson:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at,omitempty"  bson:"updated_at"`
+}
+
+// TenantSettingsUpdate changes the settings whose fields are not nil.
+type TenantSettingsUpdate struct {
+	Timezone     *string         `json:"timezone,omitempty"  bson:"timezone"`
+	Locale       *string         `json:"locale,omitempty"  bson:"locale"`
+	Currency     *string         `json:"currency,omitempty"  bson:"currency"`
+	WeekStart    *string         `json:"week_start,omitempty"  bson:"week_start"`
+	OpeningHours *[]OpeningHours `json:"opening_hours,omitempty"  bson:"opening_hours"`
+}
+
+type TenantSettingsStore interface {
+	GetTenantSettings(ctx context.Context, tenantID int) (TenantSettings, error)
+	UpdateTenantSettings(ctx context.Context, tenantID int, updates TenantSettingsUpdate) (TenantSettings, error)
+}
@@ -5,13 +5,49 @@ import (
 	"time"
 )
 
+// A tenant is active until its admin deactivates it, which leaves it read
+// only, or the platform operator suspends it, which blocks it entirely.
+// Closing the account schedules its deletion: the tenant stays read only
+// until it is purged, unless it is restored first.
+const (
+	TenantActive          = "active"
+	TenantInactive        = "inactive"
+	TenantSuspended       = "suspended"
+	TenantPendingDeletion = "pending_deletion"
+)
+
+// Who asked for a tenant to be deleted. Deletions the platform operator
+// asked for block the tenant entirely and only the operator can restore it.
+const (
+	DeletionByTenant   = "tenant"
+	DeletionByPlatform = "platform"
+)
+
 type Tenant struct {
-	ID           int
-	BusinessName string
-	Subdomain    string
-	Status       string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID                         int        `json:"id,omitempty"  bson:"id"`
+	BusinessName               string     `json:"business_name,omitempty"  bson:"business_name"`
+	Subdomain                  string     `json:"subdomain,omitempty"  bson:"subdomain"`
+	Status                     string     `json:"status,omitempty"  bson:"status"`
+	StatusReason               string     `json:"status_reason,omitempty"  bson:"status_reason"`                                 // why the tenant was deactivated or suspended
+	StatusChangedAt            *time.Time `json:"status_changed_at,omitempty"  bson:"status_changed_at"`                         // nil while the status was never changed
+	VerifiedAt                 *time.Time `json:"verified_at,omitempty"  bson:"verified_at"`                                     // set once the admin who signed up confirms their email
+	RequireEmailVerification   bool       `json:"require_email_verification"  bson:"require_email_verification"`                 // users must confirm their email before they can log in
+	RequireAdminTwoFactor      bool       `json:"require_admin_two_factor"  bson:"require_admin_two_factor"`                     // admins must log in with a second factor
+	Plan                       string     `json:"plan,omitempty"  bson:"plan"`                                                   // code of the plan whose limits apply
+	DeletionRequestedBy        *string    `json:"deletion_requested_by,omitempty"  bson:"deletion_requested_by"`                 // set while the tenant is pending deletion
+	DeletionScheduledFor       *time.Time `json:"deletion_scheduled_for,omitempty"  bson:"deletion_scheduled_for"`               // when the tenant is purged unless restored
+	StatusBeforeDeletion       *string    `json:"status_before_deletion,omitempty"  bson:"status_before_deletion"`               // the status restoring the tenant returns it to
+	StatusReasonBeforeDeletion *string    `json:"status_reason_before_deletion,omitempty"  bson:"status_reason_before_deletion"` // and its reason
+	CreatedAt                  time.Time  `json:"created_at,omitempty"  bson:"created_at"`
+	UpdatedAt                  time.Time  `json:"updated_at,omitempty"  bson:"updated_at"`
+}
+
+// TenantUpdate holds the tenant fields an admin may change, fields not nil are updated
+type TenantUpdate struct {
+	BusinessName             *string `json:"business_name,omitempty"  bson:"business_name"`
+	Subdomain                *string `json:"subdomain,omitempty"  bson:"subdomain"`
+	RequireEmailVerification *bool   `json:"require_email_verification,omitempty"  bson:"require_email_verification"`
+	RequireAdminTwoFactor    *bool   `json:"require_admin_two_factor,omitempty"  bson:"require_admin_two_factor"`
 }
 
 type TenantRequestBody struct {
@@ -21,8 +57,29 @@ type TenantRequestBody struct {
 	LastName     string `json:"last_name,omitempty"  bson:"last_name"`
 	Email        string `json:"email,omitempty"  bson:"email"`
 	Password     string `json:"password,omitempty"  bson:"password"`
+
+	RequireEmailVerification bool `json:"require_email_verification,omitempty"  bson:"require_email_verification"`
+	RequireAdminTwoFactor    bool `json:"require_admin_two_factor,omitempty"  bson:"require_admin_two_factor"`
+}
+
+type TenantStatusRequestBody struct {
+	Reason string `json:"reason,omitempty"  bson:"reason"`
 }
 
 type TenantStore interface {
 	CreateTenant(ctx context.Context, data Tenant) (Tenant, error)
+	GetTenantByID(ctx context.Context, tenantID int) (Tenant, error)
+	GetTenantBySubdomain(ctx context.Context, subdomain string) (Tenant, error)
+	VerifyTenant(ctx context.Context, tenantID int) error
+	// UpdateTenantStatus moves the tenant to status provided its current status
+	// is one of from, and fails with sql.ErrNoRows otherwise.
+	UpdateTenantStatus(ctx context.Context, tenantID int, status string, reason string, from []string) (Tenant, error)
+	// ScheduleTenantDeletion moves the tenant to TenantPendingDeletion until
+	// scheduledFor, and fails with sql.ErrNoRows if it is pending deletion already.
+	ScheduleTenantDeletion(ctx context.Context, tenantID int, requestedBy string, reason string, scheduledFor time.Time) (Tenant, error)
+	// RestoreTenant cancels the deletion one of requestedBy asked for and returns
+	// the tenant to the status it had before, with reason if it is not empty.
+	// It fails with sql.ErrNoRows if no such deletion is pending.
+	RestoreTenant(ctx context.Context, tenantID int, reason string, requestedBy ...string) (Tenant, error)
+	UpdateTenant(ctx context.Context, tenantID int, updates TenantUpdate) (Tenant, error)
 }
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrTwoFactorCodeReused = errors.New("two factor: code was already used")
+
+// TwoFactor is a user's TOTP enrollment. It is pending until EnabledAt is set.
+type TwoFactor struct {
+	UserID       int        `json:"user_id"  bson:"user_id"`
+	TenantID     int        `json:"tenant_id"  bson:"tenant_id"`
+	Secret       string     `json:"-"  bson:"secret"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"  bson:"enabled_at"`
+	LastUsedStep *int64     `json:"-"  bson:"last_used_step"`
+	CreatedAt    time.Time  `json:"created_at"  bson:"created_at"`
+}
+
+type TwoFactorCodeRequestBody struct {
+	Code         string `json:"code,omitempty"  bson:"code"`
+	RecoveryCode string `json:"recovery_code,omitempty"  bson:"recovery_code"`
+}
+
+type TwoFactorLoginRequestBody struct {
+	TwoFactorToken string `json:"two_factor_token,omitempty"  bson:"two_factor_token"`
+	Code           string `json:"code,omitempty"  bson:"code"`
+	RecoveryCode   string `json:"recovery_code,omitempty"  bson:"recovery_code"`
+}
+
+type TwoFactorStore interface {
+	// SaveTwoFactorSecret starts a new pending enrollment for the user,
+	// replacing any enrollment that was not confirmed yet.
+	SaveTwoFactorSecret(ctx context.Context, tenantID int, userID int, secret string) error
+	GetTwoFactor(ctx context.Context, tenantID int, userID int) (TwoFactor, error)
+	// EnableTwoFactor confirms the pending enrollment and replaces the
+	// user's recovery codes with the given hashes.
+	EnableTwoFactor(ctx context.Context, tenantID int, userID int, recoveryCodeHashes []string) error
+	DisableTwoFactor(ctx context.Context, tenantID int, userID int) error
+	ReplaceRecoveryCodes(ctx context.Context, tenantID int, userID int, recoveryCodeHashes []string) error
+	// ConsumeRecoveryCode marks an unused recovery code as used.
+	ConsumeRecoveryCode(ctx context.Context, tenantID int, userID int, codeHash string) error
+	// UseTwoFactorStep records the time step of an accepted code, failing with
+	// ErrTwoFactorCodeReused if that step or a later one was already used.
+	UseTwoFactorStep(ctx context.Context, tenantID int, userID int, step int64) error
+}
@@ -6,25 +6,27 @@ import (
 )
 
 type User struct {
-	ID        int       `json:"id,omitempty"  bson:"id"`
-	TenantID  int       `json:"tenant_id,omitempty"  bson:"tenant_id"`
-	FirstName string    `json:"first_name,omitempty"  bson:"firstname"`
-	LastName  string    `json:"last_name,omitempty"  bson:"lastname"`
-	Email     string    `json:"email,omitempty"  bson:"email"`
-	Password  string    `json:"password,omitempty"  bson:"password"`
-	Role      string    `json:"role,omitempty"  bson:"role"`
-	CreatedAt time.Time `json:"created_at,omitempty"  bson:"created_at"`
-	UpdatedAt time.Time `json:"updated_at,omitempty"  bson:"updated_at"`
+	ID         int        `json:"id,omitempty"  bson:"id"`
+	TenantID   int        `json:"tenant_id,omitempty"  bson:"tenant_id"`
+	FirstName  string     `json:"first_name,omitempty"  bson:"firstname"`
+	LastName   string     `json:"last_name,omitempty"  bson:"lastname"`
+	Email      string     `json:"email,omitempty"  bson:"email"`
+	Password   string     `json:"password,omitempty"  bson:"password"`
+	Role       string     `json:"role,omitempty"  bson:"role"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"  bson:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"  bson:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"  bson:"updated_at"`
 }
 
 type PublicUser struct {
-	ID        int       `json:"id,omitempty"  bson:"id"`
-	TenantID  int       `json:"tenant_id,omitempty"  bson:"tenant_id"`
-	FirstName string    `json:"first_name,omitempty"  bson:"firstname"`
-	LastName  string    `json:"last_name,omitempty"  bson:"lastname"`
-	Role      string    `json:"role,omitempty"  bson:"role"`
-	CreatedAt time.Time `json:"created_at,omitempty"  bson:"created_at"`
-	UpdatedAt time.Time `json:"updated_at,omitempty"  bson:"updated_at"`
+	ID         int        `json:"id,omitempty"  bson:"id"`
+	TenantID   int        `json:"tenant_id,omitempty"  bson:"tenant_id"`
+	FirstName  string     `json:"first_name,omitempty"  bson:"firstname"`
+	LastName   string     `json:"last_name,omitempty"  bson:"lastname"`
+	Role       string     `json:"role,omitempty"  bson:"role"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"  bson:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"  bson:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"  bson:"updated_at"`
 }
 
 // UserUpdates enables user to update one or more fields
@@ -35,12 +37,24 @@ type UserUpdate struct {
 	Email     *string `json:"email,omitempty"  bson:"email"`
 	Role      *string `json:"role,omitempty"  bson:"role"`
 	Password  *string `json:"password,omitempty"  bson:"password"`
+
+	// CurrentPassword must accompany a user changing their own password.
+	CurrentPassword *string `json:"current_password,omitempty"  bson:"-"`
 }
 
 type UserStore interface {
 	CreateUser(ctx context.Context, tenantID int, user User) (User, error)
 	GetUserByID(ctx context.Context, tenantID int, userID int) (User, error)
+	GetUserByEmail(ctx context.Context, tenantID int, email string) (User, error)
+	// GetTakenEmails returns those of emails, in lowercase, that users_email_key
+	// holds already. Outside the schema per tenant mode, this includes the
+	// users of other tenants, as they share the key.
+	GetTakenEmails(ctx context.Context, tenantID int, emails []string) ([]string, error)
+	VerifyUserEmail(ctx context.Context, tenantID int, userID int) (User, error)
 	UpdateUser(ctx context.Context, tenantID int, userID int, updates UserUpdate) (User, error)
 	DeleteUserByID(ctx context.Context, tenantID int, userID int) error
 	GetAllUsers(ctx context.Context, tenantID int) ([]User, error)
+	GetUsersByLocation(ctx context.Context, tenantID int, locationID int) ([]User, error)
+	GetUserLocations(ctx context.Context, tenantID int, userID int) ([]Location, error)
+	SetUserLocations(ctx context.Context, tenantID int, userID int, locationIDs []int) ([]Location, error)
 }
@@ -2,5 +2,5 @@ package gymulty
 
 import "embed"
 
-//go:embed postgres/migrations/*.sql
+//go:embed postgres/migrations/*.sql postgres/migrations/tenant/*.sql
 var EmbedMigrations embed.FS
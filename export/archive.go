@@ -0,0 +1,168 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+// user is a domain.User without the password, which must never be exported.
+type user struct {
+	ID         int        `json:"id"`
+	TenantID   int        `json:"tenant_id"`
+	FirstName  string     `json:"first_name"`
+	LastName   string     `json:"last_name"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// WriteArchive writes records as a zip archive holding a JSON and a CSV file
+// per entity, e.g. users.json and users.csv. The CSV columns are named after
+// the JSON fields; lists and objects within a record are JSON encoded.
+func WriteArchive(w io.Writer, records domain.TenantRecords) error {
+	users := make([]user, len(records.Users))
+	for i, u := range records.Users {
+		users[i] = user{
+			ID:         u.ID,
+			TenantID:   u.TenantID,
+			FirstName:  u.FirstName,
+			LastName:   u.LastName,
+			Email:      u.Email,
+			Role:       u.Role,
+			VerifiedAt: u.VerifiedAt,
+			CreatedAt:  u.CreatedAt,
+			UpdatedAt:  u.UpdatedAt,
+		}
+	}
+
+	entities := []struct {
+		name string
+		rows any
+	}{
+		{"tenant", []domain.Tenant{records.Tenant}},
+		{"settings", []domain.TenantSettings{records.Settings}},
+		{"users", users},
+		{"roles", records.Roles},
+		{"locations", records.Locations},
+		{"classes", records.Classes},
+		{"class_locations", records.ClassLocations},
+		{"user_locations", records.UserLocations},
+		{"invitations", records.Invitations},
+		{"api_keys", records.APIKeys},
+		{"sessions", records.Sessions},
+		{"login_attempts", records.LoginAttempts},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, entity := range entities {
+		if err := writeJSON(archive, entity.name+".json", entity.rows); err != nil {
+			return err
+		}
+		if err := writeCSV(archive, entity.name+".csv", entity.rows); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func writeJSON(archive *zip.Writer, name string, rows any) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+// writeCSV writes rows, a slice of structs, with a header row of the JSON
+// names of their fields. Fields left out of JSON are left out here too.
+func writeCSV(archive *zip.Writer, name string, rows any) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	out := csv.NewWriter(f)
+
+	slice := reflect.ValueOf(rows)
+	fields := csvFields(slice.Type().Elem())
+
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = field.name
+	}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	for i := 0; i < slice.Len(); i++ {
+		row := slice.Index(i)
+		record := make([]string, len(fields))
+		for j, field := range fields {
+			record[j], err = csvValue(row.Field(field.index))
+			if err != nil {
+				return fmt.Errorf("export: %s.%s: %w", name, field.name, err)
+			}
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+type csvField struct {
+	index int
+	name  string
+}
+
+func csvFields(t reflect.Type) []csvField {
+	var fields []csvField
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "-" || !t.Field(i).IsExported() {
+			continue
+		}
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		fields = append(fields, csvField{index: i, name: name})
+	}
+	return fields
+}
+
+func csvValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Time:
+		return value.UTC().Format(time.RFC3339), nil
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case int:
+		return strconv.Itoa(value), nil
+	default:
+		b, err := json.Marshal(value)
+		return string(b), err
+	}
+}
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Disk keeps archives as files in a local directory, which is created with
+// the first archive.
+type Disk struct {
+	dir string
+}
+
+func NewDisk(dir string) *Disk {
+	return &Disk{dir: dir}
+}
+
+// ArchiveName names the archive of an export.
+func ArchiveName(tenantID int, exportID int) string {
+	return fmt.Sprintf("tenant-%d-export-%d.zip", tenantID, exportID)
+}
+
+// Save writes the file name with write. It only appears under its name once
+// write succeeded, so a failed export never leaves a partial archive behind.
+func (d *Disk) Save(name string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(d.dir, 0o700); err != nil {
+		return fmt.Errorf("error creating export directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(d.dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(d.dir, name))
+}
+
+func (d *Disk) Open(name string) (*os.File, error) {
+	return os.Open(filepath.Join(d.dir, name))
+}
+
+// Remove deletes the file name. Files that are already gone are no error.
+func (d *Disk) Remove(name string) error {
+	err := os.Remove(filepath.Join(d.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// RemoveTenant deletes the archives of every export of the tenant.
+func (d *Disk) RemoveTenant(tenantID int) error {
+	names, err := filepath.Glob(filepath.Join(d.dir, fmt.Sprintf("tenant-%d-export-*", tenantID)))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, name := range names {
+		errs = append(errs, d.Remove(filepath.Base(name)))
+	}
+	return errors.Join(errs...)
+}
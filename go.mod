@@ -2,7 +2,10 @@ module github.com/emanuelquerty/gymulty
 
 go 1.22.2
 
-require github.com/stretchr/testify v1.10.0
+require (
+	github.com/stretchr/testify v1.10.0
+	golang.org/x/text v0.21.0
+)
 
 require (
 	github.com/jackc/pgpassfile v1.0.0 // indirect
@@ -14,7 +17,6 @@ require (
 	github.com/sethvargo/go-retry v0.3.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
 )
 
 require (
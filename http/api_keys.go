@@ -0,0 +1,180 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+// apiKeyPrefix starts every API key so Authenticate can tell keys from
+// access tokens and leaked keys are easy to search for.
+const apiKeyPrefix = "gym_"
+
+var ErrInvalidAPIKey = errors.New("api key: invalid api key")
+
+type APIKeyHandler struct {
+	http.Handler
+	store  domain.APIKeyStore
+	logger *slog.Logger
+}
+
+func NewAPIKeyHandler(logger *slog.Logger, store domain.APIKeyStore) *APIKeyHandler {
+	router := http.NewServeMux()
+
+	handler := &APIKeyHandler{
+		Handler: middleware.StripSlashes(router),
+		store:   store,
+		logger:  logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (h *APIKeyHandler) registerRoutes(router *http.ServeMux) {
+	manageKeys := Allow(domain.PermAPIKeysManage)
+
+	router.Handle("POST /api/tenants/{tenantID}/api-keys", authorize(h.logger, manageKeys, verifiedTenant(h.logger, h.createAPIKey)))
+	router.Handle("GET /api/tenants/{tenantID}/api-keys", authorize(h.logger, manageKeys, h.getAllAPIKeys))
+	router.Handle("DELETE /api/tenants/{tenantID}/api-keys/{keyID}", authorize(h.logger, manageKeys, h.revokeAPIKey))
+}
+
+// createAPIKey responds with the new key. It is not stored and cannot be
+// retrieved again.
+func (h *APIKeyHandler) createAPIKey(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.APIKeyRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	if strings.TrimSpace(body.Name) == "" {
+		return e.withContext(ErrInvalidAPIKey, ErrMsgInvalidAPIKeyName, ErrStatusBadRequest)
+	}
+	if appErr := validatePermissions(e, body.Scopes); appErr != nil {
+		return appErr
+	}
+	// a key must not be able to mint further keys
+	if slices.Contains(body.Scopes, domain.PermAPIKeysManage) {
+		return e.withContext(ErrInvalidAPIKey, ErrMsgInvalidAPIKeyScope, ErrStatusBadRequest)
+	}
+	// nor do more than whoever created it
+	if !canGrant(p, body.Scopes) {
+		return e.withContext(ErrPermissionDenied, ErrMsgAPIKeyScopeNotHeld, ErrStatusForbidden)
+	}
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		return e.withContext(ErrInvalidAPIKey, ErrMsgInvalidAPIKeyExpiry, ErrStatusBadRequest)
+	}
+
+	secret, prefix, hash, err := NewAPIKey()
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	key := domain.APIKey{
+		Name:      body.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    body.Scopes,
+		ExpiresAt: body.ExpiresAt,
+	}
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+	if p.UserID != 0 {
+		key.CreatedBy = &p.UserID
+	}
+
+	key, err = h.store.CreateAPIKey(r.Context(), tenantID, key)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	resourceURI := fmt.Sprintf("%s://%s%s/%d", r.URL.Scheme, r.Host, r.URL.String(), key.ID)
+	w.Header().Set("Location", resourceURI)
+	w.Header().Set("Cache-Control", "no-store")
+
+	w.WriteHeader(http.StatusCreated)
+	res := Response[APIKeyCreatedResponse]{Count: 1, Data: APIKeyCreatedResponse{APIKey: key, Key: secret}}
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *APIKeyHandler) getAllAPIKeys(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	keys, err := h.store.GetAllAPIKeys(r.Context(), tenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[[]domain.APIKey]{
+		Count: len(keys),
+		Data:  keys,
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *APIKeyHandler) revokeAPIKey(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	keyID, err := strconv.Atoi(r.PathValue("keyID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	err = h.store.RevokeAPIKey(r.Context(), tenantID, keyID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// NewAPIKey returns a random API key, the prefix shown to identify it and
+// the hash that should be persisted in its place.
+func NewAPIKey() (string, string, string, error) {
+	token, _, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+	key := apiKeyPrefix + token
+	return key, key[:len(apiKeyPrefix)+8], HashToken(key), nil
+}
+
+func isAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+func apiKeyActive(key domain.APIKey, now time.Time) bool {
+	if key.RevokedAt != nil {
+		return false
+	}
+	return key.ExpiresAt == nil || now.Before(*key.ExpiresAt)
+}
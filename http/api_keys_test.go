@@ -0,0 +1,121 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	t.Run("returns the key once and stores only its hash", func(t *testing.T) {
+		var stored domain.APIKey
+		store := new(mock.APIKeyStore)
+		store.CreateAPIKeyFn = func(ctx context.Context, tenantID int, key domain.APIKey) (domain.APIKey, error) {
+			key.ID = 3
+			key.TenantID = tenantID
+			stored = key
+			return key, nil
+		}
+
+		body := `{"name":"Door access","scopes":["users:read"]}`
+		req := httptest.NewRequest("POST", "/api/tenants/1/api-keys", strings.NewReader(body))
+		res := newAPIKeyRequest(store, req)
+
+		var got Response[APIKeyCreatedResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.True(t, strings.HasPrefix(got.Data.Key, apiKeyPrefix), "key should be prefixed")
+		assert.True(t, strings.HasPrefix(got.Data.Key, stored.Prefix), "prefix should identify the key")
+		assert.Equal(t, HashToken(got.Data.Key), stored.KeyHash, "only the hash should be stored")
+		assert.Equal(t, []string{domain.PermUsersRead}, stored.Scopes, "scopes should be equal")
+		assert.Equal(t, 1, *stored.CreatedBy, "creator should be recorded")
+		assert.NotContains(t, res.Body.String(), stored.KeyHash, "hash should not be returned")
+	})
+
+	t.Run("returns 400 status code for invalid keys", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+		bodies := []string{
+			`{"scopes":["users:read"]}`,
+			`{"name":"x","scopes":["users:delete"]}`,
+			`{"name":"x","scopes":["*"]}`,
+			`{"name":"x","scopes":["api_keys:manage"]}`,
+			`{"name":"x","expires_at":"` + past + `"}`,
+		}
+		for _, body := range bodies {
+			store := new(mock.APIKeyStore)
+			req := httptest.NewRequest("POST", "/api/tenants/1/api-keys", strings.NewReader(body))
+			res := newAPIKeyRequest(store, req)
+
+			assert.Equal(t, 400, res.Code, "status codes should be equal for %s", body)
+		}
+	})
+
+	t.Run("returns 403 status code for scopes the creator does not hold", func(t *testing.T) {
+		keyManager := Principal{UserID: 4, TenantID: 1, Role: "integrations", Permissions: []string{domain.PermAPIKeysManage, domain.PermUsersRead}}
+		for _, scopes := range []string{`["users:write"]`, `["users:read","roles:write"]`, `["tenant:manage"]`} {
+			var created bool
+			store := new(mock.APIKeyStore)
+			store.CreateAPIKeyFn = func(ctx context.Context, tenantID int, key domain.APIKey) (domain.APIKey, error) {
+				created = true
+				return key, nil
+			}
+
+			req := httptest.NewRequest("POST", "/api/tenants/1/api-keys", strings.NewReader(`{"name":"x","scopes":`+scopes+`}`))
+			res := newAPIKeyRequest(store, asPrincipal(req, keyManager))
+
+			assertPermissionDenied(t, res)
+			assert.False(t, created, "key should not be created for %s", scopes)
+		}
+	})
+
+	t.Run("trainer cannot create api keys", func(t *testing.T) {
+		store := new(mock.APIKeyStore)
+		req := httptest.NewRequest("POST", "/api/tenants/1/api-keys", strings.NewReader(`{"name":"x"}`))
+		res := newAPIKeyRequest(store, asPrincipal(req, testPrincipal(3, domain.RoleTrainer)))
+
+		assertPermissionDenied(t, res)
+	})
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	t.Run("revokes the key", func(t *testing.T) {
+		var revoked int
+		store := new(mock.APIKeyStore)
+		store.RevokeAPIKeyFn = func(ctx context.Context, tenantID int, keyID int) error {
+			revoked = keyID
+			return nil
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/api-keys/3", nil)
+		res := newAPIKeyRequest(store, req)
+
+		assert.Equal(t, 204, res.Code, "status codes should be equal")
+		assert.Equal(t, 3, revoked, "key ids should be equal")
+	})
+}
+
+func TestAPIKeyPrincipalPolicies(t *testing.T) {
+	t.Run("api keys cannot manage sessions", func(t *testing.T) {
+		store := new(mock.SessionStore)
+		req := httptest.NewRequest("GET", "/api/tenants/1/me/sessions", nil)
+		res := newSessionRequest(store, asPrincipal(req, Principal{TenantID: 1, APIKeyID: 5}))
+
+		assertPermissionDenied(t, res)
+	})
+}
+
+func newAPIKeyRequest(store domain.APIKeyStore, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewAPIKeyHandler(slog.Default(), store)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, withDefaultPrincipal(req))
+	return res
+}
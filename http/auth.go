@@ -0,0 +1,604 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("auth: invalid email or password")
+	ErrInvalidResetToken  = errors.New("auth: invalid password reset token")
+	ErrWeakPassword       = errors.New("auth: password too short")
+	ErrWrongPassword      = errors.New("auth: current password does not match")
+	ErrInvalidEmail       = errors.New("auth: invalid email address")
+	ErrEmailNotVerified   = errors.New("auth: email address not verified")
+	ErrLockedOut          = errors.New("auth: too many failed login attempts")
+)
+
+const (
+	passwordResetTTL  = time.Hour
+	minPasswordLength = 8
+
+	purposeTwoFactor  = "two_factor"
+	twoFactorTokenTTL = 5 * time.Minute
+)
+
+// dummyPasswordHash is compared against when no user matches the given email
+// so that unknown and known emails take roughly the same time to reject.
+const dummyPasswordHash = "$2a$10$f/BlWgpbtlfcRLkIrvm3HO22dvO1B8DmQLde4Zmtm4jsuxjLFj8bC"
+
+type AuthHandler struct {
+	http.Handler
+	store    domain.Store
+	tokens   *TokenManager
+	verifier *EmailVerifier
+	lockout  *Lockout
+	quotas   *Quotas
+	mailer   domain.Mailer
+	appURL   string
+	logger   *slog.Logger
+}
+
+func NewAuthHandler(logger *slog.Logger, store domain.Store, tokens *TokenManager, quotas *Quotas, mailer domain.Mailer, appURL string) *AuthHandler {
+	router := http.NewServeMux()
+	handler := &AuthHandler{
+		Handler:  middleware.StripSlashes(router),
+		store:    store,
+		tokens:   tokens,
+		verifier: NewEmailVerifier(tokens, mailer, appURL),
+		lockout:  NewLockout(store),
+		quotas:   quotas,
+		mailer:   mailer,
+		appURL:   appURL,
+		logger:   logger,
+	}
+
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (a *AuthHandler) registerRoutes(router *http.ServeMux) {
+	router.Handle("POST /api/tenants/{tenantID}/auth/login", errorHandler(a.login))
+	router.Handle("POST /api/tenants/{tenantID}/auth/refresh", errorHandler(a.refresh))
+	router.Handle("POST /api/tenants/{tenantID}/auth/password/forgot", errorHandler(a.forgotPassword))
+	router.Handle("POST /api/tenants/{tenantID}/auth/password/reset", errorHandler(a.resetPassword))
+	router.Handle("POST /api/tenants/{tenantID}/auth/verify-email", errorHandler(a.verifyEmail))
+	router.Handle("POST /api/tenants/{tenantID}/auth/verify-email/resend", errorHandler(a.resendVerification))
+	router.Handle("POST /api/tenants/{tenantID}/auth/invitations/accept", errorHandler(a.acceptInvitation))
+	router.Handle("POST /api/tenants/{tenantID}/auth/two-factor/enroll", errorHandler(a.enrollTwoFactor))
+	router.Handle("POST /api/tenants/{tenantID}/auth/two-factor/verify", errorHandler(a.verifyTwoFactor))
+}
+
+func (a *AuthHandler) login(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: a.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.LoginRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+	if body.Email == "" || body.Password == "" {
+		return e.withContext(ErrInvalidCredentials, ErrMsgMissingCredentials, ErrStatusBadRequest)
+	}
+
+	if appErr := a.checkLockout(w, r, e, tenantID, body.Email); appErr != nil {
+		return appErr
+	}
+
+	user, err := a.store.GetUserByEmail(r.Context(), tenantID, body.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		CheckPassword(dummyPasswordHash, body.Password)
+		a.recordAttempt(r, tenantID, body.Email, domain.LoginFailed)
+		return e.withContext(ErrInvalidCredentials, ErrMsgInvalidCredentials, ErrStatusUnauthorized)
+	}
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	if !CheckPassword(user.Password, body.Password) {
+		a.recordAttempt(r, tenantID, body.Email, domain.LoginFailed)
+		return e.withContext(ErrInvalidCredentials, ErrMsgInvalidCredentials, ErrStatusUnauthorized)
+	}
+
+	tenant, err := a.store.GetTenantByID(r.Context(), tenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	if tenantBlocked(tenant) {
+		return e.withContext(ErrTenantSuspended, ErrMsgTenantSuspended, ErrStatusForbidden)
+	}
+	if user.VerifiedAt == nil && tenant.RequireEmailVerification {
+		return e.withContext(ErrEmailNotVerified, ErrMsgEmailNotVerified, ErrStatusForbidden)
+	}
+
+	twoFactor, err := a.store.GetTwoFactor(r.Context(), tenantID, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	enabled := err == nil && twoFactor.EnabledAt != nil
+
+	if enabled || (tenant.RequireAdminTwoFactor && user.Role == domain.RoleAdmin) {
+		return a.challengeTwoFactor(w, e, user, !enabled)
+	}
+
+	a.recordAttempt(r, tenantID, user.Email, domain.LoginSucceeded)
+	return a.startSession(w, r, e, user, nil)
+}
+
+// checkLockout rejects the attempt with 429 while email or the client IP is locked out.
+func (a *AuthHandler) checkLockout(w http.ResponseWriter, r *http.Request, e *appError, tenantID int, email string) *appError {
+	wait, err := a.lockout.Check(r.Context(), tenantID, email, clientIP(r))
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	a.recordAttempt(r, tenantID, email, domain.LoginBlocked)
+	w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())))
+	return e.withContext(ErrLockedOut, ErrMsgTooManyAttempts, ErrStatusTooManyRequests)
+}
+
+// recordAttempt records a login attempt. Failing to do so is logged but does
+// not fail the request.
+func (a *AuthHandler) recordAttempt(r *http.Request, tenantID int, email string, outcome string) {
+	err := a.lockout.Record(r.Context(), tenantID, email, clientIP(r), outcome)
+	if err != nil {
+		a.logger.Error("recording login attempt", slog.String("error", err.Error()))
+	}
+}
+
+// challengeTwoFactor answers a login that needs a second factor with a
+// short-lived token to present alongside the code, instead of a session.
+func (a *AuthHandler) challengeTwoFactor(w http.ResponseWriter, e *appError, user domain.User, enrollmentRequired bool) *appError {
+	token, err := a.tokens.IssueFor(purposeTwoFactor, user, twoFactorTokenTTL)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[TwoFactorChallengeResponse]{
+		Count: 1,
+		Data: TwoFactorChallengeResponse{
+			TwoFactorRequired:  true,
+			EnrollmentRequired: enrollmentRequired,
+			TwoFactorToken:     token,
+			ExpiresIn:          int(twoFactorTokenTTL.Seconds()),
+		},
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// enrollTwoFactor lets an admin who is required to use a second factor but
+// has none yet set one up in the middle of logging in.
+func (a *AuthHandler) enrollTwoFactor(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: a.logger}
+
+	var body domain.TwoFactorLoginRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	user, appErr := a.twoFactorUser(r, e, body.TwoFactorToken)
+	if appErr != nil {
+		return appErr
+	}
+	if appErr := a.checkTenantWritable(r, e, user.TenantID); appErr != nil {
+		return appErr
+	}
+
+	enrollment, err := enrollTwoFactor(r.Context(), a.store, user)
+	if err != nil {
+		return twoFactorError(e, err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(Response[TwoFactorEnrollmentResponse]{Count: 1, Data: enrollment})
+	return nil
+}
+
+// verifyTwoFactor completes a login with a TOTP or recovery code. If the
+// enrollment was still pending it is confirmed and the recovery codes are
+// returned along with the tokens.
+func (a *AuthHandler) verifyTwoFactor(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: a.logger}
+
+	var body domain.TwoFactorLoginRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	user, appErr := a.twoFactorUser(r, e, body.TwoFactorToken)
+	if appErr != nil {
+		return appErr
+	}
+
+	// codes are guessable too, so they count against the same lockout as passwords
+	if appErr := a.checkLockout(w, r, e, user.TenantID, user.Email); appErr != nil {
+		return appErr
+	}
+
+	twoFactor, err := a.store.GetTwoFactor(r.Context(), user.TenantID, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return e.withContext(ErrTwoFactorNotEnrolled, ErrMsgTwoFactorNotEnrolled, ErrStatusBadRequest)
+	}
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	var recoveryCodes []string
+	if twoFactor.EnabledAt == nil {
+		recoveryCodes, err = confirmTwoFactor(r.Context(), a.store, user.TenantID, user.ID, body.Code)
+	} else {
+		err = checkSecondFactor(r.Context(), a.store, twoFactor, body.Code, body.RecoveryCode)
+	}
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		a.recordAttempt(r, user.TenantID, user.Email, domain.LoginFailed)
+		return e.withContext(err, ErrMsgInvalidTwoFactorCode, ErrStatusUnauthorized)
+	}
+	if err != nil {
+		return twoFactorError(e, err)
+	}
+
+	a.recordAttempt(r, user.TenantID, user.Email, domain.LoginSucceeded)
+	return a.startSession(w, r, e, user, recoveryCodes)
+}
+
+// twoFactorUser returns the user a two-factor login token was issued to.
+func (a *AuthHandler) twoFactorUser(r *http.Request, e *appError, token string) (domain.User, *appError) {
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return domain.User{}, e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	claims, err := a.tokens.VerifyFor(purposeTwoFactor, token)
+	if err != nil || claims.TenantID != tenantID {
+		return domain.User{}, e.withContext(ErrInvalidToken, ErrMsgInvalidTwoFactorToken, ErrStatusUnauthorized)
+	}
+
+	user, err := a.store.GetUserByID(r.Context(), tenantID, claims.UserID)
+	if err != nil {
+		return domain.User{}, e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	return user, nil
+}
+
+func (a *AuthHandler) refresh(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: a.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.RefreshRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+	if body.RefreshToken == "" {
+		return e.withContext(domain.ErrInvalidRefreshToken, ErrMsgInvalidRefreshToken, ErrStatusBadRequest)
+	}
+
+	refreshToken, refreshHash, err := NewOpaqueToken()
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	oldHash := HashToken(body.RefreshToken)
+	session, err := a.store.RotateRefreshToken(r.Context(), tenantID, oldHash, refreshHash, a.tokens.RefreshExpiry())
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, domain.ErrInvalidRefreshToken):
+		return e.withContext(domain.ErrInvalidRefreshToken, ErrMsgInvalidRefreshToken, ErrStatusUnauthorized)
+	case errors.Is(err, domain.ErrRefreshTokenReused):
+		return e.withContext(err, ErrMsgInvalidRefreshToken, ErrStatusUnauthorized)
+	case err != nil:
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	user, err := a.store.GetUserByID(r.Context(), tenantID, session.UserID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	return a.writeTokens(w, e, user, session.ID, refreshToken, nil)
+}
+
+// forgotPassword emails a single-use reset link. It answers 202 whether or
+// not the email belongs to a user so it cannot be used to probe for accounts.
+func (a *AuthHandler) forgotPassword(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: a.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.ForgotPasswordRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+	if body.Email == "" {
+		return e.withContext(ErrInvalidCredentials, ErrMsgMissingEmail, ErrStatusBadRequest)
+	}
+
+	user, err := a.store.GetUserByEmail(r.Context(), tenantID, body.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	if err == nil {
+		token, tokenHash, err := NewOpaqueToken()
+		if err != nil {
+			return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+		}
+
+		expiresAt := time.Now().Add(passwordResetTTL)
+		err = a.store.CreatePasswordResetToken(r.Context(), tenantID, user.ID, tokenHash, expiresAt)
+		if err != nil {
+			return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+		}
+
+		err = a.mailer.Send(r.Context(), passwordResetEmail(a.appURL, user, token))
+		if err != nil {
+			a.logger.Error("sending password reset email", slog.String("error", err.Error()))
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+func (a *AuthHandler) resetPassword(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: a.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.ResetPasswordRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+	if len(body.Password) < minPasswordLength {
+		return e.withContext(ErrWeakPassword, ErrMsgWeakPassword, ErrStatusBadRequest)
+	}
+	if appErr := a.checkTenantWritable(r, e, tenantID); appErr != nil {
+		return appErr
+	}
+
+	hash, err := HashPassword(body.Password)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	// the token is only used up if the password actually changes
+	err = a.store.WithTx(r.Context(), func(tx domain.Store) error {
+		userID, err := tx.ConsumePasswordResetToken(r.Context(), tenantID, HashToken(body.Token))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.UpdateUser(r.Context(), tenantID, userID, domain.UserUpdate{Password: &hash})
+		if err != nil {
+			return err
+		}
+
+		// whoever knew the old password must not stay logged in
+		return tx.RevokeAllSessions(r.Context(), tenantID, userID)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return e.withContext(ErrInvalidResetToken, ErrMsgInvalidResetToken, ErrStatusBadRequest)
+	}
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// verifyEmail confirms the email address of the user a verification link
+// was sent to. Verifying the admin who signed up also verifies the tenant.
+func (a *AuthHandler) verifyEmail(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: a.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.VerifyEmailRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	claims, err := a.verifier.Verify(body.Token)
+	if err != nil || claims.TenantID != tenantID {
+		return e.withContext(ErrInvalidToken, ErrMsgInvalidVerificationToken, ErrStatusBadRequest)
+	}
+
+	user, err := a.store.GetUserByID(r.Context(), tenantID, claims.UserID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	// links sent before an email change must not verify the new address
+	if user.Email != claims.Email {
+		return e.withContext(ErrInvalidToken, ErrMsgInvalidVerificationToken, ErrStatusBadRequest)
+	}
+
+	err = a.store.WithTx(r.Context(), func(tx domain.Store) error {
+		user, err := tx.VerifyUserEmail(r.Context(), tenantID, user.ID)
+		if err != nil || user.Role != domain.RoleAdmin {
+			return err
+		}
+		return tx.VerifyTenant(r.Context(), tenantID)
+	})
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// resendVerification emails a new verification link to an unverified user.
+// Like forgotPassword it always answers 202.
+func (a *AuthHandler) resendVerification(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: a.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.ResendVerificationRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+	if body.Email == "" {
+		return e.withContext(ErrInvalidCredentials, ErrMsgMissingEmail, ErrStatusBadRequest)
+	}
+
+	user, err := a.store.GetUserByEmail(r.Context(), tenantID, body.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	if err == nil && user.VerifiedAt == nil {
+		err = a.verifier.Send(r.Context(), user)
+		if err != nil {
+			a.logger.Error("sending verification email", slog.String("error", err.Error()))
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+// acceptInvitation creates the invited user with the profile and password
+// they picked. The email and role are those of the invitation, and so are
+// the names they leave out.
+func (a *AuthHandler) acceptInvitation(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: a.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.AcceptInvitationRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+	if len(body.Password) < minPasswordLength {
+		return e.withContext(ErrWeakPassword, ErrMsgWeakPassword, ErrStatusBadRequest)
+	}
+	if appErr := a.checkTenantWritable(r, e, tenantID); appErr != nil {
+		return appErr
+	}
+
+	hash, err := HashPassword(body.Password)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	user := domain.User{
+		TenantID:  tenantID,
+		FirstName: body.FirstName,
+		LastName:  body.LastName,
+		Password:  hash,
+	}
+	var accepted domain.User
+	err = a.store.WithTx(r.Context(), func(tx domain.Store) error {
+		accepted, err = tx.AcceptInvitation(r.Context(), tenantID, HashToken(body.Token), user)
+		if err != nil {
+			return err
+		}
+		// the pending invitation is counted already, so this only fails for
+		// tenants over their limit, e.g. after moving to a smaller plan
+		return a.quotas.CheckRole(r.Context(), tx, tenantID, accepted.Role, 0)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return e.withContext(ErrInvitationNotFound, ErrMsgInvalidInvitationToken, ErrStatusBadRequest)
+	}
+	if err != nil {
+		return quotaError(e, err, ErrMsgInvitationQuotaExceeded)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	res := Response[[]domain.PublicUser]{Count: 1, Data: []domain.PublicUser{MapToPublicUser(accepted)}}
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// checkTenantWritable rejects the changes made through these routes, which
+// have no principal for authorize to check, while the tenant is blocked or
+// read only.
+func (a *AuthHandler) checkTenantWritable(r *http.Request, e *appError, tenantID int) *appError {
+	tenant, err := a.store.GetTenantByID(r.Context(), tenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	switch {
+	case tenantBlocked(tenant):
+		return e.withContext(ErrTenantSuspended, ErrMsgTenantSuspended, ErrStatusForbidden)
+	case tenant.Status == domain.TenantPendingDeletion:
+		return e.withContext(ErrTenantReadOnly, ErrMsgTenantPendingDeletion, ErrStatusForbidden)
+	case tenant.Status == domain.TenantInactive:
+		return e.withContext(ErrTenantReadOnly, ErrMsgTenantInactive, ErrStatusForbidden)
+	}
+	return nil
+}
+
+// startSession opens a new session for user and responds with its token pair.
+func (a *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, e *appError, user domain.User, recoveryCodes []string) *appError {
+	refreshToken, refreshHash, err := NewOpaqueToken()
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	session := domain.Session{
+		UserID:    user.ID,
+		UserAgent: r.UserAgent(),
+		IPAddress: clientIP(r),
+		ExpiresAt: a.tokens.RefreshExpiry(),
+	}
+	session, err = a.store.CreateSession(r.Context(), user.TenantID, session, refreshHash)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	return a.writeTokens(w, e, user, session.ID, refreshToken, recoveryCodes)
+}
+
+func (a *AuthHandler) writeTokens(w http.ResponseWriter, e *appError, user domain.User, sessionID int, refreshToken string, recoveryCodes []string) *appError {
+	token, _, err := a.tokens.Issue(user, sessionID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[LoginResponse]{
+		Count: 1,
+		Data: LoginResponse{
+			AccessToken:  token,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(a.tokens.TTL().Seconds()),
+			RefreshToken: refreshToken,
+
+			RecoveryCodes: recoveryCodes,
+		},
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,747 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogin(t *testing.T) {
+	hash, _ := HashPassword("ReallySecret1001")
+	verifiedAt := time.Now()
+	user := domain.User{
+		ID:         4,
+		TenantID:   2,
+		FirstName:  "Peter",
+		LastName:   "Gray",
+		Email:      "pgray@email.com",
+		Password:   hash,
+		Role:       "trainer",
+		VerifiedAt: &verifiedAt,
+	}
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+
+	t.Run("returns a signed access token on valid credentials", func(t *testing.T) {
+		store := newLoginStore(user, domain.Tenant{ID: 2})
+		var storedHash string
+		store.CreateSessionFn = func(ctx context.Context, tenantID int, session domain.Session, tokenHash string) (domain.Session, error) {
+			storedHash = tokenHash
+			session.ID = 9
+			return session, nil
+		}
+
+		body, _ := json.Marshal(domain.LoginRequestBody{Email: user.Email, Password: "ReallySecret1001"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/login", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+
+		var got Response[LoginResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, "Bearer", got.Data.TokenType, "token types should be equal")
+		assert.Equal(t, 900, got.Data.ExpiresIn, "expiry should be equal")
+
+		claims, err := tokens.Verify(got.Data.AccessToken)
+		assert.NoError(t, err, "token should verify")
+		assert.Equal(t, user.ID, claims.UserID, "user ids should be equal")
+		assert.Equal(t, user.TenantID, claims.TenantID, "tenant ids should be equal")
+		assert.Equal(t, user.Role, claims.Role, "roles should be equal")
+		assert.Equal(t, 9, claims.SessionID, "session ids should be equal")
+		assert.Equal(t, storedHash, HashToken(got.Data.RefreshToken), "only the refresh token hash should be stored")
+	})
+
+	t.Run("returns 401 status code on wrong password", func(t *testing.T) {
+		store := new(mock.Store)
+		allowLogins(store)
+		store.GetUserByEmailFn = func(ctx context.Context, tenantID int, email string) (domain.User, error) {
+			return user, nil
+		}
+
+		body, _ := json.Marshal(domain.LoginRequestBody{Email: user.Email, Password: "wrong"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/login", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 401 status code on unknown email", func(t *testing.T) {
+		store := new(mock.Store)
+		allowLogins(store)
+		store.GetUserByEmailFn = func(ctx context.Context, tenantID int, email string) (domain.User, error) {
+			return domain.User{}, sql.ErrNoRows
+		}
+
+		body, _ := json.Marshal(domain.LoginRequestBody{Email: "nobody@email.com", Password: "whatever"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/login", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 400 status code on missing credentials", func(t *testing.T) {
+		store := new(mock.Store)
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/login", bytes.NewBufferString("{}"))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 429 status code while the account is locked out", func(t *testing.T) {
+		store := newLoginStore(user, domain.Tenant{ID: 2})
+		last := time.Now()
+		store.CountAccountFailuresFn = func(ctx context.Context, tenantID int, email string, since time.Time) (domain.LoginFailures, error) {
+			return domain.LoginFailures{Count: accountFailureThreshold, LastAt: &last}, nil
+		}
+		var recorded domain.LoginAttempt
+		store.RecordLoginAttemptFn = func(ctx context.Context, tenantID int, attempt domain.LoginAttempt) error {
+			recorded = attempt
+			return nil
+		}
+
+		body, _ := json.Marshal(domain.LoginRequestBody{Email: user.Email, Password: "ReallySecret1001"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/login", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 429, res.Code, "status codes should be equal")
+		assert.Equal(t, "60", res.Header().Get("Retry-After"), "retry after should be set")
+		assert.Equal(t, domain.LoginBlocked, recorded.Outcome, "blocked attempt should be recorded")
+	})
+
+	t.Run("records failed attempts", func(t *testing.T) {
+		store := newLoginStore(user, domain.Tenant{ID: 2})
+		var recorded domain.LoginAttempt
+		store.RecordLoginAttemptFn = func(ctx context.Context, tenantID int, attempt domain.LoginAttempt) error {
+			recorded = attempt
+			return nil
+		}
+
+		body, _ := json.Marshal(domain.LoginRequestBody{Email: "PGray@email.com", Password: "wrong"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/login", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+		want := domain.LoginAttempt{TenantID: 2, Email: "pgray@email.com", IPAddress: "192.0.2.1", Outcome: domain.LoginFailed}
+		assert.Equal(t, want, recorded, "failed attempt should be recorded")
+	})
+
+	unverified := user
+	unverified.VerifiedAt = nil
+
+	t.Run("returns 403 status code for unverified email when tenant requires verification", func(t *testing.T) {
+		store := newLoginStore(unverified, domain.Tenant{ID: 2, RequireEmailVerification: true})
+
+		body, _ := json.Marshal(domain.LoginRequestBody{Email: user.Email, Password: "ReallySecret1001"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/login", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+	})
+
+	t.Run("lets unverified users log in when tenant does not require verification", func(t *testing.T) {
+		store := newLoginStore(unverified, domain.Tenant{ID: 2})
+		store.CreateSessionFn = func(ctx context.Context, tenantID int, session domain.Session, tokenHash string) (domain.Session, error) {
+			return session, nil
+		}
+
+		body, _ := json.Marshal(domain.LoginRequestBody{Email: user.Email, Password: "ReallySecret1001"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/login", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 403 status code when the tenant is suspended", func(t *testing.T) {
+		store := newLoginStore(user, domain.Tenant{ID: 2, Status: domain.TenantSuspended})
+
+		body, _ := json.Marshal(domain.LoginRequestBody{Email: user.Email, Password: "ReallySecret1001"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/login", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgTenantSuspended, got.Message, "messages should be equal")
+	})
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	hash, _ := HashPassword("ReallySecret1001")
+	verifiedAt := time.Now()
+	admin := domain.User{ID: 1, TenantID: 2, Email: "pgray@email.com", Password: hash, Role: domain.RoleAdmin, VerifiedAt: &verifiedAt}
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+	loginBody, _ := json.Marshal(domain.LoginRequestBody{Email: admin.Email, Password: "ReallySecret1001"})
+
+	enabledAt := time.Now()
+	enabled := func(ctx context.Context, tenantID int, userID int) (domain.TwoFactor, error) {
+		return domain.TwoFactor{TenantID: tenantID, UserID: userID, Secret: rfc6238Secret, EnabledAt: &enabledAt}, nil
+	}
+
+	t.Run("challenges users with two factor enabled instead of starting a session", func(t *testing.T) {
+		store := newLoginStore(admin, domain.Tenant{ID: 2})
+		store.GetTwoFactorFn = enabled
+
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/login", bytes.NewBuffer(loginBody))
+		res := newAuthRequest(store, tokens, req)
+
+		var got Response[TwoFactorChallengeResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.True(t, got.Data.TwoFactorRequired, "second factor should be required")
+		assert.False(t, got.Data.EnrollmentRequired, "enrollment should not be required")
+
+		_, err := tokens.Verify(got.Data.TwoFactorToken)
+		assert.Error(t, err, "two factor token must not be an access token")
+	})
+
+	t.Run("requires enrollment for admins when the tenant requires two factor", func(t *testing.T) {
+		store := newLoginStore(admin, domain.Tenant{ID: 2, RequireAdminTwoFactor: true})
+
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/login", bytes.NewBuffer(loginBody))
+		res := newAuthRequest(store, tokens, req)
+
+		var got Response[TwoFactorChallengeResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.True(t, got.Data.TwoFactorRequired, "second factor should be required")
+		assert.True(t, got.Data.EnrollmentRequired, "enrollment should be required")
+	})
+
+	t.Run("starts a session once a valid code is presented", func(t *testing.T) {
+		token, _ := tokens.IssueFor(purposeTwoFactor, admin, twoFactorTokenTTL)
+		store := new(mock.Store)
+		allowLogins(store)
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return admin, nil
+		}
+		store.GetTwoFactorFn = enabled
+		store.UseTwoFactorStepFn = func(ctx context.Context, tenantID int, userID int, step int64) error {
+			return nil
+		}
+		store.CreateSessionFn = func(ctx context.Context, tenantID int, session domain.Session, tokenHash string) (domain.Session, error) {
+			session.ID = 9
+			return session, nil
+		}
+
+		body, _ := json.Marshal(domain.TwoFactorLoginRequestBody{TwoFactorToken: token, Code: currentTOTP(rfc6238Secret)})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/two-factor/verify", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		var got Response[LoginResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+
+		claims, err := tokens.Verify(got.Data.AccessToken)
+		assert.NoError(t, err, "token should verify")
+		assert.Equal(t, 9, claims.SessionID, "session ids should be equal")
+	})
+
+	t.Run("returns 401 status code on wrong code", func(t *testing.T) {
+		token, _ := tokens.IssueFor(purposeTwoFactor, admin, twoFactorTokenTTL)
+		store := new(mock.Store)
+		allowLogins(store)
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return admin, nil
+		}
+		store.GetTwoFactorFn = enabled
+		store.ConsumeRecoveryCodeFn = func(ctx context.Context, tenantID int, userID int, codeHash string) error {
+			return sql.ErrNoRows
+		}
+
+		body, _ := json.Marshal(domain.TwoFactorLoginRequestBody{TwoFactorToken: token, RecoveryCode: "used-code"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/two-factor/verify", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 403 status code when enrolling while the tenant is read only", func(t *testing.T) {
+		token, _ := tokens.IssueFor(purposeTwoFactor, admin, twoFactorTokenTTL)
+		store := newLoginStore(admin, domain.Tenant{ID: 2, Status: domain.TenantPendingDeletion})
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return admin, nil
+		}
+
+		body, _ := json.Marshal(domain.TwoFactorLoginRequestBody{TwoFactorToken: token})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/two-factor/enroll", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgTenantPendingDeletion, got.Message, "messages should be equal")
+	})
+
+	t.Run("returns 401 status code without a two factor token", func(t *testing.T) {
+		access, _, _ := tokens.Issue(admin, 9)
+		store := new(mock.Store)
+
+		body, _ := json.Marshal(domain.TwoFactorLoginRequestBody{TwoFactorToken: access, Code: currentTOTP(rfc6238Secret)})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/two-factor/verify", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+	user := domain.User{ID: 4, TenantID: 2, Role: "member"}
+
+	t.Run("rotates the refresh token and issues a new access token", func(t *testing.T) {
+		store := new(mock.Store)
+		store.RotateRefreshTokenFn = func(ctx context.Context, tenantID int, oldHash string, newHash string, expiresAt time.Time) (domain.Session, error) {
+			assert.Equal(t, HashToken("old-token"), oldHash, "old token hashes should be equal")
+			assert.NotEqual(t, oldHash, newHash, "refresh token should be rotated")
+			return domain.Session{ID: 9, TenantID: tenantID, UserID: user.ID}, nil
+		}
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return user, nil
+		}
+
+		body, _ := json.Marshal(domain.RefreshRequestBody{RefreshToken: "old-token"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/refresh", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		var got Response[LoginResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.NotEmpty(t, got.Data.RefreshToken, "refresh token should be returned")
+		assert.NotEqual(t, "old-token", got.Data.RefreshToken, "refresh token should be rotated")
+
+		claims, err := tokens.Verify(got.Data.AccessToken)
+		assert.NoError(t, err, "token should verify")
+		assert.Equal(t, 9, claims.SessionID, "session ids should be equal")
+	})
+
+	t.Run("returns 401 status code when a rotated token is reused", func(t *testing.T) {
+		store := new(mock.Store)
+		store.RotateRefreshTokenFn = func(ctx context.Context, tenantID int, oldHash string, newHash string, expiresAt time.Time) (domain.Session, error) {
+			return domain.Session{}, domain.ErrRefreshTokenReused
+		}
+
+		body, _ := json.Marshal(domain.RefreshRequestBody{RefreshToken: "stolen-token"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/refresh", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 401 status code on unknown token", func(t *testing.T) {
+		store := new(mock.Store)
+		store.RotateRefreshTokenFn = func(ctx context.Context, tenantID int, oldHash string, newHash string, expiresAt time.Time) (domain.Session, error) {
+			return domain.Session{}, sql.ErrNoRows
+		}
+
+		body, _ := json.Marshal(domain.RefreshRequestBody{RefreshToken: "unknown"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/refresh", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+	})
+}
+
+func TestForgotPassword(t *testing.T) {
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+	user := domain.User{ID: 4, TenantID: 2, FirstName: "Peter", Email: "pgray@email.com"}
+
+	t.Run("emails a reset link whose token hash is stored", func(t *testing.T) {
+		var storedHash string
+		store := new(mock.Store)
+		store.GetUserByEmailFn = func(ctx context.Context, tenantID int, email string) (domain.User, error) {
+			return user, nil
+		}
+		store.CreatePasswordResetTokenFn = func(ctx context.Context, tenantID int, userID int, tokenHash string, expiresAt time.Time) error {
+			storedHash = tokenHash
+			return nil
+		}
+
+		var sent domain.Email
+		mailer := new(mock.Mailer)
+		mailer.SendFn = func(ctx context.Context, email domain.Email) error {
+			sent = email
+			return nil
+		}
+
+		body, _ := json.Marshal(domain.ForgotPasswordRequestBody{Email: user.Email})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/password/forgot", bytes.NewBuffer(body))
+		res := newAuthRequestWithMailer(store, tokens, mailer, req)
+
+		assert.Equal(t, 202, res.Code, "status codes should be equal")
+		assert.Equal(t, user.Email, sent.To, "recipients should be equal")
+
+		link, _ := url.Parse(strings.Fields(sent.Body[strings.Index(sent.Body, "https://"):])[0])
+		assert.Equal(t, storedHash, HashToken(link.Query().Get("token")), "emailed token should match stored hash")
+	})
+
+	t.Run("returns 202 status code without sending for unknown email", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetUserByEmailFn = func(ctx context.Context, tenantID int, email string) (domain.User, error) {
+			return domain.User{}, sql.ErrNoRows
+		}
+
+		body, _ := json.Marshal(domain.ForgotPasswordRequestBody{Email: "nobody@email.com"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/password/forgot", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req) // the mock mailer panics if used
+
+		assert.Equal(t, 202, res.Code, "status codes should be equal")
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+
+	t.Run("updates the password and revokes all sessions", func(t *testing.T) {
+		var newHash string
+		revoked := false
+		store := newActiveTenantStore()
+		store.ConsumePasswordResetTokenFn = func(ctx context.Context, tenantID int, tokenHash string) (int, error) {
+			assert.Equal(t, HashToken("reset-token"), tokenHash, "token hashes should be equal")
+			return 4, nil
+		}
+		store.UpdateUserFn = func(ctx context.Context, tenantID int, userID int, update domain.UserUpdate) (domain.User, error) {
+			newHash = *update.Password
+			return domain.User{ID: userID}, nil
+		}
+		store.RevokeAllSessionsFn = func(ctx context.Context, tenantID int, userID int) error {
+			revoked = true
+			return nil
+		}
+
+		body, _ := json.Marshal(domain.ResetPasswordRequestBody{Token: "reset-token", Password: "BrandNewSecret1"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/password/reset", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 204, res.Code, "status codes should be equal")
+		assert.True(t, CheckPassword(newHash, "BrandNewSecret1"), "password should be stored hashed")
+		assert.True(t, revoked, "sessions should be revoked")
+	})
+
+	t.Run("keeps the token when the password cannot be updated", func(t *testing.T) {
+		var txErr error
+		store := newActiveTenantStore()
+		store.WithTxFn = func(ctx context.Context, fn func(tx domain.Store) error) error {
+			txErr = fn(store)
+			return txErr
+		}
+		store.ConsumePasswordResetTokenFn = func(ctx context.Context, tenantID int, tokenHash string) (int, error) {
+			return 4, nil
+		}
+		store.UpdateUserFn = func(ctx context.Context, tenantID int, userID int, update domain.UserUpdate) (domain.User, error) {
+			return domain.User{}, errors.New("connection reset")
+		}
+
+		body, _ := json.Marshal(domain.ResetPasswordRequestBody{Token: "reset-token", Password: "BrandNewSecret1"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/password/reset", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 500, res.Code, "status codes should be equal")
+		assert.Error(t, txErr, "consuming the token should be rolled back")
+	})
+
+	t.Run("returns 400 status code on used or expired token", func(t *testing.T) {
+		store := newActiveTenantStore()
+		store.ConsumePasswordResetTokenFn = func(ctx context.Context, tenantID int, tokenHash string) (int, error) {
+			return 0, sql.ErrNoRows
+		}
+
+		body, _ := json.Marshal(domain.ResetPasswordRequestBody{Token: "used-token", Password: "BrandNewSecret1"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/password/reset", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 403 status code while the tenant is read only", func(t *testing.T) {
+		for _, status := range []string{domain.TenantInactive, domain.TenantPendingDeletion, domain.TenantSuspended} {
+			store := newLoginStore(domain.User{}, domain.Tenant{ID: 2, Status: status})
+			var consumed bool
+			store.ConsumePasswordResetTokenFn = func(ctx context.Context, tenantID int, tokenHash string) (int, error) {
+				consumed = true
+				return 4, nil
+			}
+
+			body, _ := json.Marshal(domain.ResetPasswordRequestBody{Token: "reset-token", Password: "BrandNewSecret1"})
+			req := httptest.NewRequest("POST", "/api/tenants/2/auth/password/reset", bytes.NewBuffer(body))
+			res := newAuthRequest(store, tokens, req)
+
+			assert.Equal(t, 403, res.Code, status)
+			assert.False(t, consumed, status)
+		}
+	})
+
+	t.Run("returns 400 status code on short password", func(t *testing.T) {
+		store := new(mock.Store)
+		body, _ := json.Marshal(domain.ResetPasswordRequestBody{Token: "reset-token", Password: "short"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/password/reset", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+
+	t.Run("creates the invited user with the chosen password", func(t *testing.T) {
+		store := newActiveTenantStore()
+		var stored domain.User
+		store.AcceptInvitationFn = func(ctx context.Context, tenantID int, tokenHash string, user domain.User) (domain.User, error) {
+			assert.Equal(t, HashToken("invite-token"), tokenHash, "token hashes should be equal")
+			user.ID = 8
+			user.Email = "ann@email.com"
+			user.Role = domain.RoleTrainer
+			stored = user
+			return user, nil
+		}
+
+		body, _ := json.Marshal(domain.AcceptInvitationRequestBody{Token: "invite-token", FirstName: "Ann", LastName: "Lee", Password: "NewSecret2002"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/invitations/accept", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		var got Response[[]domain.PublicUser]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.Equal(t, domain.RoleTrainer, got.Data[0].Role, "roles should be equal")
+		assert.True(t, CheckPassword(stored.Password, "NewSecret2002"), "password should be hashed")
+	})
+
+	t.Run("returns 400 status code for invalid invitations", func(t *testing.T) {
+		store := newActiveTenantStore()
+		store.AcceptInvitationFn = func(ctx context.Context, tenantID int, tokenHash string, user domain.User) (domain.User, error) {
+			return domain.User{}, sql.ErrNoRows
+		}
+
+		body, _ := json.Marshal(domain.AcceptInvitationRequestBody{Token: "expired", Password: "NewSecret2002"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/invitations/accept", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 403 status code while the tenant is read only", func(t *testing.T) {
+		store := newLoginStore(domain.User{}, domain.Tenant{ID: 2, Status: domain.TenantInactive})
+		var accepted bool
+		store.AcceptInvitationFn = func(ctx context.Context, tenantID int, tokenHash string, user domain.User) (domain.User, error) {
+			accepted = true
+			return user, nil
+		}
+
+		body, _ := json.Marshal(domain.AcceptInvitationRequestBody{Token: "invite-token", Password: "NewSecret2002"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/invitations/accept", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgTenantInactive, got.Message, "messages should be equal")
+		assert.False(t, accepted, "invitation should not be accepted")
+	})
+
+	t.Run("returns 400 status code for a short password", func(t *testing.T) {
+		store := new(mock.Store)
+		body, _ := json.Marshal(domain.AcceptInvitationRequestBody{Token: "invite-token", Password: "short"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/invitations/accept", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+	admin := domain.User{ID: 1, TenantID: 2, Email: "pgray@email.com", Role: domain.RoleAdmin}
+	token, _ := tokens.IssueFor(purposeEmailVerification, admin, emailVerificationTTL)
+
+	t.Run("verifies the user and the tenant of an admin", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return admin, nil
+		}
+		var verifiedUser int
+		store.VerifyUserEmailFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			verifiedUser = userID
+			return admin, nil
+		}
+		var verifiedTenant int
+		store.VerifyTenantFn = func(ctx context.Context, tenantID int) error {
+			verifiedTenant = tenantID
+			return nil
+		}
+
+		body, _ := json.Marshal(domain.VerifyEmailRequestBody{Token: token})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/verify-email", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 204, res.Code, "status codes should be equal")
+		assert.Equal(t, admin.ID, verifiedUser, "user should be verified")
+		assert.Equal(t, admin.TenantID, verifiedTenant, "tenant should be verified")
+	})
+
+	t.Run("returns 400 status code when the email changed since the link was sent", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			changed := admin
+			changed.Email = "new@email.com"
+			return changed, nil
+		}
+
+		body, _ := json.Marshal(domain.VerifyEmailRequestBody{Token: token})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/verify-email", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 400 status code for tokens of another purpose", func(t *testing.T) {
+		access, _, _ := tokens.Issue(admin, 1)
+		store := new(mock.Store)
+
+		body, _ := json.Marshal(domain.VerifyEmailRequestBody{Token: access})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/verify-email", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 400 status code for tokens of another tenant", func(t *testing.T) {
+		store := new(mock.Store)
+
+		body, _ := json.Marshal(domain.VerifyEmailRequestBody{Token: token})
+		req := httptest.NewRequest("POST", "/api/tenants/3/auth/verify-email", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+}
+
+func TestResendVerification(t *testing.T) {
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+
+	t.Run("emails a new link to unverified users", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetUserByEmailFn = func(ctx context.Context, tenantID int, email string) (domain.User, error) {
+			return domain.User{ID: 4, TenantID: tenantID, Email: email}, nil
+		}
+
+		var sent domain.Email
+		mailer := new(mock.Mailer)
+		mailer.SendFn = func(ctx context.Context, email domain.Email) error {
+			sent = email
+			return nil
+		}
+
+		body, _ := json.Marshal(domain.ResendVerificationRequestBody{Email: "pgray@email.com"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/verify-email/resend", bytes.NewBuffer(body))
+		res := newAuthRequestWithMailer(store, tokens, mailer, req)
+
+		assert.Equal(t, 202, res.Code, "status codes should be equal")
+		assert.Equal(t, "pgray@email.com", sent.To, "recipients should be equal")
+		assert.Contains(t, sent.Body, "https://app.gymulty.test/verify-email?", "email should contain the link")
+	})
+
+	t.Run("does not email users who are already verified", func(t *testing.T) {
+		verifiedAt := time.Now()
+		store := new(mock.Store)
+		store.GetUserByEmailFn = func(ctx context.Context, tenantID int, email string) (domain.User, error) {
+			return domain.User{ID: 4, TenantID: tenantID, Email: email, VerifiedAt: &verifiedAt}, nil
+		}
+
+		body, _ := json.Marshal(domain.ResendVerificationRequestBody{Email: "pgray@email.com"})
+		req := httptest.NewRequest("POST", "/api/tenants/2/auth/verify-email/resend", bytes.NewBuffer(body))
+		res := newAuthRequest(store, tokens, req)
+
+		assert.Equal(t, 202, res.Code, "status codes should be equal")
+	})
+}
+
+func TestTokenManager(t *testing.T) {
+	user := domain.User{ID: 1, TenantID: 1, Role: "admin"}
+
+	t.Run("rejects tokens signed with another secret", func(t *testing.T) {
+		token, _, _ := NewTokenManager("one", time.Minute, time.Hour).Issue(user, 0)
+		_, err := NewTokenManager("two", time.Minute, time.Hour).Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken, "token should be invalid")
+	})
+
+	t.Run("rejects expired tokens", func(t *testing.T) {
+		tokens := NewTokenManager("secret", time.Minute, time.Hour)
+		token, _, _ := tokens.Issue(user, 0)
+
+		tokens.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+		_, err := tokens.Verify(token)
+		assert.ErrorIs(t, err, ErrExpiredToken, "token should be expired")
+	})
+}
+
+// newLoginStore returns a store in which user can log in to tenant without a second factor.
+func newLoginStore(user domain.User, tenant domain.Tenant) *mock.Store {
+	store := new(mock.Store)
+	store.GetUserByEmailFn = func(ctx context.Context, tenantID int, email string) (domain.User, error) {
+		return user, nil
+	}
+	store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+		return tenant, nil
+	}
+	store.GetTwoFactorFn = func(ctx context.Context, tenantID int, userID int) (domain.TwoFactor, error) {
+		return domain.TwoFactor{}, sql.ErrNoRows
+	}
+	allowLogins(store)
+	return store
+}
+
+func newActiveTenantStore() *mock.Store {
+	store := new(mock.Store)
+	store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+		return domain.Tenant{ID: tenantID, Status: domain.TenantActive}, nil
+	}
+	return store
+}
+
+// allowLogins lets every login attempt through the lockout and drops the records.
+func allowLogins(store *mock.Store) {
+	store.CountAccountFailuresFn = func(ctx context.Context, tenantID int, email string, since time.Time) (domain.LoginFailures, error) {
+		return domain.LoginFailures{}, nil
+	}
+	store.CountIPFailuresFn = func(ctx context.Context, ip string, since time.Time) (domain.LoginFailures, error) {
+		return domain.LoginFailures{}, nil
+	}
+	store.RecordLoginAttemptFn = func(ctx context.Context, tenantID int, attempt domain.LoginAttempt) error {
+		return nil
+	}
+}
+
+func newAuthRequest(store *mock.Store, tokens *TokenManager, req *http.Request) *httptest.ResponseRecorder {
+	return newAuthRequestWithMailer(store, tokens, new(mock.Mailer), req)
+}
+
+func newTestVerifier(mailer domain.Mailer) *EmailVerifier {
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+	return NewEmailVerifier(tokens, mailer, "https://app.gymulty.test")
+}
+
+func discardMailer() *mock.Mailer {
+	mailer := new(mock.Mailer)
+	mailer.SendFn = func(ctx context.Context, email domain.Email) error {
+		return nil
+	}
+	return mailer
+}
+
+func newAuthRequestWithMailer(store *mock.Store, tokens *TokenManager, mailer *mock.Mailer, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewAuthHandler(slog.Default(), store, tokens, unlimitedQuotas(), mailer, "https://app.gymulty.test")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
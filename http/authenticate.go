@@ -0,0 +1,194 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+var (
+	ErrMissingToken    = errors.New("auth: missing bearer token")
+	ErrTenantMismatch  = errors.New("auth: token tenant does not match requested tenant")
+	ErrTenantSuspended = errors.New("auth: tenant has been suspended")
+)
+
+// Principal is the authenticated caller of a request, either a user or,
+// when APIKeyID is set, an integration holding an API key.
+type Principal struct {
+	UserID      int
+	TenantID    int
+	Role        string
+	SessionID   int
+	APIKeyID    int
+	Permissions []string
+
+	// TenantUnverified is set until the admin who signed the tenant up
+	// confirms their email address.
+	TenantUnverified bool
+
+	// TenantReadOnly is set while the tenant is deactivated or pending
+	// deletion, TenantPendingDeletion only in the latter case.
+	TenantReadOnly        bool
+	TenantPendingDeletion bool
+}
+
+type principalCtxKeyType string
+
+const principalCtxKey principalCtxKeyType = "principal"
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalCtxKey).(Principal)
+	return p, ok
+}
+
+// Authenticate validates the bearer token or API key of every request and
+// stores the caller, with the permissions of their tenant role or the
+// scopes of their key, in the request context. Tokens stop working as soon
+// as their session is revoked. Requests for a {tenantID} other than the one
+// the credentials were issued for are rejected, and so is every request of
+// a suspended tenant.
+func Authenticate(tokens *TokenManager, store domain.Store) middleware.Middleware {
+	return func(logger *slog.Logger, next http.Handler) http.Handler {
+		return errorHandler(func(w http.ResponseWriter, r *http.Request) *appError {
+			e := &appError{Logger: logger}
+
+			token, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				return e.withContext(ErrMissingToken, ErrMsgUnauthorized, ErrStatusUnauthorized)
+			}
+
+			var p Principal
+			var err error
+			if isAPIKey(token) {
+				p, err = apiKeyPrincipal(r, store, token)
+			} else {
+				p, err = tokenPrincipal(r, tokens, store, token)
+			}
+			if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrExpiredToken) || errors.Is(err, ErrInvalidAPIKey) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				return e.withContext(err, ErrMsgUnauthorized, ErrStatusUnauthorized)
+			}
+			if err != nil {
+				return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+			}
+
+			if pathTenant := r.PathValue("tenantID"); pathTenant != "" {
+				tenantID, err := strconv.Atoi(pathTenant)
+				if err != nil {
+					return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+				}
+				if tenantID != p.TenantID {
+					return e.withContext(ErrTenantMismatch, ErrMsgTenantMismatch, ErrStatusForbidden)
+				}
+			}
+
+			tenant, err := store.GetTenantByID(r.Context(), p.TenantID)
+			if err != nil {
+				return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+			}
+			if tenantBlocked(tenant) {
+				return e.withContext(ErrTenantSuspended, ErrMsgTenantSuspended, ErrStatusForbidden)
+			}
+			p.TenantUnverified = tenant.VerifiedAt == nil
+			p.TenantPendingDeletion = tenant.Status == domain.TenantPendingDeletion
+			p.TenantReadOnly = tenant.Status == domain.TenantInactive || p.TenantPendingDeletion
+
+			if p.APIKeyID != 0 {
+				err = store.TouchAPIKey(r.Context(), p.APIKeyID)
+				if err != nil {
+					logger.Error("updating api key last use", slog.String("error", err.Error()))
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+			return nil
+		})
+	}
+}
+
+func tokenPrincipal(r *http.Request, tokens *TokenManager, store domain.Store, token string) (Principal, error) {
+	claims, err := tokens.Verify(token)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	// logging a device out must cut it off at once, not when its token expires
+	session, err := store.GetSessionByID(r.Context(), claims.TenantID, claims.SessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Principal{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+	if !sessionActive(session, claims.UserID, time.Now()) {
+		return Principal{}, ErrInvalidToken
+	}
+
+	// a user demoted since the token was issued must lose the old role's
+	// permissions at once, so the role is read from the user, not the claims
+	user, err := store.GetUserByID(r.Context(), claims.TenantID, claims.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Principal{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+
+	// a role deleted since the token was issued grants no permissions
+	role, err := store.GetRoleByName(r.Context(), claims.TenantID, user.Role)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Principal{}, err
+	}
+
+	return Principal{
+		UserID:      claims.UserID,
+		TenantID:    claims.TenantID,
+		Role:        user.Role,
+		SessionID:   claims.SessionID,
+		Permissions: role.Permissions,
+	}, nil
+}
+
+func sessionActive(session domain.Session, userID int, now time.Time) bool {
+	return session.UserID == userID && session.RevokedAt == nil && now.Before(session.ExpiresAt)
+}
+
+func apiKeyPrincipal(r *http.Request, store domain.APIKeyStore, token string) (Principal, error) {
+	key, err := store.GetAPIKeyByHash(r.Context(), HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Principal{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+	if !apiKeyActive(key, time.Now()) {
+		return Principal{}, ErrInvalidAPIKey
+	}
+
+	return Principal{
+		TenantID:    key.TenantID,
+		APIKeyID:    key.ID,
+		Permissions: key.Scopes,
+	}, nil
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
@@ -0,0 +1,271 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticate(t *testing.T) {
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+	token, _, _ := tokens.Issue(domain.User{ID: 3, TenantID: 1, Role: "member"}, 12)
+
+	t.Run("returns 401 status code without bearer token", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/tenants/1/users/3", nil)
+		res, _ := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+		assert.Equal(t, "Bearer", res.Header().Get("WWW-Authenticate"), "challenge should be set")
+	})
+
+	t.Run("returns 401 status code on invalid token", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/tenants/1/users/3", nil)
+		req.Header.Set("Authorization", "Bearer not.a.token")
+		res, _ := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 401 status code once the session is revoked or expired", func(t *testing.T) {
+		for _, sessionID := range []int{revokedSessionID, expiredSessionID} {
+			token, _, _ := tokens.Issue(domain.User{ID: 3, TenantID: 1, Role: "member"}, sessionID)
+			req := httptest.NewRequest("GET", "/api/tenants/1/users/3", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res, _ := newAuthenticatedRequest(tokens, req)
+
+			assert.Equal(t, 401, res.Code, "status codes should be equal")
+		}
+	})
+
+	t.Run("returns 401 status code for the session of another user", func(t *testing.T) {
+		token, _, _ := tokens.Issue(domain.User{ID: 4, TenantID: 1, Role: "admin"}, 12)
+		req := httptest.NewRequest("GET", "/api/tenants/1/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res, _ := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 403 status code when token tenant differs from path tenant", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/tenants/2/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res, _ := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+	})
+
+	t.Run("passes principal to next handler on valid token", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/tenants/1/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res, got := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		want := Principal{
+			UserID:      3,
+			TenantID:    1,
+			Role:        "member",
+			SessionID:   12,
+			Permissions: domain.DefaultRolePermissions["member"],
+		}
+		assert.Equal(t, want, got, "principals should be equal")
+	})
+
+	t.Run("takes permissions from the current role of a demoted user", func(t *testing.T) {
+		token, _, _ := tokens.Issue(domain.User{ID: 3, TenantID: 1, Role: "admin"}, 12)
+		req := httptest.NewRequest("GET", "/api/tenants/1/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res, got := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, "member", got.Role, "roles should be equal")
+		assert.Equal(t, domain.DefaultRolePermissions["member"], got.Permissions, "permissions should be equal")
+	})
+
+	t.Run("marks principal of an unverified tenant", func(t *testing.T) {
+		token, _, _ := tokens.Issue(domain.User{ID: 3, TenantID: unverifiedTenantID, Role: "member"}, 12)
+		req := httptest.NewRequest("GET", "/api/tenants/9/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res, got := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.True(t, got.TenantUnverified, "principal should be marked unverified")
+	})
+
+	t.Run("marks principal of a deactivated tenant read only", func(t *testing.T) {
+		token, _, _ := tokens.Issue(domain.User{ID: 3, TenantID: inactiveTenantID, Role: "member"}, 12)
+		req := httptest.NewRequest("GET", "/api/tenants/11/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res, got := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.True(t, got.TenantReadOnly, "principal should be marked read only")
+	})
+
+	t.Run("returns 403 status code for a suspended tenant", func(t *testing.T) {
+		token, _, _ := tokens.Issue(domain.User{ID: 3, TenantID: suspendedTenantID, Role: "member"}, 12)
+		req := httptest.NewRequest("GET", "/api/tenants/10/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res, _ := newAuthenticatedRequest(tokens, req)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgTenantSuspended, got.Message, "messages should be equal")
+	})
+
+	t.Run("marks principal of a tenant closing its account read only", func(t *testing.T) {
+		token, _, _ := tokens.Issue(domain.User{ID: 3, TenantID: closingTenantID, Role: "member"}, 12)
+		req := httptest.NewRequest("GET", "/api/tenants/12/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res, got := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.True(t, got.TenantReadOnly, "principal should be marked read only")
+		assert.True(t, got.TenantPendingDeletion, "principal should be marked pending deletion")
+	})
+
+	t.Run("returns 403 status code for a tenant the platform is deleting", func(t *testing.T) {
+		token, _, _ := tokens.Issue(domain.User{ID: 3, TenantID: deletedTenantID, Role: "member"}, 12)
+		req := httptest.NewRequest("GET", "/api/tenants/13/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res, _ := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+	})
+
+	t.Run("passes api key principal with the key scopes", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/tenants/1/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		res, got := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		want := Principal{
+			TenantID:    1,
+			APIKeyID:    5,
+			Permissions: []string{domain.PermUsersRead},
+		}
+		assert.Equal(t, want, got, "principals should be equal")
+	})
+
+	t.Run("returns 401 status code for revoked, expired and unknown api keys", func(t *testing.T) {
+		for _, key := range []string{revokedAPIKey, expiredAPIKey, "gym_unknown"} {
+			req := httptest.NewRequest("GET", "/api/tenants/1/users/3", nil)
+			req.Header.Set("Authorization", "Bearer "+key)
+			res, _ := newAuthenticatedRequest(tokens, req)
+
+			assert.Equal(t, 401, res.Code, "status codes should be equal")
+		}
+	})
+
+	t.Run("returns 403 status code when api key tenant differs from path tenant", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/tenants/2/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		res, _ := newAuthenticatedRequest(tokens, req)
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+	})
+}
+
+const (
+	unverifiedTenantID = 9
+	suspendedTenantID  = 10
+	inactiveTenantID   = 11
+	closingTenantID    = 12
+	deletedTenantID    = 13
+
+	revokedSessionID = 20
+	expiredSessionID = 21
+
+	testAPIKey    = "gym_active"
+	revokedAPIKey = "gym_revoked"
+	expiredAPIKey = "gym_expired"
+)
+
+func testAPIKeys() []domain.APIKey {
+	past := time.Now().Add(-time.Hour)
+	return []domain.APIKey{
+		{ID: 5, TenantID: 1, KeyHash: HashToken(testAPIKey), Scopes: []string{domain.PermUsersRead}},
+		{ID: 6, TenantID: 1, KeyHash: HashToken(revokedAPIKey), RevokedAt: &past},
+		{ID: 7, TenantID: 1, KeyHash: HashToken(expiredAPIKey), ExpiresAt: &past},
+	}
+}
+
+func newAuthenticatedRequest(tokens *TokenManager, req *http.Request) (*httptest.ResponseRecorder, Principal) {
+	var principal Principal
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	store := new(mock.Store)
+	store.GetRoleByNameFn = func(ctx context.Context, tenantID int, name string) (domain.Role, error) {
+		return domain.Role{TenantID: tenantID, Name: name, Permissions: domain.DefaultRolePermissions[name]}, nil
+	}
+	store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+		if userID != 3 {
+			return domain.User{}, sql.ErrNoRows
+		}
+		return domain.User{ID: userID, TenantID: tenantID, Role: "member"}, nil
+	}
+	store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+		if tenantID == unverifiedTenantID {
+			return domain.Tenant{ID: tenantID}, nil
+		}
+		verifiedAt := time.Now()
+		tenant := domain.Tenant{ID: tenantID, Status: domain.TenantActive, VerifiedAt: &verifiedAt}
+		switch tenantID {
+		case suspendedTenantID:
+			tenant.Status = domain.TenantSuspended
+		case inactiveTenantID:
+			tenant.Status = domain.TenantInactive
+		case closingTenantID, deletedTenantID:
+			requestedBy := domain.DeletionByTenant
+			if tenantID == deletedTenantID {
+				requestedBy = domain.DeletionByPlatform
+			}
+			tenant.Status = domain.TenantPendingDeletion
+			tenant.DeletionRequestedBy = &requestedBy
+		}
+		return tenant, nil
+	}
+
+	store.GetSessionByIDFn = func(ctx context.Context, tenantID int, sessionID int) (domain.Session, error) {
+		session := domain.Session{ID: sessionID, TenantID: tenantID, UserID: 3, ExpiresAt: time.Now().Add(time.Hour)}
+		switch sessionID {
+		case revokedSessionID:
+			revokedAt := time.Now().Add(-time.Minute)
+			session.RevokedAt = &revokedAt
+		case expiredSessionID:
+			session.ExpiresAt = time.Now().Add(-time.Minute)
+		}
+		return session, nil
+	}
+
+	store.GetAPIKeyByHashFn = func(ctx context.Context, keyHash string) (domain.APIKey, error) {
+		for _, key := range testAPIKeys() {
+			if key.KeyHash == keyHash {
+				return key, nil
+			}
+		}
+		return domain.APIKey{}, sql.ErrNoRows
+	}
+	store.TouchAPIKeyFn = func(ctx context.Context, keyID int) error {
+		return nil
+	}
+
+	router := http.NewServeMux()
+	router.Handle("/api/tenants/{tenantID}/users/", Authenticate(tokens, store)(slog.Default(), next))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	return res, principal
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var (
+	ErrNoPrincipal      = errors.New("auth: request has no authenticated principal")
+	ErrPermissionDenied = errors.New("auth: permission denied")
+	ErrTenantUnverified = errors.New("auth: tenant has not been verified")
+	ErrTenantReadOnly   = errors.New("auth: tenant is deactivated and read only")
+)
+
+// Policy reports whether the principal is allowed to perform the request.
+type Policy func(p Principal, r *http.Request) bool
+
+// Authenticated grants access to every authenticated principal.
+func Authenticated(p Principal, r *http.Request) bool {
+	return true
+}
+
+// AuthenticatedUser grants access to every principal that is a user, as
+// opposed to an integration holding an API key.
+func AuthenticatedUser(p Principal, r *http.Request) bool {
+	return p.UserID != 0 && p.APIKeyID == 0
+}
+
+// Allow grants access to principals holding at least one of the permissions.
+func Allow(perms ...string) Policy {
+	return func(p Principal, r *http.Request) bool {
+		for _, perm := range perms {
+			if p.Can(perm) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Self grants access when the path value named param is the principal's own user id.
+func Self(param string) Policy {
+	return func(p Principal, r *http.Request) bool {
+		id, err := strconv.Atoi(r.PathValue(param))
+		return err == nil && id == p.UserID
+	}
+}
+
+// AnyOf grants access when any of the policies does.
+func AnyOf(policies ...Policy) Policy {
+	return func(p Principal, r *http.Request) bool {
+		for _, policy := range policies {
+			if policy(p, r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AllOf grants access when every one of the policies does.
+func AllOf(policies ...Policy) Policy {
+	return func(p Principal, r *http.Request) bool {
+		for _, policy := range policies {
+			if !policy(p, r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func (p Principal) Can(perm string) bool {
+	return slices.Contains(p.Permissions, perm) || slices.Contains(p.Permissions, domain.PermAll)
+}
+
+// authorize guards fn with policy, rejecting requests whose principal is
+// missing or not allowed before fn runs. Deactivated tenants and those
+// pending deletion may only read.
+func authorize(logger *slog.Logger, policy Policy, fn errorHandler) http.Handler {
+	return authorizeRequest(logger, policy, false, fn)
+}
+
+// authorizeInactive is authorize for the few routes that must keep working
+// while the tenant is deactivated or pending deletion, such as reactivating
+// or restoring it.
+func authorizeInactive(logger *slog.Logger, policy Policy, fn errorHandler) http.Handler {
+	return authorizeRequest(logger, policy, true, fn)
+}
+
+func authorizeRequest(logger *slog.Logger, policy Policy, allowReadOnly bool, fn errorHandler) http.Handler {
+	return errorHandler(func(w http.ResponseWriter, r *http.Request) *appError {
+		e := &appError{Logger: logger}
+
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			return e.withContext(ErrNoPrincipal, ErrMsgUnauthorized, ErrStatusUnauthorized)
+		}
+		if !policy(p, r) {
+			return e.withContext(ErrPermissionDenied, ErrMsgPermissionDenied, ErrStatusForbidden)
+		}
+		if p.TenantReadOnly && !allowReadOnly && !safeMethod(r.Method) {
+			if p.TenantPendingDeletion {
+				return e.withContext(ErrTenantReadOnly, ErrMsgTenantPendingDeletion, ErrStatusForbidden)
+			}
+			return e.withContext(ErrTenantReadOnly, ErrMsgTenantInactive, ErrStatusForbidden)
+		}
+		return fn(w, r)
+	})
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// verifiedTenant guards fn, which must already be authorized, so that it only
+// runs once the tenant has been verified. Unverified tenants can still read
+// and edit what they have, they just cannot create anything new.
+func verifiedTenant(logger *slog.Logger, fn errorHandler) errorHandler {
+	return func(w http.ResponseWriter, r *http.Request) *appError {
+		e := &appError{Logger: logger}
+
+		p, _ := PrincipalFromContext(r.Context())
+		if p.TenantUnverified {
+			return e.withContext(ErrTenantUnverified, ErrMsgTenantNotVerified, ErrStatusForbidden)
+		}
+		return fn(w, r)
+	}
+}
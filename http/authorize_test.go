@@ -0,0 +1,178 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserPolicies(t *testing.T) {
+	member := testPrincipal(7, domain.RoleMember)
+	store := new(mock.Store)
+	store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+		return domain.User{ID: userID, TenantID: tenantID, Role: domain.RoleMember}, nil
+	}
+	store.CreateUserFn = func(ctx context.Context, tenantID int, user domain.User) (domain.User, error) {
+		return user, nil
+	}
+	store.UpdateUserFn = func(ctx context.Context, tenantID int, userID int, update domain.UserUpdate) (domain.User, error) {
+		return domain.User{ID: userID, TenantID: tenantID}, nil
+	}
+
+	t.Run("member can read own profile", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/tenants/1/users/7", nil)
+		res := newUserRequest(store, asPrincipal(req, member))
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+	})
+
+	t.Run("member cannot read another user", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/tenants/1/users/8", nil)
+		res := newUserRequest(store, asPrincipal(req, member))
+		assertPermissionDenied(t, res)
+	})
+
+	t.Run("member cannot create users", func(t *testing.T) {
+		body, _ := json.Marshal(domain.User{Email: "new@email.com", Role: domain.RoleMember})
+		req := httptest.NewRequest("POST", "/api/tenants/1/users", bytes.NewBuffer(body))
+		res := newUserRequest(store, asPrincipal(req, member))
+		assertPermissionDenied(t, res)
+	})
+
+	t.Run("member cannot change own role", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"role": domain.RoleAdmin})
+		req := httptest.NewRequest("PUT", "/api/tenants/1/users/7", bytes.NewBuffer(body))
+		res := newUserRequest(store, asPrincipal(req, member))
+		assertPermissionDenied(t, res)
+	})
+
+	t.Run("trainer cannot delete users", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/users/8", nil)
+		res := newUserRequest(store, asPrincipal(req, testPrincipal(3, domain.RoleTrainer)))
+		assertPermissionDenied(t, res)
+	})
+
+	t.Run("admin of an unverified tenant cannot create users", func(t *testing.T) {
+		admin := testPrincipal(1, domain.RoleAdmin)
+		admin.TenantUnverified = true
+		req := httptest.NewRequest("POST", "/api/tenants/1/users", strings.NewReader(`{"first_name":"Ann"}`))
+		res := newUserRequest(store, asPrincipal(req, admin))
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+	})
+
+	t.Run("admin of an unverified tenant can still read users", func(t *testing.T) {
+		admin := testPrincipal(1, domain.RoleAdmin)
+		admin.TenantUnverified = true
+		req := httptest.NewRequest("GET", "/api/tenants/1/users/8", nil)
+		res := newUserRequest(store, asPrincipal(req, admin))
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+	})
+
+	t.Run("admin of a deactivated tenant cannot change users", func(t *testing.T) {
+		admin := testPrincipal(1, domain.RoleAdmin)
+		admin.TenantReadOnly = true
+		req := httptest.NewRequest("PUT", "/api/tenants/1/users/8", strings.NewReader(`{"first_name":"Ann"}`))
+		res := newUserRequest(store, asPrincipal(req, admin))
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+	})
+
+	t.Run("admin of a deactivated tenant can still read users", func(t *testing.T) {
+		admin := testPrincipal(1, domain.RoleAdmin)
+		admin.TenantReadOnly = true
+		req := httptest.NewRequest("GET", "/api/tenants/1/users/8", nil)
+		res := newUserRequest(store, asPrincipal(req, admin))
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 401 status code without principal", func(t *testing.T) {
+		handler := NewUserHandler(slog.Default(), store, newTestVerifier(discardMailer()), unlimitedQuotas(), discardMailer(), "https://app.gymulty.test")
+		req := httptest.NewRequest("GET", "/api/tenants/1/users/7", nil)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+	})
+}
+
+func TestClassPolicies(t *testing.T) {
+	trainer := testPrincipal(5, domain.RoleTrainer)
+	store := new(mock.Store)
+	store.CreateClassFn = func(ctx context.Context, tenantID int, class domain.Class) (domain.Class, error) {
+		return class, nil
+	}
+	store.GetClassByIDFn = func(ctx context.Context, tenantID, classID int) (domain.Class, error) {
+		return domain.Class{ID: classID, TrainerID: 9}, nil
+	}
+
+	t.Run("trainer creates class assigned to themselves", func(t *testing.T) {
+		body, _ := json.Marshal(domain.Class{Name: "Spin"})
+		req := httptest.NewRequest("POST", "/api/tenants/1/classes", bytes.NewBuffer(body))
+		res := NewClassRequest(asPrincipal(req, trainer), store)
+
+		var got Response[[]domain.Class]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.Equal(t, trainer.UserID, got.Data[0].TrainerID, "trainer ids should be equal")
+	})
+
+	t.Run("trainer cannot create class for another trainer", func(t *testing.T) {
+		body, _ := json.Marshal(domain.Class{Name: "Spin", TrainerID: 9})
+		req := httptest.NewRequest("POST", "/api/tenants/1/classes", bytes.NewBuffer(body))
+		res := NewClassRequest(asPrincipal(req, trainer), store)
+		assertPermissionDenied(t, res)
+	})
+
+	t.Run("trainer cannot delete another trainer's class", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/classes/2", nil)
+		res := NewClassRequest(asPrincipal(req, trainer), store)
+		assertPermissionDenied(t, res)
+	})
+
+	t.Run("member cannot create classes", func(t *testing.T) {
+		body, _ := json.Marshal(domain.Class{Name: "Spin"})
+		req := httptest.NewRequest("POST", "/api/tenants/1/classes", bytes.NewBuffer(body))
+		res := NewClassRequest(asPrincipal(req, testPrincipal(7, domain.RoleMember)), store)
+		assertPermissionDenied(t, res)
+	})
+}
+
+func assertPermissionDenied(t *testing.T, res *httptest.ResponseRecorder) {
+	t.Helper()
+	var got appError
+	json.NewDecoder(res.Body).Decode(&got)
+	assert.Equal(t, 403, res.Code, "status codes should be equal")
+	assert.Equal(t, ErrStatusForbidden, got.Code, "error codes should be equal")
+}
+
+func testPrincipal(userID int, role string) Principal {
+	return Principal{
+		UserID:      userID,
+		TenantID:    1,
+		Role:        role,
+		Permissions: domain.DefaultRolePermissions[role],
+	}
+}
+
+// asPrincipal authenticates req as p, as the Authenticate middleware would.
+func asPrincipal(req *http.Request, p Principal) *http.Request {
+	return req.WithContext(WithPrincipal(req.Context(), p))
+}
+
+// withDefaultPrincipal authenticates req as an admin unless the test already picked a principal.
+func withDefaultPrincipal(req *http.Request) *http.Request {
+	if _, ok := PrincipalFromContext(req.Context()); ok {
+		return req
+	}
+	return asPrincipal(req, testPrincipal(1, domain.RoleAdmin))
+}
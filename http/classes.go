@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/emanuelquerty/gymulty/domain"
 	"github.com/emanuelquerty/gymulty/http/middleware"
@@ -13,27 +14,36 @@ import (
 
 type ClassHandler struct {
 	http.Handler
-	store  domain.ClassStore
-	logger *slog.Logger
+	store    domain.Store
+	settings domain.TenantSettingsStore
+	quotas   *Quotas
+	logger   *slog.Logger
 }
 
-func NewClassHandler(logger *slog.Logger, store domain.ClassStore) *ClassHandler {
+func NewClassHandler(logger *slog.Logger, store domain.Store, settings domain.TenantSettingsStore, quotas *Quotas) *ClassHandler {
 	router := http.NewServeMux()
 
 	handler := &ClassHandler{
-		Handler: middleware.StripSlashes(router),
-		store:   store,
-		logger:  logger,
+		Handler:  middleware.StripSlashes(router),
+		store:    store,
+		settings: settings,
+		quotas:   quotas,
+		logger:   logger,
 	}
 	handler.registerRoutes(router)
 	return handler
 }
 
 func (c *ClassHandler) registerRoutes(router *http.ServeMux) {
-	router.Handle("POST /api/tenants/{tenantID}/classes", errorHandler(c.CreateClass))
-	router.Handle("GET /api/tenants/{tenantID}/classes/{classID}", errorHandler(c.GetClassByID))
-	router.Handle("DELETE /api/tenants/{tenantID}/classes/{classID}", errorHandler(c.DeleteClassByID))
-	router.Handle("GET /api/tenants/{tenantID}/classes", errorHandler(c.GetAllClasses))
+	readClass := Allow(domain.PermClassesRead)
+	writeClass := Allow(domain.PermClassesWrite, domain.PermClassesManage)
+
+	router.Handle("POST /api/tenants/{tenantID}/classes", authorize(c.logger, writeClass, verifiedTenant(c.logger, c.CreateClass)))
+	router.Handle("GET /api/tenants/{tenantID}/classes/{classID}", authorize(c.logger, readClass, c.GetClassByID))
+	router.Handle("DELETE /api/tenants/{tenantID}/classes/{classID}", authorize(c.logger, writeClass, c.DeleteClassByID))
+	router.Handle("GET /api/tenants/{tenantID}/classes", authorize(c.logger, readClass, c.GetAllClasses))
+	router.Handle("GET /api/tenants/{tenantID}/classes/{classID}/locations", authorize(c.logger, readClass, c.GetClassLocations))
+	router.Handle("PUT /api/tenants/{tenantID}/classes/{classID}/locations", authorize(c.logger, Allow(domain.PermClassesManage), c.SetClassLocations))
 }
 
 func (c *ClassHandler) CreateClass(w http.ResponseWriter, r *http.Request) *appError {
@@ -47,10 +57,30 @@ func (c *ClassHandler) CreateClass(w http.ResponseWriter, r *http.Request) *appE
 	var class domain.Class
 	json.NewDecoder(r.Body).Decode(&class)
 
-	class, err = c.store.CreateClass(r.Context(), tenantID, class)
+	// trainers without classes:manage may only schedule classes they train
+	p, _ := PrincipalFromContext(r.Context())
+	if !p.Can(domain.PermClassesManage) {
+		if class.TrainerID == 0 {
+			class.TrainerID = p.UserID
+		}
+		if class.TrainerID != p.UserID {
+			return e.withContext(ErrPermissionDenied, ErrMsgPermissionDenied, ErrStatusForbidden)
+		}
+	}
+
+	var newClass domain.Class
+	err = c.store.WithTx(r.Context(), func(tx domain.Store) error {
+		err := c.quotas.CheckClass(r.Context(), tx, tenantID, class.StartsAt)
+		if err != nil {
+			return err
+		}
+		newClass, err = tx.CreateClass(r.Context(), tenantID, class)
+		return err
+	})
 	if err != nil {
-		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+		return quotaError(&e, err, ErrMsgClassQuotaExceeded)
 	}
+	class = newClass
 
 	resourceURI := fmt.Sprintf("%s://%s%s/%d", r.URL.Scheme, r.Host, r.URL.String(), class.ID)
 	w.Header().Set("Location", resourceURI)
@@ -77,10 +107,16 @@ func (c *ClassHandler) GetClassByID(w http.ResponseWriter, r *http.Request) *app
 		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
 	}
 
+	loc, appErr := c.timezone(r, &e, tenantID)
+	if appErr != nil {
+		return appErr
+	}
+
 	class, err := c.store.GetClassByID(r.Context(), tenantID, classID)
 	if err != nil {
 		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
 	}
+	classInTimezone(&class, loc)
 
 	w.WriteHeader(http.StatusOK)
 	res := Response[[]domain.Class]{Count: 1, Data: []domain.Class{class}}
@@ -104,6 +140,17 @@ func (c *ClassHandler) DeleteClassByID(w http.ResponseWriter, r *http.Request) *
 		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
 	}
 
+	p, _ := PrincipalFromContext(r.Context())
+	if !p.Can(domain.PermClassesManage) {
+		class, err := c.store.GetClassByID(r.Context(), tenantID, classID)
+		if err != nil {
+			return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+		}
+		if class.TrainerID != p.UserID {
+			return e.withContext(ErrPermissionDenied, ErrMsgPermissionDenied, ErrStatusForbidden)
+		}
+	}
+
 	err = c.store.DeleteClassByID(r.Context(), tenantID, classID)
 	if err != nil {
 		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
@@ -121,10 +168,28 @@ func (c *ClassHandler) GetAllClasses(w http.ResponseWriter, r *http.Request) *ap
 		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
 	}
 
-	classes, err := c.store.GetAllClasses(r.Context(), tenantID)
+	loc, appErr := c.timezone(r, &e, tenantID)
+	if appErr != nil {
+		return appErr
+	}
+
+	locationID, appErr := locationFilter(r, &e)
+	if appErr != nil {
+		return appErr
+	}
+
+	var classes []domain.Class
+	if locationID != 0 {
+		classes, err = c.store.GetClassesByLocation(r.Context(), tenantID, locationID)
+	} else {
+		classes, err = c.store.GetAllClasses(r.Context(), tenantID)
+	}
 	if err != nil {
 		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
 	}
+	for i := range classes {
+		classInTimezone(&classes[i], loc)
+	}
 	res := Response[[]domain.Class]{
 		Count: len(classes),
 		Data:  classes,
@@ -133,3 +198,83 @@ func (c *ClassHandler) GetAllClasses(w http.ResponseWriter, r *http.Request) *ap
 	json.NewEncoder(w).Encode(res)
 	return nil
 }
+
+func (c *ClassHandler) GetClassLocations(w http.ResponseWriter, r *http.Request) *appError {
+	e := appError{Logger: c.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	classID, err := strconv.Atoi(r.PathValue("classID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	locations, err := c.store.GetClassLocations(r.Context(), tenantID, classID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	writeLocations(w, locations)
+	return nil
+}
+
+// SetClassLocations replaces the locations a class is held at.
+func (c *ClassHandler) SetClassLocations(w http.ResponseWriter, r *http.Request) *appError {
+	e := appError{Logger: c.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	classID, err := strconv.Atoi(r.PathValue("classID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.LocationsRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	locations, err := c.store.SetClassLocations(r.Context(), tenantID, classID, body.LocationIDs)
+	if err != nil {
+		return locationsError(&e, err)
+	}
+
+	writeLocations(w, locations)
+	return nil
+}
+
+// timezone returns the zone class times are rendered in, picked with the
+// timezone query parameter: "tenant" for the zone the gym is in, or any
+// IANA name. It is nil when the parameter is missing and times are left as is.
+func (c *ClassHandler) timezone(r *http.Request, e *appError, tenantID int) (*time.Location, *appError) {
+	name := r.URL.Query().Get("timezone")
+	if name == "" {
+		return nil, nil
+	}
+
+	if name == "tenant" {
+		settings, err := c.settings.GetTenantSettings(r.Context(), tenantID)
+		if err != nil {
+			return nil, e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+		}
+		name = settings.Timezone
+	}
+
+	loc, err := loadTimezone(name)
+	if err != nil {
+		return nil, e.withContext(err, ErrMsgInvalidTimezone, ErrStatusBadRequest)
+	}
+	return loc, nil
+}
+
+func classInTimezone(class *domain.Class, loc *time.Location) {
+	if loc == nil {
+		return
+	}
+	class.StartsAt = class.StartsAt.In(loc)
+	class.EndsAt = class.EndsAt.In(loc)
+}
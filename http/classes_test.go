@@ -29,7 +29,7 @@ func TestCreateClass(t *testing.T) {
 		EndsAt:      time.Now().AddDate(0, 0, 18).Add(1 * time.Hour).UTC(),
 	}
 
-	store := new(mock.ClassStore)
+	store := new(mock.Store)
 	store.CreateClassFn = func(ctx context.Context, tenantID int, class domain.Class) (domain.Class, error) {
 		return class, nil
 	}
@@ -77,7 +77,7 @@ func TestCreateClass(t *testing.T) {
 		buf := bytes.NewBuffer(body)
 		req := httptest.NewRequest("POST", "/api/tenants/invalid324/classes", buf)
 
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.CreateClassFn = func(ctx context.Context, tenantID int, class domain.Class) (domain.Class, error) {
 			return domain.Class{}, nil
 		}
@@ -94,7 +94,7 @@ func TestCreateClass(t *testing.T) {
 		buf := bytes.NewBuffer(body)
 		req := httptest.NewRequest("POST", "/api/tenants/99999/classes", buf)
 
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.CreateClassFn = func(ctx context.Context, tenantID int, class domain.Class) (domain.Class, error) {
 			return domain.Class{}, sql.ErrNoRows
 		}
@@ -118,7 +118,7 @@ func TestGetClassByID(t *testing.T) {
 		EndsAt:      time.Now().AddDate(0, 0, 18).Add(1 * time.Hour).UTC(),
 	}
 	t.Run("returns class with id 1", func(t *testing.T) {
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.GetClassByIDFn = func(ctx context.Context, tenantID, classID int) (domain.Class, error) {
 			return class, nil
 		}
@@ -133,7 +133,7 @@ func TestGetClassByID(t *testing.T) {
 	})
 
 	t.Run("returns 400 on invalid tenant/class id", func(t *testing.T) {
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.GetClassByIDFn = func(ctx context.Context, tenantID, classID int) (domain.Class, error) {
 			return domain.Class{}, nil
 		}
@@ -154,7 +154,7 @@ func TestGetClassByID(t *testing.T) {
 	})
 
 	t.Run("returns 404 on non-existing tenant/class id", func(t *testing.T) {
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.GetClassByIDFn = func(ctx context.Context, tenantID, classID int) (domain.Class, error) {
 			return domain.Class{}, sql.ErrNoRows
 		}
@@ -177,7 +177,7 @@ func TestGetClassByID(t *testing.T) {
 
 func TestDeleteClassByID(t *testing.T) {
 	t.Run("delete class with id 3, returning 204 on success", func(t *testing.T) {
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.DeleteClassByIDFn = func(ctx context.Context, tenantID, classID int) error {
 			return nil
 		}
@@ -190,7 +190,7 @@ func TestDeleteClassByID(t *testing.T) {
 	})
 
 	t.Run("delete class with invalid class id, returning 400 status code", func(t *testing.T) {
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.DeleteClassByIDFn = func(ctx context.Context, tenantID, classID int) error {
 			return nil
 		}
@@ -203,7 +203,7 @@ func TestDeleteClassByID(t *testing.T) {
 	})
 
 	t.Run("delete class with invalid tenant id, returning 400 status code", func(t *testing.T) {
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.DeleteClassByIDFn = func(ctx context.Context, tenantID, classID int) error {
 			return nil
 		}
@@ -230,7 +230,7 @@ func TestGetAllClasses(t *testing.T) {
 		},
 	}
 	t.Run("returns all classes given tenant id", func(t *testing.T) {
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.GetAllClassesFn = func(ctx context.Context, tenantID int) ([]domain.Class, error) {
 			return classes, nil
 		}
@@ -248,7 +248,7 @@ func TestGetAllClasses(t *testing.T) {
 	})
 
 	t.Run("returns count zero (0) in response for tenant with no classes", func(t *testing.T) {
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.GetAllClassesFn = func(ctx context.Context, tenantID int) ([]domain.Class, error) {
 			return []domain.Class{}, nil
 		}
@@ -263,7 +263,7 @@ func TestGetAllClasses(t *testing.T) {
 	})
 
 	t.Run("returns 400 status code on invalid tenantID", func(t *testing.T) {
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.GetAllClassesFn = func(ctx context.Context, tenantID int) ([]domain.Class, error) {
 			return classes, nil
 		}
@@ -277,7 +277,7 @@ func TestGetAllClasses(t *testing.T) {
 	})
 
 	t.Run("returns 200 status code on success", func(t *testing.T) {
-		store := new(mock.ClassStore)
+		store := new(mock.Store)
 		store.GetAllClassesFn = func(ctx context.Context, tenantID int) ([]domain.Class, error) {
 			return classes, nil
 		}
@@ -291,9 +291,57 @@ func TestGetAllClasses(t *testing.T) {
 	})
 }
 
-func NewClassRequest(req *http.Request, store domain.ClassStore) *httptest.ResponseRecorder {
+func NewClassRequest(req *http.Request, store domain.Store) *httptest.ResponseRecorder {
 	res := httptest.NewRecorder()
-	handler := NewClassHandler(slog.Default(), store)
-	handler.ServeHTTP(res, req)
+	handler := NewClassHandler(slog.Default(), store, new(mock.TenantSettingsStore), unlimitedQuotas())
+	handler.ServeHTTP(res, withDefaultPrincipal(req))
 	return res
 }
+
+func TestClassTimezone(t *testing.T) {
+	startsAt := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	store := new(mock.Store)
+	store.GetAllClassesFn = func(ctx context.Context, tenantID int) ([]domain.Class, error) {
+		return []domain.Class{{ID: 1, TenantID: tenantID, StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour)}}, nil
+	}
+	settings := new(mock.TenantSettingsStore)
+	settings.GetTenantSettingsFn = func(ctx context.Context, tenantID int) (domain.TenantSettings, error) {
+		return domain.TenantSettings{TenantID: tenantID, Timezone: "America/New_York"}, nil
+	}
+
+	getClasses := func(target string) (*httptest.ResponseRecorder, map[string]any) {
+		req := httptest.NewRequest("GET", target, nil)
+		res := httptest.NewRecorder()
+		NewClassHandler(slog.Default(), store, settings, unlimitedQuotas()).ServeHTTP(res, withDefaultPrincipal(req))
+
+		var got struct {
+			Data []map[string]any `json:"data"`
+		}
+		json.NewDecoder(res.Body).Decode(&got)
+		if len(got.Data) == 0 {
+			return res, nil
+		}
+		return res, got.Data[0]
+	}
+
+	t.Run("renders times in the tenant timezone", func(t *testing.T) {
+		res, class := getClasses("/api/tenants/1/classes?timezone=tenant")
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, "2026-03-02T02:00:00-05:00", class["starts_at"], "start should be local to the gym")
+		assert.Equal(t, "2026-03-02T03:00:00-05:00", class["ends_at"], "end should be local to the gym")
+	})
+
+	t.Run("renders times in a requested timezone", func(t *testing.T) {
+		res, class := getClasses("/api/tenants/1/classes?timezone=Europe/Lisbon")
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, "2026-03-02T07:00:00Z", class["starts_at"], "start should be in Lisbon time")
+	})
+
+	t.Run("returns 400 status code for an unknown timezone", func(t *testing.T) {
+		res, _ := getClasses("/api/tenants/1/classes?timezone=Mars/Olympus")
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/export"
+)
+
+const purgeInterval = time.Hour // how often tenants due for deletion are purged
+
+// TenantCloser closes tenant accounts. Closing only schedules the deletion
+// of a tenant, which can be restored until its grace period ends; Run then
+// purges it in the background and records a deletion certificate.
+type TenantCloser struct {
+	store    domain.Store
+	disk     *export.Disk
+	resolver *TenantResolver
+	logger   *slog.Logger
+	grace    time.Duration
+	now      func() time.Time
+}
+
+func NewTenantCloser(logger *slog.Logger, store domain.Store, disk *export.Disk, resolver *TenantResolver, grace time.Duration) *TenantCloser {
+	return &TenantCloser{
+		store:    store,
+		disk:     disk,
+		resolver: resolver,
+		logger:   logger,
+		grace:    grace,
+		now:      time.Now,
+	}
+}
+
+// Schedule closes the account of the tenant, provided it is not pending
+// deletion already, so that it is purged once the grace period ends.
+func (c *TenantCloser) Schedule(ctx context.Context, tenantID int, requestedBy string, reason string) (domain.Tenant, error) {
+	tenant, err := c.store.ScheduleTenantDeletion(ctx, tenantID, requestedBy, reason, c.now().Add(c.grace))
+	if !errors.Is(err, sql.ErrNoRows) {
+		return tenant, err
+	}
+
+	// either the tenant does not exist or its deletion is scheduled already
+	_, err = c.store.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	return domain.Tenant{}, ErrInvalidTenantStatus
+}
+
+// PurgeDue purges every tenant whose grace period ended. Tenants restored
+// since they were listed are left alone.
+func (c *TenantCloser) PurgeDue(ctx context.Context) error {
+	now := c.now()
+	tenants, err := c.store.GetTenantsDueForPurge(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, tenant := range tenants {
+		certificate, err := c.store.PurgeTenant(ctx, tenant.ID, now)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		c.resolver.Forget(tenant.Subdomain)
+		c.logger.Info("purged tenant", slog.Int("tenant_id", tenant.ID), slog.Int("certificate_id", certificate.ID))
+
+		// the archives of its exports are all that is left of the tenant
+		err = c.disk.RemoveTenant(tenant.ID)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run purges the tenants due for deletion every interval until ctx is done.
+func (c *TenantCloser) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.PurgeDue(ctx); err != nil {
+				c.logger.Error("purging tenants", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// tenantBlocked reports whether only the platform operator may act on the
+// tenant, as the operator suspended it or is deleting it.
+func tenantBlocked(tenant domain.Tenant) bool {
+	return tenant.Status == domain.TenantSuspended || deletionRequestedBy(tenant) == domain.DeletionByPlatform
+}
+
+func deletionRequestedBy(tenant domain.Tenant) string {
+	if tenant.Status != domain.TenantPendingDeletion || tenant.DeletionRequestedBy == nil {
+		return ""
+	}
+	return *tenant.DeletionRequestedBy
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/export"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeDueTenants(t *testing.T) {
+	newPurgeStore := func(purged *[]int) *mock.Store {
+		store := new(mock.Store)
+		store.GetTenantsDueForPurgeFn = func(ctx context.Context, now time.Time) ([]domain.Tenant, error) {
+			return []domain.Tenant{{ID: 1, Subdomain: "swolegym"}, {ID: 2, Subdomain: "ironworks"}}, nil
+		}
+		store.PurgeTenantFn = func(ctx context.Context, tenantID int, now time.Time) (domain.DeletionCertificate, error) {
+			*purged = append(*purged, tenantID)
+			return domain.DeletionCertificate{ID: tenantID, TenantID: tenantID}, nil
+		}
+		return store
+	}
+
+	t.Run("purges due tenants with their export archives", func(t *testing.T) {
+		var purged []int
+		store := newPurgeStore(&purged)
+
+		dir := t.TempDir()
+		for _, name := range []string{export.ArchiveName(1, 3), export.ArchiveName(1, 4), export.ArchiveName(3, 5)} {
+			os.WriteFile(filepath.Join(dir, name), []byte("zip"), 0o600)
+		}
+		closer := NewTenantCloser(slog.Default(), store, export.NewDisk(dir), NewTenantResolver(store, "gymulty.app", time.Minute), time.Hour)
+
+		err := closer.PurgeDue(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, purged, "due tenants should be purged")
+		left, _ := filepath.Glob(filepath.Join(dir, "*"))
+		assert.Equal(t, []string{filepath.Join(dir, export.ArchiveName(3, 5))}, left, "only archives of other tenants should be left")
+	})
+
+	t.Run("skips tenants restored in the meantime", func(t *testing.T) {
+		var purged []int
+		store := newPurgeStore(&purged)
+		store.PurgeTenantFn = func(ctx context.Context, tenantID int, now time.Time) (domain.DeletionCertificate, error) {
+			if tenantID == 1 {
+				return domain.DeletionCertificate{}, sql.ErrNoRows
+			}
+			purged = append(purged, tenantID)
+			return domain.DeletionCertificate{ID: 1, TenantID: tenantID}, nil
+		}
+
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, export.ArchiveName(1, 3)), []byte("zip"), 0o600)
+		closer := NewTenantCloser(slog.Default(), store, export.NewDisk(dir), NewTenantResolver(store, "gymulty.app", time.Minute), time.Hour)
+
+		err := closer.PurgeDue(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{2}, purged, "restored tenants should not be purged")
+		assert.FileExists(t, filepath.Join(dir, export.ArchiveName(1, 3)), "archives of restored tenants should be kept")
+	})
+
+	t.Run("purges the other tenants when one fails", func(t *testing.T) {
+		var purged []int
+		store := newPurgeStore(&purged)
+		store.PurgeTenantFn = func(ctx context.Context, tenantID int, now time.Time) (domain.DeletionCertificate, error) {
+			if tenantID == 1 {
+				return domain.DeletionCertificate{}, errors.New("connection reset")
+			}
+			purged = append(purged, tenantID)
+			return domain.DeletionCertificate{ID: 1, TenantID: tenantID}, nil
+		}
+		closer := NewTenantCloser(slog.Default(), store, export.NewDisk(t.TempDir()), NewTenantResolver(store, "gymulty.app", time.Minute), time.Hour)
+
+		err := closer.PurgeDue(context.Background())
+
+		assert.Error(t, err)
+		assert.Equal(t, []int{2}, purged, "other tenants should still be purged")
+	})
+}
+
+func newTestCloser(store *mock.Store, resolver *TenantResolver) *TenantCloser {
+	return NewTenantCloser(slog.Default(), store, export.NewDisk(os.TempDir()), resolver, 30*24*time.Hour)
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+func passwordResetEmail(appURL string, user domain.User, token string) domain.Email {
+	link := fmt.Sprintf("%s/reset-password?tenant=%d&token=%s", appURL, user.TenantID, url.QueryEscape(token))
+	return domain.Email{
+		To:      user.Email,
+		Subject: "Reset your gymulty password",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. "+
+			"Follow the link below to choose a new one:\n\n%s\n\n"+
+			"The link expires in %s and can only be used once. "+
+			"If you did not ask for a reset you can ignore this email.",
+			user.FirstName, link, passwordResetTTL),
+	}
+}
+
+func passwordSetupEmail(appURL string, user domain.User, token string) domain.Email {
+	link := fmt.Sprintf("%s/reset-password?tenant=%d&token=%s", appURL, user.TenantID, url.QueryEscape(token))
+	return domain.Email{
+		To:      user.Email,
+		Subject: "Set up your gymulty account",
+		Body: fmt.Sprintf("Hi %s,\n\nAn account has been created for you on gymulty. "+
+			"Follow the link below to choose your password:\n\n%s\n\n"+
+			"The link expires in %s and can only be used once. "+
+			"If it expired, ask for a password reset from the login page.",
+			user.FirstName, link, passwordSetupTTL),
+	}
+}
+
+func emailVerificationEmail(appURL string, user domain.User, token string) domain.Email {
+	link := fmt.Sprintf("%s/verify-email?tenant=%d&token=%s", appURL, user.TenantID, url.QueryEscape(token))
+	return domain.Email{
+		To:      user.Email,
+		Subject: "Confirm your gymulty email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by following the link below:\n\n%s\n\n"+
+			"The link expires in %s.",
+			user.FirstName, link, emailVerificationTTL),
+	}
+}
+
+func invitationEmail(appURL string, tenant domain.Tenant, invitation domain.Invitation, token string) domain.Email {
+	link := fmt.Sprintf("%s/accept-invite?tenant=%d&token=%s", appURL, invitation.TenantID, url.QueryEscape(token))
+	return domain.Email{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You have been invited to join %s on gymulty", tenant.BusinessName),
+		Body: fmt.Sprintf("Hi,\n\n%s invited you to join them on gymulty as a %s. "+
+			"Follow the link below to set up your account:\n\n%s\n\n"+
+			"The invitation expires on %s.",
+			tenant.BusinessName, invitation.Role, link, invitation.ExpiresAt.Format("January 2, 2006")),
+	}
+}
@@ -12,36 +12,116 @@ import (
 )
 
 const (
-	ErrMsgInternal          = "An internal server error ocurred. Please try again later"
-	ErrMsgInvalidResourceID = "Invalid resource id"
-	ErrMsgNotFound          = "The resource with specified id was not found"
+	ErrMsgInternal                 = "An internal server error ocurred. Please try again later"
+	ErrMsgInvalidResourceID        = "Invalid resource id"
+	ErrMsgNotFound                 = "The resource with specified id was not found"
+	ErrMsgInvalidCredentials       = "Invalid email or password"
+	ErrMsgMissingCredentials       = "Email and password are required"
+	ErrMsgUnauthorized             = "Missing or invalid access token"
+	ErrMsgTenantMismatch           = "Access token is not valid for this tenant"
+	ErrMsgPermissionDenied         = "You do not have permission to perform this action"
+	ErrMsgInvalidRoleName          = "Role name is required"
+	ErrMsgInvalidPermission        = "Unknown permission"
+	ErrMsgBuiltinRole              = "The built-in admin role cannot be modified"
+	ErrMsgBuiltinRoleName          = "The built-in roles cannot be renamed or deleted"
+	ErrMsgRolePermissionNotHeld    = "Roles cannot be given permissions you do not have yourself"
+	ErrMsgRoleInUse                = "Role is still assigned to one or more users"
+	ErrMsgRoleNotAssignable        = "You cannot give a role with permissions you do not have yourself"
+	ErrMsgOwnRole                  = "You cannot change your own role"
+	ErrMsgInvalidRefreshToken      = "Refresh token is invalid, expired or revoked"
+	ErrMsgMissingEmail             = "Email is required"
+	ErrMsgWeakPassword             = "Password must be at least 8 characters long"
+	ErrMsgWrongPassword            = "Current password is incorrect"
+	ErrMsgInvalidResetToken        = "Password reset token is invalid, expired or already used"
+	ErrMsgEmailNotVerified         = "Please confirm your email address before logging in"
+	ErrMsgInvalidVerificationToken = "Verification link is invalid or has expired"
+	ErrMsgInvalidTwoFactorCode     = "Invalid two-factor authentication code"
+	ErrMsgInvalidTwoFactorToken    = "Two-factor login has expired, please log in again"
+	ErrMsgTwoFactorNotEnrolled     = "Two-factor authentication has not been set up"
+	ErrMsgTwoFactorEnabled         = "Two-factor authentication is already enabled"
+	ErrMsgTwoFactorRequired        = "Two-factor authentication is required for admins of this gym"
+	ErrMsgTooManyAttempts          = "Too many failed login attempts, please try again later"
+	ErrMsgInvalidLimit             = "Limit must be a positive number"
+	ErrMsgInvalidAPIKeyName        = "API key name is required"
+	ErrMsgInvalidAPIKeyScope       = "API keys cannot be allowed to manage API keys"
+	ErrMsgAPIKeyScopeNotHeld       = "API keys cannot be given permissions you do not have yourself"
+	ErrMsgInvalidAPIKeyExpiry      = "API key expiry must be in the future"
+	ErrMsgInvalidInvitation        = "Email and role are required"
+	ErrMsgUserExists               = "A user with this email already exists"
+	ErrMsgInvalidInvitationToken   = "Invitation is invalid, expired or already accepted"
+	ErrMsgTenantNotVerified        = "Please confirm the email address of your account to unlock this action"
+	ErrMsgUnknownTenant            = "No gym is served from this address"
+	ErrMsgTenantSuspended          = "This gym has been suspended, please contact support"
+	ErrMsgTenantInactive           = "This gym has been deactivated and is read only until an admin reactivates it"
+	ErrMsgInvalidTenantStatus      = "The gym cannot change to this status from its current one"
+	ErrMsgTenantPendingDeletion    = "This gym is scheduled for deletion and is read only until an admin restores it"
+	ErrMsgMissingStatusReason      = "A reason is required"
+	ErrMsgInvalidBusinessName      = "Business name is required"
+	ErrMsgInvalidSubdomain         = "Subdomain must be 3 to 63 lowercase letters, digits or hyphens and cannot start or end with a hyphen"
+	ErrMsgReservedSubdomain        = "This subdomain is reserved, please pick another one"
+	ErrMsgInvalidTimezone          = "Timezone must be an IANA timezone such as Europe/Lisbon"
+	ErrMsgInvalidLocale            = "Locale must be a language tag such as en-US"
+	ErrMsgInvalidCurrency          = "Currency must be an ISO 4217 code such as USD"
+	ErrMsgInvalidWeekStart         = "Week start must be a day of the week"
+	ErrMsgInvalidOpeningHours      = "Opening hours need a day of the week and an opening time before the closing time, as HH:MM"
+	ErrMsgInvalidLocationName      = "Location name is required"
+	ErrMsgInvalidCountry           = "Country must be an ISO 3166 code such as PT"
+	ErrMsgUnknownLocation          = "One or more locations do not exist"
+	ErrMsgInvalidOffset            = "Offset must be zero or a positive number"
+	ErrMsgInvalidStatusFilter      = "Status must be active, inactive, suspended or pending_deletion"
+	ErrMsgInvalidOperator          = "Email and name are required"
+	ErrMsgMemberQuotaExceeded      = "Your plan's member limit has been reached, upgrade your plan to add more members"
+	ErrMsgInvitationQuotaExceeded  = "This gym cannot take any more members at the moment, please contact them"
+	ErrMsgClassQuotaExceeded       = "Your plan's weekly class limit has been reached for that week, upgrade your plan to schedule more classes"
+	ErrMsgInvalidDownloadLink      = "Download link is invalid or has expired, please request a new one"
+	ErrMsgExportUnavailable        = "The archive of this export is not available, it failed, has not completed yet or has expired"
+	ErrMsgInvalidImportFile        = "Upload a CSV file as \"file\" whose first row names its columns"
+	ErrMsgInvalidImportMapping     = "Column mapping must name columns of the file for email, first_name, last_name or role, and the file needs an email column"
+	ErrMsgImportTooLarge           = "An import can have at most 5000 rows"
+	ErrMsgInvalidDryRun            = "Dry run must be true or false"
+	ErrMsgInvalidEmail             = "Email is not a valid email address"
 )
 
 const (
-	ErrStatusInternal       = "internal_server_error"
-	ErrStatusUnauthorized   = "unauthorized"
-	ErrStatusNotFound       = "not_found"
-	ErrStatusBadRequest     = "malformed_request"
-	ErrStatusForbidden      = "permission_denied"
-	ErrStatusConflict       = "conflict"
-	ErrStatusNotImplemented = "not_implemented"
+	ErrStatusInternal        = "internal_server_error"
+	ErrStatusUnauthorized    = "unauthorized"
+	ErrStatusNotFound        = "not_found"
+	ErrStatusBadRequest      = "malformed_request"
+	ErrStatusForbidden       = "permission_denied"
+	ErrStatusConflict        = "conflict"
+	ErrStatusTooManyRequests = "too_many_requests"
+	ErrStatusNotImplemented  = "not_implemented"
+	ErrStatusQuotaExceeded   = "quota_exceeded"
 )
 
 var statusCode = map[string]int{
-	ErrStatusInternal:       http.StatusInternalServerError,
-	ErrStatusUnauthorized:   http.StatusUnauthorized,
-	ErrStatusNotFound:       http.StatusNotFound,
-	ErrStatusBadRequest:     http.StatusBadRequest,
-	ErrStatusForbidden:      http.StatusForbidden,
-	ErrStatusConflict:       http.StatusConflict,
-	ErrStatusNotImplemented: http.StatusNotImplemented,
+	ErrStatusInternal:        http.StatusInternalServerError,
+	ErrStatusUnauthorized:    http.StatusUnauthorized,
+	ErrStatusNotFound:        http.StatusNotFound,
+	ErrStatusBadRequest:      http.StatusBadRequest,
+	ErrStatusForbidden:       http.StatusForbidden,
+	ErrStatusConflict:        http.StatusConflict,
+	ErrStatusTooManyRequests: http.StatusTooManyRequests,
+	ErrStatusNotImplemented:  http.StatusNotImplemented,
+	ErrStatusQuotaExceeded:   http.StatusForbidden,
 }
 
 var constraintErrors = map[string]string{
-	"tenants_subdomain_key": "Subdomain already exists",
-	"tenants_status_check":  "Invalid value for status",
-	"users_email_key":       "Email already exists",
-	"users_role_check":      "Invalid value for role",
+	"tenants_subdomain_key":            "Subdomain already exists",
+	"tenants_subdomain_lower_key":      "Subdomain already exists",
+	"tenants_business_name_key":        "Business name already exists",
+	"tenants_status_check":             "Invalid value for status",
+	"tenant_settings_week_start_check": "Week start must be a day of the week",
+	"users_email_key":                  "Email already exists",
+	"users_role_check":                 "Invalid value for role",
+	"users_tenant_id_role_fkey":        "Invalid value for role",
+	"roles_tenant_id_name_key":         "Role already exists",
+	"locations_tenant_id_name_key":     "Location already exists",
+	"platform_operators_email_key":     "Email already exists",
+	"tenants_plan_fkey":                "Unknown plan",
+
+	"invitations_tenant_id_role_fkey": "Invalid value for role",
+	"invitations_pending_email_key":   "This email has already been invited",
 }
 
 type appError struct {
@@ -68,7 +148,7 @@ func (e *appError) withContext(err error, msg string, statusText string) *appErr
 		switch dbError.Code {
 		case "23505":
 			e.Code = ErrStatusConflict
-		case "23514":
+		case "23514", "23503":
 			e.Code = ErrStatusBadRequest
 		}
 	}
@@ -0,0 +1,264 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/export"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+const (
+	purposeExportDownload  = "export_download" // marks the tokens of download links
+	expiredExportsInterval = time.Hour         // how often expired archives are removed
+)
+
+var ErrExportUnavailable = errors.New("export: archive is not available")
+
+// Exporter builds the archives of tenant exports in the background and
+// keeps them on disk until they expire. An export interrupted by a restart
+// stays running; the tenant can always request another one.
+type Exporter struct {
+	store   domain.ExportStore
+	disk    *export.Disk
+	tokens  *TokenManager
+	logger  *slog.Logger
+	ttl     time.Duration // how long archives are kept
+	linkTTL time.Duration // how long download links work
+	now     func() time.Time
+}
+
+func NewExporter(logger *slog.Logger, store domain.ExportStore, disk *export.Disk, tokens *TokenManager, ttl time.Duration, linkTTL time.Duration) *Exporter {
+	return &Exporter{
+		store:   store,
+		disk:    disk,
+		tokens:  tokens,
+		logger:  logger,
+		ttl:     ttl,
+		linkTTL: linkTTL,
+		now:     time.Now,
+	}
+}
+
+// Start builds the archive of ex in the background. It keeps going after
+// ctx, usually that of the request, is canceled.
+func (x *Exporter) Start(ctx context.Context, ex domain.Export) {
+	go x.run(context.WithoutCancel(ctx), ex)
+}
+
+func (x *Exporter) run(ctx context.Context, ex domain.Export) {
+	logger := x.logger.With(slog.Int("tenant_id", ex.TenantID), slog.Int("export_id", ex.ID))
+
+	_, err := x.store.SetExportStatus(ctx, ex.TenantID, ex.ID, domain.ExportRunning, nil)
+	if err == nil {
+		err = x.build(ctx, ex)
+	}
+	if err != nil {
+		logger.Error("building export", slog.String("error", err.Error()))
+		_, err = x.store.SetExportStatus(ctx, ex.TenantID, ex.ID, domain.ExportFailed, nil)
+		if err != nil {
+			logger.Error("marking export failed", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	expiresAt := x.now().Add(x.ttl)
+	_, err = x.store.SetExportStatus(ctx, ex.TenantID, ex.ID, domain.ExportCompleted, &expiresAt)
+	if err != nil {
+		logger.Error("marking export completed", slog.String("error", err.Error()))
+		x.disk.Remove(export.ArchiveName(ex.TenantID, ex.ID))
+	}
+}
+
+func (x *Exporter) build(ctx context.Context, ex domain.Export) error {
+	records, err := x.store.GetTenantRecords(ctx, ex.TenantID)
+	if err != nil {
+		return err
+	}
+	return x.disk.Save(export.ArchiveName(ex.TenantID, ex.ID), func(w io.Writer) error {
+		return export.WriteArchive(w, records)
+	})
+}
+
+// DownloadLink returns the path of a link to the archive of a completed
+// export. The link stops working after linkTTL or when the archive expires,
+// whichever comes first, and needs no access token to be followed.
+func (x *Exporter) DownloadLink(ex domain.Export) (string, error) {
+	ttl := min(x.linkTTL, ex.ExpiresAt.Sub(x.now()))
+	claims := Claims{TenantID: ex.TenantID, ExportID: ex.ID, Purpose: purposeExportDownload}
+
+	token, _, err := x.tokens.issue(claims, ttl)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/api/tenants/%d/exports/%d/download?token=%s", ex.TenantID, ex.ID, token), nil
+}
+
+// RemoveExpired removes the archives of all tenants that expired.
+func (x *Exporter) RemoveExpired(ctx context.Context) error {
+	exports, err := x.store.ExpireExports(ctx, x.now())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, ex := range exports {
+		errs = append(errs, x.disk.Remove(export.ArchiveName(ex.TenantID, ex.ID)))
+	}
+	return errors.Join(errs...)
+}
+
+// Run removes expired archives every interval until ctx is done.
+func (x *Exporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := x.RemoveExpired(ctx); err != nil {
+				x.logger.Error("removing expired exports", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// ExportHandler serves the exports of all data of a tenant. Exports can be
+// requested while the tenant is deactivated, as gyms leaving need them most.
+type ExportHandler struct {
+	http.Handler
+	store    domain.ExportStore
+	exporter *Exporter
+	logger   *slog.Logger
+}
+
+func NewExportHandler(logger *slog.Logger, store domain.ExportStore, exporter *Exporter) *ExportHandler {
+	router := http.NewServeMux()
+
+	handler := &ExportHandler{
+		Handler:  middleware.StripSlashes(router),
+		store:    store,
+		exporter: exporter,
+		logger:   logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (h *ExportHandler) registerRoutes(router *http.ServeMux) {
+	exportData := Allow(domain.PermDataExport)
+
+	router.Handle("POST /api/tenants/{tenantID}/exports", authorizeInactive(h.logger, exportData, h.createExport))
+	router.Handle("GET /api/tenants/{tenantID}/exports/{exportID}", authorize(h.logger, exportData, h.getExport))
+	router.Handle("GET /api/tenants/{tenantID}/exports/{exportID}/download", errorHandler(h.downloadExport))
+}
+
+func (h *ExportHandler) createExport(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	ex := domain.Export{Status: domain.ExportPending}
+	if p.UserID != 0 {
+		ex.RequestedBy = &p.UserID
+	}
+
+	ex, err := h.store.CreateExport(r.Context(), p.TenantID, ex)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	h.exporter.Start(r.Context(), ex)
+
+	w.Header().Set("Location", fmt.Sprintf("/api/tenants/%d/exports/%d", p.TenantID, ex.ID))
+
+	res := Response[ExportResponse]{Count: 1, Data: ExportResponse{Export: ex}}
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// getExport reports the status of an export. Once it completed, every call
+// hands out a fresh download link.
+func (h *ExportHandler) getExport(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	exportID, err := strconv.Atoi(r.PathValue("exportID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	ex, err := h.store.GetExportByID(r.Context(), p.TenantID, exportID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	data := ExportResponse{Export: ex}
+	if h.downloadable(ex) {
+		data.DownloadURL, err = h.exporter.DownloadLink(ex)
+		if err != nil {
+			return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+		}
+	}
+
+	res := Response[ExportResponse]{Count: 1, Data: data}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// downloadExport serves the archive to whoever follows a valid download
+// link, without an access token, so that browsers can download it.
+func (h *ExportHandler) downloadExport(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+	exportID, err := strconv.Atoi(r.PathValue("exportID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	claims, err := h.exporter.tokens.VerifyFor(purposeExportDownload, r.URL.Query().Get("token"))
+	if err == nil && (claims.TenantID != tenantID || claims.ExportID != exportID) {
+		err = ErrInvalidToken
+	}
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidDownloadLink, ErrStatusForbidden)
+	}
+
+	ex, err := h.store.GetExportByID(r.Context(), tenantID, exportID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	if !h.downloadable(ex) {
+		return e.withContext(ErrExportUnavailable, ErrMsgExportUnavailable, ErrStatusNotFound)
+	}
+
+	name := export.ArchiveName(tenantID, exportID)
+	f, err := h.exporter.disk.Open(name)
+	if err != nil {
+		return e.withContext(err, ErrMsgExportUnavailable, ErrStatusNotFound)
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	http.ServeContent(w, r, name, ex.UpdatedAt, f)
+	return nil
+}
+
+func (h *ExportHandler) downloadable(ex domain.Export) bool {
+	return ex.Status == domain.ExportCompleted && ex.ExpiresAt != nil && ex.ExpiresAt.After(h.exporter.now())
+}
@@ -0,0 +1,260 @@
+package http
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/export"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateExport(t *testing.T) {
+	t.Run("builds the archive in the background", func(t *testing.T) {
+		store := newExportStore()
+		exporter := newTestExporter(t, store)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/exports", nil)
+		res := newExportRequest(store, exporter, withDefaultPrincipal(req))
+
+		var got Response[ExportResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 202, res.Code, "status codes should be equal")
+		assert.Equal(t, "/api/tenants/1/exports/1", res.Header().Get("Location"), "locations should be equal")
+		assert.Equal(t, domain.ExportPending, got.Data.Status, "statuses should be equal")
+		assert.Equal(t, 1, *got.Data.RequestedBy, "requesters should be equal")
+
+		assert.Eventually(t, func() bool {
+			return store.export().Status == domain.ExportCompleted
+		}, time.Second, 10*time.Millisecond, "export should complete")
+		assert.Equal(t, []string{domain.ExportRunning, domain.ExportCompleted}, store.statuses(), "statuses should be equal")
+	})
+
+	t.Run("marks the export failed when the records cannot be read", func(t *testing.T) {
+		store := newExportStore()
+		store.GetTenantRecordsFn = func(ctx context.Context, tenantID int) (domain.TenantRecords, error) {
+			return domain.TenantRecords{}, context.DeadlineExceeded
+		}
+		exporter := newTestExporter(t, store)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/exports", nil)
+		newExportRequest(store, exporter, withDefaultPrincipal(req))
+
+		assert.Eventually(t, func() bool {
+			return store.export().Status == domain.ExportFailed
+		}, time.Second, 10*time.Millisecond, "export should fail")
+	})
+
+	t.Run("returns 403 status code for members", func(t *testing.T) {
+		store := newExportStore()
+		exporter := newTestExporter(t, store)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/exports", nil)
+		res := newExportRequest(store, exporter, asPrincipal(req, testPrincipal(7, domain.RoleMember)))
+
+		assertPermissionDenied(t, res)
+		assert.Zero(t, store.export().ID, "no export should be created")
+	})
+}
+
+func TestGetExport(t *testing.T) {
+	t.Run("hands out no download link before the export completed", func(t *testing.T) {
+		store := newExportStore()
+		store.set(domain.Export{ID: 1, TenantID: 1, Status: domain.ExportRunning})
+
+		req := httptest.NewRequest("GET", "/api/tenants/1/exports/1", nil)
+		res := newExportRequest(store, newTestExporter(t, store), withDefaultPrincipal(req))
+
+		var got Response[ExportResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Empty(t, got.Data.DownloadURL, "there should be no download link")
+	})
+
+	t.Run("hands out no download link once the archive expired", func(t *testing.T) {
+		store := newExportStore()
+		expiresAt := time.Now().Add(-time.Minute)
+		store.set(domain.Export{ID: 1, TenantID: 1, Status: domain.ExportCompleted, ExpiresAt: &expiresAt})
+
+		req := httptest.NewRequest("GET", "/api/tenants/1/exports/1", nil)
+		res := newExportRequest(store, newTestExporter(t, store), withDefaultPrincipal(req))
+
+		var got Response[ExportResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Empty(t, got.Data.DownloadURL, "there should be no download link")
+	})
+}
+
+func TestDownloadExport(t *testing.T) {
+	completedExport := func(t *testing.T) (*exportStore, *Exporter, string) {
+		t.Helper()
+		store := newExportStore()
+		exporter := newTestExporter(t, store)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/exports", nil)
+		newExportRequest(store, exporter, withDefaultPrincipal(req))
+		assert.Eventually(t, func() bool {
+			return store.export().Status == domain.ExportCompleted
+		}, time.Second, 10*time.Millisecond, "export should complete")
+
+		req = httptest.NewRequest("GET", "/api/tenants/1/exports/1", nil)
+		res := newExportRequest(store, exporter, withDefaultPrincipal(req))
+
+		var got Response[ExportResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		return store, exporter, got.Data.DownloadURL
+	}
+
+	t.Run("serves a JSON and CSV file per entity without passwords", func(t *testing.T) {
+		store, exporter, link := completedExport(t)
+
+		req := httptest.NewRequest("GET", link, nil)
+		res := newExportRequest(store, exporter, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, "application/zip", res.Header().Get("Content-Type"), "content types should be equal")
+
+		archive, err := zip.NewReader(bytes.NewReader(res.Body.Bytes()), int64(res.Body.Len()))
+		assert.NoError(t, err)
+
+		files := map[string][]byte{}
+		for _, f := range archive.File {
+			r, _ := f.Open()
+			files[f.Name], _ = io.ReadAll(r)
+			r.Close()
+		}
+		assert.Contains(t, files, "classes.json")
+		assert.Contains(t, files, "class_locations.csv")
+		assert.NotContains(t, string(files["users.json"]), "password", "passwords should not be exported")
+		assert.NotContains(t, string(files["invitations.json"]), "token", "token hashes should not be exported")
+
+		users, err := csv.NewReader(bytes.NewReader(files["users.csv"])).ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"id", "tenant_id", "first_name", "last_name", "email", "role", "verified_at", "created_at", "updated_at"}, users[0], "columns should be equal")
+		assert.Equal(t, "jane@gym.com", users[1][4], "emails should be equal")
+
+		roles, err := csv.NewReader(bytes.NewReader(files["roles.csv"])).ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, `["classes:read"]`, roles[1][4], "permissions should be JSON encoded")
+	})
+
+	t.Run("returns 403 status code for a link to another export", func(t *testing.T) {
+		store, exporter, link := completedExport(t)
+		_, token, _ := strings.Cut(link, "?token=")
+
+		req := httptest.NewRequest("GET", "/api/tenants/1/exports/2/download?token="+token, nil)
+		res := newExportRequest(store, exporter, req)
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 404 status code once the archive expired", func(t *testing.T) {
+		store, exporter, link := completedExport(t)
+		exporter.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		exporter.tokens.now = exporter.now
+
+		err := exporter.RemoveExpired(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, domain.ExportExpired, store.export().Status, "statuses should be equal")
+
+		exporter.tokens.now = time.Now
+		req := httptest.NewRequest("GET", link, nil)
+		res := newExportRequest(store, exporter, req)
+
+		assert.Equal(t, 404, res.Code, "status codes should be equal")
+	})
+}
+
+// exportStore keeps a single export in memory, which the exporter updates
+// from another goroutine.
+type exportStore struct {
+	*mock.Store
+	mu      sync.Mutex
+	current domain.Export
+	history []string
+}
+
+func newExportStore() *exportStore {
+	s := &exportStore{Store: new(mock.Store)}
+	s.CreateExportFn = func(ctx context.Context, tenantID int, ex domain.Export) (domain.Export, error) {
+		ex.ID, ex.TenantID = 1, tenantID
+		s.set(ex)
+		return ex, nil
+	}
+	s.GetExportByIDFn = func(ctx context.Context, tenantID int, exportID int) (domain.Export, error) {
+		ex := s.export()
+		if ex.ID != exportID || ex.TenantID != tenantID {
+			return domain.Export{}, sql.ErrNoRows
+		}
+		return ex, nil
+	}
+	s.SetExportStatusFn = func(ctx context.Context, tenantID int, exportID int, status string, expiresAt *time.Time) (domain.Export, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.current.Status, s.current.ExpiresAt = status, expiresAt
+		s.history = append(s.history, status)
+		return s.current, nil
+	}
+	s.ExpireExportsFn = func(ctx context.Context, now time.Time) ([]domain.Export, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.current.Status != domain.ExportCompleted || s.current.ExpiresAt.After(now) {
+			return nil, nil
+		}
+		s.current.Status = domain.ExportExpired
+		return []domain.Export{s.current}, nil
+	}
+	s.GetTenantRecordsFn = func(ctx context.Context, tenantID int) (domain.TenantRecords, error) {
+		return domain.TenantRecords{
+			Tenant:      domain.Tenant{ID: tenantID, BusinessName: "Iron Works"},
+			Users:       []domain.User{{ID: 1, TenantID: tenantID, Email: "jane@gym.com", Password: "$2a$10$hash", Role: domain.RoleMember}},
+			Roles:       []domain.Role{{ID: 3, TenantID: tenantID, Name: domain.RoleMember, Permissions: []string{domain.PermClassesRead}}},
+			Classes:     []domain.Class{{ID: 2, TenantID: tenantID, Name: "Spinning"}},
+			Invitations: []domain.Invitation{{ID: 4, TenantID: tenantID, Email: "joe@gym.com", TokenHash: "secret"}},
+		}, nil
+	}
+	return s
+}
+
+func (s *exportStore) set(ex domain.Export) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = ex
+}
+
+func (s *exportStore) export() domain.Export {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current
+}
+
+func (s *exportStore) statuses() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.history...)
+}
+
+func newTestExporter(t *testing.T, store domain.ExportStore) *Exporter {
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+	return NewExporter(slog.Default(), store, export.NewDisk(t.TempDir()), tokens, time.Hour, 15*time.Minute)
+}
+
+func newExportRequest(store domain.ExportStore, exporter *Exporter, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewExportHandler(slog.Default(), store, exporter)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
@@ -0,0 +1,381 @@
+package http
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+const (
+	maxImportSize = 5 << 20 // bytes of the uploaded file
+	maxImportRows = 5000
+)
+
+var (
+	ErrInvalidImport        = errors.New("import: invalid file")
+	ErrInvalidImportMapping = errors.New("import: invalid column mapping")
+	ErrImportTooLarge       = errors.New("import: too many rows")
+)
+
+// importFields are the user fields a column of an import can fill. Unless
+// mapped otherwise, a column fills the field it is named after.
+var importFields = []string{"email", "first_name", "last_name", "role"}
+
+// importRow is a row of an import as the invitation it becomes.
+type importRow struct {
+	line       int
+	invitation domain.Invitation
+	member     bool // the role counts against the member quota, set by validate
+}
+
+// ImportHandler imports the members of gyms moving over from other software.
+// Every row of the uploaded CSV file becomes an invitation, so members pick
+// their own password, and either all rows are imported or none.
+type ImportHandler struct {
+	http.Handler
+	store  domain.Store
+	quotas *Quotas
+	mailer domain.Mailer
+	appURL string
+	logger *slog.Logger
+}
+
+func NewImportHandler(logger *slog.Logger, store domain.Store, quotas *Quotas, mailer domain.Mailer, appURL string) *ImportHandler {
+	router := http.NewServeMux()
+
+	handler := &ImportHandler{
+		Handler: middleware.StripSlashes(router),
+		store:   store,
+		quotas:  quotas,
+		mailer:  mailer,
+		appURL:  appURL,
+		logger:  logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (h *ImportHandler) registerRoutes(router *http.ServeMux) {
+	router.Handle("POST /api/tenants/{tenantID}/users/import", authorize(h.logger, Allow(domain.PermUsersWrite), verifiedTenant(h.logger, h.importUsers)))
+}
+
+// importUsers takes a multipart form with the CSV file as "file" and an
+// optional "mapping" of fields to column names, e.g. {"email": "E-mail"}.
+// With ?dry_run=true it only reports the rows that would fail.
+func (h *ImportHandler) importUsers(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			return e.withContext(err, ErrMsgInvalidDryRun, ErrStatusBadRequest)
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidImportFile, ErrStatusBadRequest)
+	}
+	defer file.Close()
+
+	mapping := map[string]string{}
+	if value := r.FormValue("mapping"); value != "" {
+		err = json.Unmarshal([]byte(value), &mapping)
+		if err != nil {
+			return e.withContext(err, ErrMsgInvalidImportMapping, ErrStatusBadRequest)
+		}
+	}
+
+	rows, err := parseImport(file, mapping)
+	switch {
+	case errors.Is(err, ErrInvalidImportMapping):
+		return e.withContext(err, ErrMsgInvalidImportMapping, ErrStatusBadRequest)
+	case errors.Is(err, ErrImportTooLarge):
+		return e.withContext(err, ErrMsgImportTooLarge, ErrStatusBadRequest)
+	case err != nil:
+		return e.withContext(err, ErrMsgInvalidImportFile, ErrStatusBadRequest)
+	}
+
+	rowErrors, err := h.validate(r.Context(), p, rows)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[ImportResponse]{
+		Count: len(rows),
+		Data:  ImportResponse{DryRun: dryRun, Rows: len(rows), Errors: rowErrors},
+	}
+	if len(rowErrors) > 0 && !dryRun {
+		// the report tells which rows to fix before trying again
+		h.logger.Info("rejecting import", slog.Int("tenant_id", p.TenantID), slog.Int("invalid_rows", len(rowErrors)))
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(res)
+		return nil
+	}
+
+	members := 0
+	for _, row := range rows {
+		if row.member {
+			members++
+		}
+	}
+	if dryRun {
+		if members > 0 {
+			err = h.quotas.CheckMembers(r.Context(), h.store, p.TenantID, members)
+			if err != nil {
+				return quotaError(e, err, ErrMsgMemberQuotaExceeded)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(res)
+		return nil
+	}
+
+	expiresAt := time.Now().Add(invitationTTL)
+	tokens := make([]string, len(rows))
+	for i := range rows {
+		rows[i].invitation.ExpiresAt = expiresAt
+		tokens[i], rows[i].invitation.TokenHash, err = NewOpaqueToken()
+		if err != nil {
+			return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+		}
+		if p.UserID != 0 {
+			rows[i].invitation.InvitedBy = &p.UserID
+		}
+	}
+
+	// an email taken since validating rolls back every row, not only its own
+	invitations := make([]domain.Invitation, len(rows))
+	err = h.store.WithTx(r.Context(), func(tx domain.Store) error {
+		if members > 0 {
+			err := h.quotas.CheckMembers(r.Context(), tx, p.TenantID, members)
+			if err != nil {
+				return err
+			}
+		}
+		for i, row := range rows {
+			var err error
+			invitations[i], err = tx.CreateInvitation(r.Context(), p.TenantID, row.invitation)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return quotaError(e, err, ErrMsgMemberQuotaExceeded)
+	}
+
+	// sending hundreds of emails would outlast the request, and invitations
+	// whose email got lost can be resent
+	go h.send(context.WithoutCancel(r.Context()), p.TenantID, invitations, tokens)
+
+	res.Data.Invitations = invitations
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// validate reports the rows that cannot be imported as they are. Emails must
+// be valid, unique within the file and neither taken by a user nor invited
+// already, and roles must exist and be ones p may give, see assignableRole.
+// Role names are matched regardless of case and replaced by the stored name.
+func (h *ImportHandler) validate(ctx context.Context, p Principal, rows []importRow) ([]ImportRowError, error) {
+	roles, err := h.store.GetAllRoles(ctx, p.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	// an exact match wins over roles whose names only differ in case
+	rolesByName := map[string]domain.Role{}
+	for _, role := range roles {
+		if _, ok := rolesByName[strings.ToLower(role.Name)]; !ok {
+			rolesByName[strings.ToLower(role.Name)] = role
+		}
+	}
+	for _, role := range roles {
+		rolesByName[role.Name] = role
+	}
+
+	invitations, err := h.store.GetAllInvitations(ctx, p.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	invited := map[string]bool{}
+	for _, invitation := range invitations {
+		if invitation.AcceptedAt == nil && invitation.RevokedAt == nil {
+			invited[normalizeEmail(invitation.Email)] = true
+		}
+	}
+
+	rowErrors := []ImportRowError{}
+	firstLine := map[string]int{}
+	var emails []string
+	for i, row := range rows {
+		email := row.invitation.Email
+		switch {
+		case email == "":
+			rowErrors = append(rowErrors, ImportRowError{Row: row.line, Field: "email", Message: ErrMsgMissingEmail})
+		case !validEmail(email):
+			rowErrors = append(rowErrors, ImportRowError{Row: row.line, Field: "email", Message: ErrMsgInvalidEmail})
+		case firstLine[email] != 0:
+			msg := fmt.Sprintf("Email already appears on row %d", firstLine[email])
+			rowErrors = append(rowErrors, ImportRowError{Row: row.line, Field: "email", Message: msg})
+		case invited[email]:
+			rowErrors = append(rowErrors, ImportRowError{Row: row.line, Field: "email", Message: constraintErrors["invitations_pending_email_key"]})
+		default:
+			firstLine[email] = row.line
+			emails = append(emails, email)
+		}
+
+		role, ok := rolesByName[row.invitation.Role]
+		if !ok {
+			role, ok = rolesByName[strings.ToLower(row.invitation.Role)]
+		}
+		switch {
+		case !ok:
+			rowErrors = append(rowErrors, ImportRowError{Row: row.line, Field: "role", Message: constraintErrors["invitations_tenant_id_role_fkey"]})
+		case !canGrant(p, role.Permissions):
+			rowErrors = append(rowErrors, ImportRowError{Row: row.line, Field: "role", Message: ErrMsgRoleNotAssignable})
+		default:
+			rows[i].invitation.Role = role.Name
+			rows[i].member = domain.IsMemberRole(role.Permissions)
+		}
+	}
+
+	if len(emails) == 0 {
+		return rowErrors, nil
+	}
+	taken, err := h.store.GetTakenEmails(ctx, p.TenantID, emails)
+	if err != nil {
+		return nil, err
+	}
+	for _, email := range taken {
+		if line, ok := firstLine[email]; ok {
+			rowErrors = append(rowErrors, ImportRowError{Row: line, Field: "email", Message: ErrMsgUserExists})
+		}
+	}
+	slices.SortStableFunc(rowErrors, func(a, b ImportRowError) int { return cmp.Compare(a.Row, b.Row) })
+	return rowErrors, nil
+}
+
+// send emails the invitation links of an import. Like InvitationHandler.send
+// it only logs failures.
+func (h *ImportHandler) send(ctx context.Context, tenantID int, invitations []domain.Invitation, tokens []string) {
+	tenant, err := h.store.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		h.logger.Error("sending import invitation emails", slog.String("error", err.Error()))
+		return
+	}
+
+	for i, invitation := range invitations {
+		err = h.mailer.Send(ctx, invitationEmail(h.appURL, tenant, invitation, tokens[i]))
+		if err != nil {
+			h.logger.Error("sending invitation email", slog.Int("invitation_id", invitation.ID), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// parseImport reads the rows of a CSV file whose first row names its
+// columns. mapping names the column of each field that is not named after
+// it; only the email column is required. Blank rows are skipped, and rows
+// are numbered by the line they start on, counting the header.
+func parseImport(r io.Reader, mapping map[string]string) ([]importRow, error) {
+	for field := range mapping {
+		if !slices.Contains(importFields, field) {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidImportMapping, field)
+		}
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+	// spreadsheet software likes to start files with a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	columns := map[string]int{}
+	for _, field := range importFields {
+		name, mapped := mapping[field]
+		if !mapped {
+			name = field
+		}
+
+		columns[field] = -1
+		for i, column := range header {
+			if strings.EqualFold(strings.TrimSpace(column), strings.TrimSpace(name)) {
+				columns[field] = i
+				break
+			}
+		}
+		if columns[field] == -1 && (mapped || field == "email") {
+			return nil, fmt.Errorf("%w: no column %q", ErrInvalidImportMapping, name)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+		}
+
+		cell := func(field string) string {
+			i := columns[field]
+			if i == -1 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, ErrImportTooLarge
+		}
+
+		line, _ := reader.FieldPos(0)
+		invitation := domain.Invitation{
+			Email:     normalizeEmail(cell("email")),
+			FirstName: cell("first_name"),
+			LastName:  cell("last_name"),
+			Role:      cell("role"),
+		}
+		if invitation.Role == "" {
+			invitation.Role = domain.RoleMember
+		}
+		rows = append(rows, importRow{line: line, invitation: invitation})
+	}
+	return rows, nil
+}
+
+// validEmail reports whether email is a bare address such as jane@gym.com.
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
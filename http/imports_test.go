@@ -0,0 +1,291 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportUsers(t *testing.T) {
+	newStore := func(created *[]domain.Invitation) *mock.Store {
+		store := new(mock.Store)
+		store.GetAllRolesFn = func(ctx context.Context, tenantID int) ([]domain.Role, error) {
+			var roles []domain.Role
+			for _, name := range []string{domain.RoleAdmin, domain.RoleTrainer, domain.RoleMember} {
+				roles = append(roles, domain.Role{Name: name, Permissions: domain.DefaultRolePermissions[name]})
+			}
+			return roles, nil
+		}
+		store.GetAllInvitationsFn = func(ctx context.Context, tenantID int) ([]domain.Invitation, error) {
+			now := time.Now()
+			return []domain.Invitation{
+				{Email: "pending@gym.com"},
+				{Email: "accepted@gym.com", AcceptedAt: &now},
+			}, nil
+		}
+		store.GetTakenEmailsFn = func(ctx context.Context, tenantID int, emails []string) ([]string, error) {
+			var taken []string
+			for _, email := range emails {
+				if email == "taken@gym.com" {
+					taken = append(taken, email)
+				}
+			}
+			return taken, nil
+		}
+		store.CreateInvitationFn = func(ctx context.Context, tenantID int, invitation domain.Invitation) (domain.Invitation, error) {
+			invitation.ID = len(*created) + 1
+			invitation.TenantID = tenantID
+			*created = append(*created, invitation)
+			return invitation, nil
+		}
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{ID: tenantID, BusinessName: "SwoleGym"}, nil
+		}
+		return store
+	}
+
+	invalidFile := "email,first_name,role\n" +
+		"ann@gym.com,Ann,member\n" +
+		"not-an-email,Bob,member\n" +
+		"\n" +
+		"ANN@gym.com,Annie,member\n" +
+		"taken@gym.com,Tom,member\n" +
+		"pending@gym.com,Pam,member\n" +
+		"accepted@gym.com,Al,coach\n" +
+		",Nobody,member\n"
+
+	wantErrors := []ImportRowError{
+		{Row: 3, Field: "email", Message: ErrMsgInvalidEmail},
+		{Row: 5, Field: "email", Message: "Email already appears on row 2"},
+		{Row: 6, Field: "email", Message: ErrMsgUserExists},
+		{Row: 7, Field: "email", Message: "This email has already been invited"},
+		{Row: 8, Field: "role", Message: "Invalid value for role"},
+		{Row: 9, Field: "email", Message: ErrMsgMissingEmail},
+	}
+
+	t.Run("reports invalid rows of a dry run", func(t *testing.T) {
+		var created []domain.Invitation
+		store := newStore(&created)
+
+		req := newImportUpload(t, "/api/tenants/1/users/import?dry_run=true", invalidFile, "")
+		res := newImportRequest(store, unlimitedQuotas(), discardMailer(), withDefaultPrincipal(req))
+
+		var got Response[ImportResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.True(t, got.Data.DryRun, "import should be a dry run")
+		assert.Equal(t, 7, got.Data.Rows, "blank rows should be skipped")
+		assert.Equal(t, wantErrors, got.Data.Errors, "row errors should be equal")
+		assert.Empty(t, created, "a dry run should import nothing")
+	})
+
+	t.Run("imports nothing when a row is invalid", func(t *testing.T) {
+		var created []domain.Invitation
+		store := newStore(&created)
+
+		req := newImportUpload(t, "/api/tenants/1/users/import", invalidFile, "")
+		res := newImportRequest(store, unlimitedQuotas(), discardMailer(), withDefaultPrincipal(req))
+
+		var got Response[ImportResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+		assert.Equal(t, wantErrors, got.Data.Errors, "row errors should be equal")
+		assert.Empty(t, created, "no row should be imported")
+	})
+
+	t.Run("invites every row with mapped columns", func(t *testing.T) {
+		var created []domain.Invitation
+		store := newStore(&created)
+
+		var mu sync.Mutex
+		var sent []domain.Email
+		mailer := new(mock.Mailer)
+		mailer.SendFn = func(ctx context.Context, email domain.Email) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, email)
+			return nil
+		}
+
+		file := "\ufeffE-Mail,Given name,Surname,Type\n" +
+			"Ann@Gym.com,Ann,Lee,\n" +
+			"bob@gym.com,Bob,\"Stone, Jr.\",Trainer\n"
+		mapping := `{"email": "e-mail", "first_name": "Given name", "last_name": "Surname", "role": "Type"}`
+
+		req := newImportUpload(t, "/api/tenants/1/users/import", file, mapping)
+		res := newImportRequest(store, unlimitedQuotas(), mailer, withDefaultPrincipal(req))
+
+		var got Response[ImportResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.Empty(t, got.Data.Errors, "there should be no row errors")
+		assert.Len(t, got.Data.Invitations, 2, "every row should be invited")
+
+		assert.Equal(t, "ann@gym.com", created[0].Email, "emails should be normalized")
+		assert.Equal(t, "Lee", created[0].LastName, "last names should be equal")
+		assert.Equal(t, domain.RoleMember, created[0].Role, "roles should default to member")
+		assert.Equal(t, "Stone, Jr.", created[1].LastName, "last names should be equal")
+		assert.Equal(t, domain.RoleTrainer, created[1].Role, "roles should be equal")
+		assert.Equal(t, 1, *created[1].InvitedBy, "inviter should be recorded")
+		assert.NotEmpty(t, created[1].TokenHash, "invitations should have a token")
+
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(sent) == 2
+		}, time.Second, 10*time.Millisecond, "every invitee should be emailed")
+	})
+
+	t.Run("matches custom role names regardless of case", func(t *testing.T) {
+		var created []domain.Invitation
+		store := newStore(&created)
+		store.GetAllRolesFn = func(ctx context.Context, tenantID int) ([]domain.Role, error) {
+			return []domain.Role{
+				{Name: domain.RoleMember, Permissions: domain.DefaultRolePermissions[domain.RoleMember]},
+				{Name: "Front Desk", Permissions: []string{domain.PermUsersRead}},
+			}, nil
+		}
+
+		file := "email,role\nann@gym.com,front desk\nbob@gym.com,FRONT DESK\ncid@gym.com,Front Desk\n"
+		req := newImportUpload(t, "/api/tenants/1/users/import", file, "")
+		res := newImportRequest(store, unlimitedQuotas(), discardMailer(), withDefaultPrincipal(req))
+
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.Len(t, created, 3, "every row should be imported")
+		for _, invitation := range created {
+			assert.Equal(t, "Front Desk", invitation.Role, "roles should be stored by their name")
+		}
+	})
+
+	t.Run("returns 409 status code when an email is taken while importing", func(t *testing.T) {
+		var created []domain.Invitation
+		store := newStore(&created)
+		store.CreateInvitationFn = func(ctx context.Context, tenantID int, invitation domain.Invitation) (domain.Invitation, error) {
+			if invitation.Email == "bob@gym.com" {
+				return domain.Invitation{}, &pgconn.PgError{Code: "23505", ConstraintName: "invitations_pending_email_key"}
+			}
+			created = append(created, invitation)
+			return invitation, nil
+		}
+		var rolledBack bool
+		store.WithTxFn = func(ctx context.Context, fn func(tx domain.Store) error) error {
+			err := fn(store)
+			rolledBack = err != nil
+			return err
+		}
+
+		req := newImportUpload(t, "/api/tenants/1/users/import", "email\nann@gym.com\nbob@gym.com\n", "")
+		res := newImportRequest(store, unlimitedQuotas(), discardMailer(), withDefaultPrincipal(req))
+
+		assert.Equal(t, 409, res.Code, "status codes should be equal")
+		assert.True(t, rolledBack, "the whole import should be rolled back")
+	})
+
+	t.Run("returns 400 status code when a mapped column is missing", func(t *testing.T) {
+		var created []domain.Invitation
+		store := newStore(&created)
+
+		req := newImportUpload(t, "/api/tenants/1/users/import", "email,name\nann@gym.com,Ann\n", `{"first_name": "First name"}`)
+		res := newImportRequest(store, unlimitedQuotas(), discardMailer(), withDefaultPrincipal(req))
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+		assert.Contains(t, res.Body.String(), ErrMsgInvalidImportMapping, "error messages should be equal")
+	})
+
+	t.Run("returns 403 status code above the member limit", func(t *testing.T) {
+		var created []domain.Invitation
+		store := newStore(&created)
+		store.GetTenantPlanFn = func(ctx context.Context, tenantID int) (domain.Plan, error) {
+			return domain.Plan{Code: domain.PlanStarter, MaxMembers: ptr(100)}, nil
+		}
+		store.CountMembersFn = func(ctx context.Context, tenantID int) (int, error) {
+			return 99, nil
+		}
+
+		req := newImportUpload(t, "/api/tenants/1/users/import", "email\nann@gym.com\nbob@gym.com\n", "")
+		res := newImportRequest(store, NewQuotas(store, store, store), discardMailer(), withDefaultPrincipal(req))
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Contains(t, res.Body.String(), ErrMsgMemberQuotaExceeded, "error messages should be equal")
+		assert.Empty(t, created, "no row should be imported")
+	})
+
+	t.Run("only counts member roles against the member limit", func(t *testing.T) {
+		var created []domain.Invitation
+		store := newStore(&created)
+		store.GetTenantPlanFn = func(ctx context.Context, tenantID int) (domain.Plan, error) {
+			return domain.Plan{Code: domain.PlanStarter, MaxMembers: ptr(100)}, nil
+		}
+		store.CountMembersFn = func(ctx context.Context, tenantID int) (int, error) {
+			return 99, nil
+		}
+		req := newImportUpload(t, "/api/tenants/1/users/import", "email,role\nann@gym.com,member\nbob@gym.com,trainer\n", "")
+		res := newImportRequest(store, NewQuotas(store, store, store), discardMailer(), withDefaultPrincipal(req))
+
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.Len(t, created, 2, "every row should be imported")
+	})
+
+	t.Run("rejects rows with roles the importer cannot give", func(t *testing.T) {
+		var created []domain.Invitation
+		store := newStore(&created)
+		frontDesk := testPrincipal(7, "front-desk")
+		frontDesk.Permissions = []string{domain.PermUsersRead, domain.PermUsersWrite, domain.PermClassesRead}
+
+		req := newImportUpload(t, "/api/tenants/1/users/import", "email,role\nann@gym.com,member\nbob@gym.com,admin\n", "")
+		res := newImportRequest(store, unlimitedQuotas(), discardMailer(), asPrincipal(req, frontDesk))
+
+		var got Response[ImportResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+		assert.Equal(t, []ImportRowError{{Row: 3, Field: "role", Message: ErrMsgRoleNotAssignable}}, got.Data.Errors, "row errors should be equal")
+		assert.Empty(t, created, "no row should be imported")
+	})
+
+	t.Run("returns 403 status code for members", func(t *testing.T) {
+		var created []domain.Invitation
+		store := newStore(&created)
+
+		req := newImportUpload(t, "/api/tenants/1/users/import", "email\nann@gym.com\n", "")
+		res := newImportRequest(store, unlimitedQuotas(), discardMailer(), asPrincipal(req, testPrincipal(7, domain.RoleMember)))
+
+		assertPermissionDenied(t, res)
+	})
+}
+
+func newImportUpload(t *testing.T, target string, file string, mapping string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	part, err := form.CreateFormFile("file", "members.csv")
+	assert.NoError(t, err)
+	part.Write([]byte(file))
+	if mapping != "" {
+		form.WriteField("mapping", mapping)
+	}
+	form.Close()
+
+	req := httptest.NewRequest("POST", target, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func newImportRequest(store *mock.Store, quotas *Quotas, mailer *mock.Mailer, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewImportHandler(slog.Default(), store, quotas, mailer, "https://app.gymulty.test")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
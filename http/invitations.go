@@ -0,0 +1,215 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrInvalidInvitation  = errors.New("invitation: invalid invitation")
+	ErrUserExists         = errors.New("invitation: user already exists")
+	ErrInvitationNotFound = errors.New("invitation: invalid or expired invitation token")
+)
+
+// InvitationHandler lets admins invite people to their tenant. Invitations
+// are accepted through the AuthHandler as the invitee has no login yet.
+type InvitationHandler struct {
+	http.Handler
+	store  domain.Store
+	quotas *Quotas
+	mailer domain.Mailer
+	appURL string
+	logger *slog.Logger
+}
+
+func NewInvitationHandler(logger *slog.Logger, store domain.Store, quotas *Quotas, mailer domain.Mailer, appURL string) *InvitationHandler {
+	router := http.NewServeMux()
+
+	handler := &InvitationHandler{
+		Handler: middleware.StripSlashes(router),
+		store:   store,
+		quotas:  quotas,
+		mailer:  mailer,
+		appURL:  appURL,
+		logger:  logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (h *InvitationHandler) registerRoutes(router *http.ServeMux) {
+	readInvitation := Allow(domain.PermUsersRead, domain.PermUsersWrite)
+	writeInvitation := Allow(domain.PermUsersWrite)
+
+	router.Handle("POST /api/tenants/{tenantID}/invitations", authorize(h.logger, writeInvitation, verifiedTenant(h.logger, h.createInvitation)))
+	router.Handle("GET /api/tenants/{tenantID}/invitations", authorize(h.logger, readInvitation, h.getAllInvitations))
+	router.Handle("POST /api/tenants/{tenantID}/invitations/{invitationID}/resend", authorize(h.logger, writeInvitation, h.resendInvitation))
+	router.Handle("DELETE /api/tenants/{tenantID}/invitations/{invitationID}", authorize(h.logger, writeInvitation, h.revokeInvitation))
+}
+
+func (h *InvitationHandler) createInvitation(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.InvitationRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+	body.Email = normalizeEmail(body.Email)
+
+	if body.Email == "" || body.Role == "" {
+		return e.withContext(ErrInvalidInvitation, ErrMsgInvalidInvitation, ErrStatusBadRequest)
+	}
+	if appErr := assignableRole(r.Context(), h.store, e, p, tenantID, body.Role); appErr != nil {
+		return appErr
+	}
+
+	_, err = h.store.GetUserByEmail(r.Context(), tenantID, body.Email)
+	if err == nil {
+		return e.withContext(ErrUserExists, ErrMsgUserExists, ErrStatusConflict)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	token, tokenHash, err := NewOpaqueToken()
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	invitation := domain.Invitation{
+		Email:     body.Email,
+		Role:      body.Role,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	}
+	if p.UserID != 0 {
+		invitation.InvitedBy = &p.UserID
+	}
+
+	var created domain.Invitation
+	err = h.store.WithTx(r.Context(), func(tx domain.Store) error {
+		// pending invitations count as members, so invitees are sure of their place
+		err := h.quotas.CheckRole(r.Context(), tx, tenantID, body.Role, 1)
+		if err != nil {
+			return err
+		}
+		created, err = tx.CreateInvitation(r.Context(), tenantID, invitation)
+		return err
+	})
+	if err != nil {
+		return quotaError(e, err, ErrMsgMemberQuotaExceeded)
+	}
+	invitation = created
+	h.send(r, invitation, token)
+
+	resourceURI := fmt.Sprintf("%s://%s%s/%d", r.URL.Scheme, r.Host, r.URL.String(), invitation.ID)
+	w.Header().Set("Location", resourceURI)
+
+	w.WriteHeader(http.StatusCreated)
+	res := Response[[]domain.Invitation]{Count: 1, Data: []domain.Invitation{invitation}}
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *InvitationHandler) getAllInvitations(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	invitations, err := h.store.GetAllInvitations(r.Context(), tenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[[]domain.Invitation]{
+		Count: len(invitations),
+		Data:  invitations,
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// resendInvitation emails a new link for a pending invitation. The previous
+// link stops working and the expiry starts over.
+func (h *InvitationHandler) resendInvitation(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	invitationID, err := strconv.Atoi(r.PathValue("invitationID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	token, tokenHash, err := NewOpaqueToken()
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	invitation, err := h.store.RenewInvitation(r.Context(), tenantID, invitationID, tokenHash, time.Now().Add(invitationTTL))
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	h.send(r, invitation, token)
+
+	res := Response[[]domain.Invitation]{Count: 1, Data: []domain.Invitation{invitation}}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *InvitationHandler) revokeInvitation(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	invitationID, err := strconv.Atoi(r.PathValue("invitationID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	err = h.store.RevokeInvitation(r.Context(), tenantID, invitationID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// send emails the invitation link. The invitation stands even if this
+// fails, it can be resent.
+func (h *InvitationHandler) send(r *http.Request, invitation domain.Invitation, token string) {
+	tenant, err := h.store.GetTenantByID(r.Context(), invitation.TenantID)
+	if err == nil {
+		err = h.mailer.Send(r.Context(), invitationEmail(h.appURL, tenant, invitation, token))
+	}
+	if err != nil {
+		h.logger.Error("sending invitation email", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,199 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateInvitation(t *testing.T) {
+	newStore := func() *mock.Store {
+		store := new(mock.Store)
+		store.GetUserByEmailFn = func(ctx context.Context, tenantID int, email string) (domain.User, error) {
+			return domain.User{}, sql.ErrNoRows
+		}
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{ID: tenantID, BusinessName: "SwoleGym"}, nil
+		}
+		return store
+	}
+
+	t.Run("creates the invitation and emails its link", func(t *testing.T) {
+		store := newStore()
+		var stored domain.Invitation
+		store.CreateInvitationFn = func(ctx context.Context, tenantID int, invitation domain.Invitation) (domain.Invitation, error) {
+			invitation.ID = 2
+			invitation.TenantID = tenantID
+			stored = invitation
+			return invitation, nil
+		}
+
+		var sent domain.Email
+		mailer := new(mock.Mailer)
+		mailer.SendFn = func(ctx context.Context, email domain.Email) error {
+			sent = email
+			return nil
+		}
+
+		body := `{"email":"Ann@email.com","role":"trainer"}`
+		req := httptest.NewRequest("POST", "/api/tenants/1/invitations", strings.NewReader(body))
+		res := newInvitationRequestWithMailer(store, mailer, req)
+
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.Equal(t, "ann@email.com", stored.Email, "emails should be normalized")
+		assert.Equal(t, domain.RoleTrainer, stored.Role, "roles should be equal")
+		assert.Equal(t, 1, *stored.InvitedBy, "inviter should be recorded")
+		assert.WithinDuration(t, time.Now().Add(invitationTTL), stored.ExpiresAt, time.Minute, "invitation should expire")
+
+		assert.Equal(t, "ann@email.com", sent.To, "recipients should be equal")
+		assert.Contains(t, sent.Subject, "SwoleGym", "subject should name the gym")
+		start := strings.Index(sent.Body, "https://")
+		link, err := url.Parse(strings.Fields(sent.Body[start:])[0])
+		assert.NoError(t, err, "email should contain a link")
+		assert.Equal(t, stored.TokenHash, HashToken(link.Query().Get("token")), "emailed token should match stored hash")
+		assert.NotContains(t, res.Body.String(), stored.TokenHash, "token hash should not be returned")
+	})
+
+	t.Run("returns 409 status code when the user already exists", func(t *testing.T) {
+		store := newStore()
+		store.GetUserByEmailFn = func(ctx context.Context, tenantID int, email string) (domain.User, error) {
+			return domain.User{ID: 4, Email: email}, nil
+		}
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/invitations", strings.NewReader(`{"email":"ann@email.com","role":"member"}`))
+		res := newInvitationRequest(store, req)
+
+		assert.Equal(t, 409, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 400 status code without role", func(t *testing.T) {
+		store := newStore()
+		req := httptest.NewRequest("POST", "/api/tenants/1/invitations", strings.NewReader(`{"email":"ann@email.com"}`))
+		res := newInvitationRequest(store, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("staff cannot invite admins", func(t *testing.T) {
+		store := newStore()
+		store.GetRoleByNameFn = func(ctx context.Context, tenantID int, name string) (domain.Role, error) {
+			return domain.Role{Name: name, Permissions: domain.DefaultRolePermissions[name]}, nil
+		}
+		var created bool
+		store.CreateInvitationFn = func(ctx context.Context, tenantID int, invitation domain.Invitation) (domain.Invitation, error) {
+			created = true
+			return invitation, nil
+		}
+
+		frontDesk := Principal{UserID: 5, TenantID: 1, Permissions: []string{domain.PermUsersRead, domain.PermUsersWrite, domain.PermClassesRead}}
+		req := httptest.NewRequest("POST", "/api/tenants/1/invitations", strings.NewReader(`{"email":"ann@email.com","role":"admin"}`))
+		res := newInvitationRequest(store, asPrincipal(req, frontDesk))
+
+		assertPermissionDenied(t, res)
+		assert.False(t, created, "invitation should not be created")
+	})
+
+	t.Run("member cannot invite", func(t *testing.T) {
+		store := newStore()
+		req := httptest.NewRequest("POST", "/api/tenants/1/invitations", strings.NewReader(`{"email":"ann@email.com","role":"member"}`))
+		res := newInvitationRequest(store, asPrincipal(req, testPrincipal(7, domain.RoleMember)))
+
+		assertPermissionDenied(t, res)
+	})
+}
+
+func TestResendInvitation(t *testing.T) {
+	t.Run("renews the token and emails the new link", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{ID: tenantID}, nil
+		}
+		var renewed string
+		store.RenewInvitationFn = func(ctx context.Context, tenantID int, invitationID int, tokenHash string, expiresAt time.Time) (domain.Invitation, error) {
+			renewed = tokenHash
+			return domain.Invitation{ID: invitationID, TenantID: tenantID, Email: "ann@email.com", ExpiresAt: expiresAt}, nil
+		}
+
+		var sent domain.Email
+		mailer := new(mock.Mailer)
+		mailer.SendFn = func(ctx context.Context, email domain.Email) error {
+			sent = email
+			return nil
+		}
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/invitations/2/resend", nil)
+		res := newInvitationRequestWithMailer(store, mailer, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.NotEmpty(t, renewed, "token should be renewed")
+		assert.Equal(t, "ann@email.com", sent.To, "recipients should be equal")
+	})
+
+	t.Run("returns 404 status code for invitations no longer pending", func(t *testing.T) {
+		store := new(mock.Store)
+		store.RenewInvitationFn = func(ctx context.Context, tenantID int, invitationID int, tokenHash string, expiresAt time.Time) (domain.Invitation, error) {
+			return domain.Invitation{}, sql.ErrNoRows
+		}
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/invitations/2/resend", nil)
+		res := newInvitationRequest(store, req)
+
+		assert.Equal(t, 404, res.Code, "status codes should be equal")
+	})
+}
+
+func TestRevokeInvitation(t *testing.T) {
+	t.Run("revokes the invitation", func(t *testing.T) {
+		store := new(mock.Store)
+		var revoked int
+		store.RevokeInvitationFn = func(ctx context.Context, tenantID int, invitationID int) error {
+			revoked = invitationID
+			return nil
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/invitations/2", nil)
+		res := newInvitationRequest(store, req)
+
+		assert.Equal(t, 204, res.Code, "status codes should be equal")
+		assert.Equal(t, 2, revoked, "invitation ids should be equal")
+	})
+}
+
+func TestGetAllInvitations(t *testing.T) {
+	t.Run("returns the tenant's invitations", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetAllInvitationsFn = func(ctx context.Context, tenantID int) ([]domain.Invitation, error) {
+			return []domain.Invitation{{ID: 1, TenantID: tenantID}, {ID: 2, TenantID: tenantID}}, nil
+		}
+
+		req := httptest.NewRequest("GET", "/api/tenants/1/invitations", nil)
+		res := newInvitationRequest(store, req)
+
+		var got Response[[]domain.Invitation]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, 2, got.Count, "counts should be equal")
+	})
+}
+
+func newInvitationRequest(store *mock.Store, req *http.Request) *httptest.ResponseRecorder {
+	return newInvitationRequestWithMailer(store, discardMailer(), req)
+}
+
+func newInvitationRequestWithMailer(store *mock.Store, mailer *mock.Mailer, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewInvitationHandler(slog.Default(), store, unlimitedQuotas(), mailer, "https://app.gymulty.test")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, withDefaultPrincipal(req))
+	return res
+}
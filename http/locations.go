@@ -0,0 +1,239 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrInvalidLocationName = errors.New("location: name is required")
+	ErrInvalidCountry      = errors.New("location: unknown country")
+)
+
+// LocationHandler serves the gyms a tenant runs. New locations default to
+// the timezone in the tenant settings.
+type LocationHandler struct {
+	http.Handler
+	store  domain.Store
+	logger *slog.Logger
+}
+
+func NewLocationHandler(logger *slog.Logger, store domain.Store) *LocationHandler {
+	router := http.NewServeMux()
+
+	handler := &LocationHandler{
+		Handler: middleware.StripSlashes(router),
+		store:   store,
+		logger:  logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (h *LocationHandler) registerRoutes(router *http.ServeMux) {
+	manageLocations := Allow(domain.PermLocationsManage)
+
+	router.Handle("POST /api/tenants/{tenantID}/locations", authorize(h.logger, manageLocations, verifiedTenant(h.logger, h.createLocation)))
+	router.Handle("GET /api/tenants/{tenantID}/locations/{locationID}", authorize(h.logger, Authenticated, h.getLocationByID))
+	router.Handle("PATCH /api/tenants/{tenantID}/locations/{locationID}", authorize(h.logger, manageLocations, h.updateLocation))
+	router.Handle("DELETE /api/tenants/{tenantID}/locations/{locationID}", authorize(h.logger, manageLocations, h.deleteLocationByID))
+	router.Handle("GET /api/tenants/{tenantID}/locations", authorize(h.logger, Authenticated, h.getAllLocations))
+}
+
+func (h *LocationHandler) createLocation(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	var location domain.Location
+	json.NewDecoder(r.Body).Decode(&location)
+
+	if location.Timezone == "" {
+		settings, err := h.store.GetTenantSettings(r.Context(), p.TenantID)
+		if err != nil {
+			return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+		}
+		location.Timezone = settings.Timezone
+	}
+
+	update := domain.LocationUpdate{
+		Name:         &location.Name,
+		Country:      &location.Country,
+		Timezone:     &location.Timezone,
+		OpeningHours: &location.OpeningHours,
+	}
+	if appErr := validateLocation(e, &update); appErr != nil {
+		return appErr
+	}
+	location.Name, location.Country = *update.Name, *update.Country
+
+	location, err := h.store.CreateLocation(r.Context(), p.TenantID, location)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	resourceURI := fmt.Sprintf("%s://%s%s/%d", r.URL.Scheme, r.Host, r.URL.String(), location.ID)
+	w.Header().Set("Location", resourceURI)
+
+	res := Response[domain.Location]{Count: 1, Data: location}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *LocationHandler) getLocationByID(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	locationID, err := strconv.Atoi(r.PathValue("locationID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	location, err := h.store.GetLocationByID(r.Context(), p.TenantID, locationID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[domain.Location]{Count: 1, Data: location}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *LocationHandler) updateLocation(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	locationID, err := strconv.Atoi(r.PathValue("locationID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var update domain.LocationUpdate
+	json.NewDecoder(r.Body).Decode(&update)
+
+	if appErr := validateLocation(e, &update); appErr != nil {
+		return appErr
+	}
+
+	location, err := h.store.UpdateLocation(r.Context(), p.TenantID, locationID, update)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[domain.Location]{Count: 1, Data: location}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// deleteLocationByID removes a location. Classes and users stay, they are
+// only no longer associated with it.
+func (h *LocationHandler) deleteLocationByID(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	locationID, err := strconv.Atoi(r.PathValue("locationID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	err = h.store.DeleteLocationByID(r.Context(), p.TenantID, locationID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *LocationHandler) getAllLocations(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	locations, err := h.store.GetAllLocations(r.Context(), p.TenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	writeLocations(w, locations)
+	return nil
+}
+
+// validateLocation checks the fields being set and brings them into their
+// canonical form, e.g. country "pt" becomes "PT".
+func validateLocation(e *appError, update *domain.LocationUpdate) *appError {
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return e.withContext(ErrInvalidLocationName, ErrMsgInvalidLocationName, ErrStatusBadRequest)
+		}
+		update.Name = &name
+	}
+
+	if update.Country != nil && *update.Country != "" {
+		region, err := language.ParseRegion(*update.Country)
+		if err != nil || !region.IsCountry() {
+			return e.withContext(ErrInvalidCountry, ErrMsgInvalidCountry, ErrStatusBadRequest)
+		}
+		country := region.String()
+		update.Country = &country
+	}
+
+	if update.Timezone != nil {
+		if _, err := loadTimezone(*update.Timezone); err != nil {
+			return e.withContext(err, ErrMsgInvalidTimezone, ErrStatusBadRequest)
+		}
+	}
+
+	if update.OpeningHours != nil {
+		for i, hours := range *update.OpeningHours {
+			hours.Day = strings.ToLower(hours.Day)
+			if !validOpeningHours(hours) {
+				return e.withContext(ErrInvalidOpeningHours, ErrMsgInvalidOpeningHours, ErrStatusBadRequest)
+			}
+			(*update.OpeningHours)[i] = hours
+		}
+	}
+	return nil
+}
+
+// locationFilter returns the location_id query parameter lists are
+// filtered by, or 0 when it is missing.
+func locationFilter(r *http.Request, e *appError) (int, *appError) {
+	param := r.URL.Query().Get("location_id")
+	if param == "" {
+		return 0, nil
+	}
+	locationID, err := strconv.Atoi(param)
+	if err != nil || locationID <= 0 {
+		return 0, e.withContext(errors.New("location: invalid location_id"), ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+	return locationID, nil
+}
+
+// locationsError reports location ids that are not the tenant's as a bad request.
+func locationsError(e *appError, err error) *appError {
+	if errors.Is(err, domain.ErrUnknownLocation) {
+		return e.withContext(err, ErrMsgUnknownLocation, ErrStatusBadRequest)
+	}
+	return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+}
+
+func writeLocations(w http.ResponseWriter, locations []domain.Location) {
+	res := Response[[]domain.Location]{
+		Count: len(locations),
+		Data:  locations,
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateLocation(t *testing.T) {
+	newCreateStore := func(created *domain.Location) *mock.Store {
+		store := new(mock.Store)
+		store.GetTenantSettingsFn = func(ctx context.Context, tenantID int) (domain.TenantSettings, error) {
+			return domain.TenantSettings{TenantID: tenantID, Timezone: "Europe/Lisbon"}, nil
+		}
+		store.CreateLocationFn = func(ctx context.Context, tenantID int, location domain.Location) (domain.Location, error) {
+			*created = location
+			location.ID = 2
+			location.TenantID = tenantID
+			return location, nil
+		}
+		return store
+	}
+
+	t.Run("defaults to the tenant timezone", func(t *testing.T) {
+		var created domain.Location
+		store := newCreateStore(&created)
+
+		body := `{"name": " Downtown ", "country": "pt", "opening_hours": [{"day": "Monday", "opens": "06:00", "closes": "22:00"}]}`
+		req := httptest.NewRequest("POST", "/api/tenants/1/locations", strings.NewReader(body))
+		res := newLocationRequest(store, withDefaultPrincipal(req))
+
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.Equal(t, "Downtown", created.Name, "names should be equal")
+		assert.Equal(t, "PT", created.Country, "countries should be equal")
+		assert.Equal(t, "Europe/Lisbon", created.Timezone, "timezones should be equal")
+		assert.Equal(t, "monday", created.OpeningHours[0].Day, "days should be equal")
+	})
+
+	t.Run("returns 400 status code for invalid locations", func(t *testing.T) {
+		bodies := map[string]string{
+			"missing name":          `{"country": "PT"}`,
+			"unknown country":       `{"name": "Downtown", "country": "XX"}`,
+			"unknown timezone":      `{"name": "Downtown", "timezone": "Mars/Olympus"}`,
+			"invalid hours":         `{"name": "Downtown", "opening_hours": [{"day": "monday", "opens": "22:00", "closes": "06:00"}]}`,
+			"continent, no country": `{"name": "Downtown", "country": "150"}`,
+		}
+
+		for name, body := range bodies {
+			var created domain.Location
+			store := newCreateStore(&created)
+
+			req := httptest.NewRequest("POST", "/api/tenants/1/locations", strings.NewReader(body))
+			res := newLocationRequest(store, withDefaultPrincipal(req))
+
+			assert.Equal(t, 400, res.Code, name)
+			assert.Empty(t, created.Name, name)
+		}
+	})
+
+	t.Run("returns 403 status code for members", func(t *testing.T) {
+		var created domain.Location
+		store := newCreateStore(&created)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/locations", strings.NewReader(`{"name": "Downtown"}`))
+		res := newLocationRequest(store, asPrincipal(req, testPrincipal(7, domain.RoleMember)))
+
+		assertPermissionDenied(t, res)
+		assert.Empty(t, created.Name, "no location should be created")
+	})
+}
+
+func TestGetAllLocations(t *testing.T) {
+	store := new(mock.Store)
+	store.GetAllLocationsFn = func(ctx context.Context, tenantID int) ([]domain.Location, error) {
+		return []domain.Location{{ID: 1, TenantID: tenantID, Name: domain.DefaultLocationName}}, nil
+	}
+
+	req := httptest.NewRequest("GET", "/api/tenants/1/locations", nil)
+	res := newLocationRequest(store, asPrincipal(req, testPrincipal(7, domain.RoleMember)))
+
+	var got Response[[]domain.Location]
+	json.NewDecoder(res.Body).Decode(&got)
+	assert.Equal(t, 200, res.Code, "status codes should be equal")
+	assert.Equal(t, 1, got.Count, "counts should be equal")
+}
+
+func TestSetClassLocations(t *testing.T) {
+	t.Run("replaces the locations of a class", func(t *testing.T) {
+		var got []int
+		store := new(mock.Store)
+		store.SetClassLocationsFn = func(ctx context.Context, tenantID int, classID int, locationIDs []int) ([]domain.Location, error) {
+			got = locationIDs
+			return []domain.Location{{ID: 2}, {ID: 3}}, nil
+		}
+
+		req := httptest.NewRequest("PUT", "/api/tenants/1/classes/4/locations", strings.NewReader(`{"location_ids": [2, 3]}`))
+		res := NewClassRequest(req, store)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, []int{2, 3}, got, "location ids should be equal")
+	})
+
+	t.Run("returns 400 status code for locations of another tenant", func(t *testing.T) {
+		store := new(mock.Store)
+		store.SetClassLocationsFn = func(ctx context.Context, tenantID int, classID int, locationIDs []int) ([]domain.Location, error) {
+			return nil, domain.ErrUnknownLocation
+		}
+
+		req := httptest.NewRequest("PUT", "/api/tenants/1/classes/4/locations", strings.NewReader(`{"location_ids": [99]}`))
+		res := NewClassRequest(req, store)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgUnknownLocation, got.Message, "messages should be equal")
+	})
+}
+
+func TestFilterByLocation(t *testing.T) {
+	t.Run("lists the classes of a location", func(t *testing.T) {
+		var filtered int
+		store := new(mock.Store)
+		store.GetClassesByLocationFn = func(ctx context.Context, tenantID int, locationID int) ([]domain.Class, error) {
+			filtered = locationID
+			return []domain.Class{{ID: 1}}, nil
+		}
+
+		req := httptest.NewRequest("GET", "/api/tenants/1/classes?location_id=3", nil)
+		res := NewClassRequest(req, store)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, 3, filtered, "location ids should be equal")
+	})
+
+	t.Run("lists the users of a location", func(t *testing.T) {
+		var filtered int
+		store := new(mock.Store)
+		store.GetUsersByLocationFn = func(ctx context.Context, tenantID int, locationID int) ([]domain.User, error) {
+			filtered = locationID
+			return []domain.User{{ID: 1}}, nil
+		}
+
+		req := httptest.NewRequest("GET", "/api/tenants/1/users?location_id=3", nil)
+		res := newUserRequest(store, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, 3, filtered, "location ids should be equal")
+	})
+
+	t.Run("returns 400 status code for an invalid location id", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/tenants/1/classes?location_id=downtown", nil)
+		res := NewClassRequest(req, new(mock.Store))
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+}
+
+func newLocationRequest(store *mock.Store, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewLocationHandler(slog.Default(), store)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+// Lockout thresholds. Once an account or IP reaches its threshold of
+// failures every further failure doubles the wait, up to lockoutMaxDelay.
+const (
+	accountFailureThreshold = 5
+	accountFailureWindow    = 24 * time.Hour
+	ipFailureThreshold      = 20
+	ipFailureWindow         = 15 * time.Minute
+
+	lockoutBaseDelay = time.Minute
+	lockoutMaxDelay  = time.Hour
+)
+
+// Lockout throttles logins per account and per client IP. Attempts are
+// kept in the LoginAttemptStore so all server instances share the counts.
+type Lockout struct {
+	store domain.LoginAttemptStore
+	now   func() time.Time
+}
+
+func NewLockout(store domain.LoginAttemptStore) *Lockout {
+	return &Lockout{store: store, now: time.Now}
+}
+
+// Check returns how long the caller must wait before email may try to log
+// in again from ip, or zero if the attempt is allowed.
+func (l *Lockout) Check(ctx context.Context, tenantID int, email string, ip string) (time.Duration, error) {
+	now := l.now()
+
+	account, err := l.store.CountAccountFailures(ctx, tenantID, normalizeEmail(email), now.Add(-accountFailureWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	byIP, err := l.store.CountIPFailures(ctx, ip, now.Add(-ipFailureWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	wait := max(
+		lockoutRemaining(account, accountFailureThreshold, now),
+		lockoutRemaining(byIP, ipFailureThreshold, now),
+	)
+	return wait.Round(time.Second), nil
+}
+
+// Record stores the outcome of an attempt by email from ip.
+func (l *Lockout) Record(ctx context.Context, tenantID int, email string, ip string, outcome string) error {
+	return l.store.RecordLoginAttempt(ctx, tenantID, domain.LoginAttempt{
+		TenantID:  tenantID,
+		Email:     normalizeEmail(email),
+		IPAddress: ip,
+		Outcome:   outcome,
+	})
+}
+
+// lockoutRemaining returns how much of the lockout earned by failures is left at now.
+func lockoutRemaining(failures domain.LoginFailures, threshold int, now time.Time) time.Duration {
+	if failures.Count < threshold || failures.LastAt == nil {
+		return 0
+	}
+
+	delay := lockoutBaseDelay
+	for i := threshold; i < failures.Count && delay < lockoutMaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, lockoutMaxDelay)
+
+	return max(failures.LastAt.Add(delay).Sub(now), 0)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
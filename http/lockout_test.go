@@ -0,0 +1,78 @@
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockout(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	newLockout := func(account domain.LoginFailures, byIP domain.LoginFailures) *Lockout {
+		store := new(mock.LoginAttemptStore)
+		store.CountAccountFailuresFn = func(ctx context.Context, tenantID int, email string, since time.Time) (domain.LoginFailures, error) {
+			assert.Equal(t, "pgray@email.com", email, "emails should be normalized")
+			assert.Equal(t, now.Add(-accountFailureWindow), since, "account window should be applied")
+			return account, nil
+		}
+		store.CountIPFailuresFn = func(ctx context.Context, ip string, since time.Time) (domain.LoginFailures, error) {
+			assert.Equal(t, now.Add(-ipFailureWindow), since, "ip window should be applied")
+			return byIP, nil
+		}
+		lockout := NewLockout(store)
+		lockout.now = func() time.Time { return now }
+		return lockout
+	}
+
+	t.Run("allows attempts below the thresholds", func(t *testing.T) {
+		last := now.Add(-time.Second)
+		lockout := newLockout(
+			domain.LoginFailures{Count: accountFailureThreshold - 1, LastAt: &last},
+			domain.LoginFailures{Count: ipFailureThreshold - 1, LastAt: &last},
+		)
+
+		wait, err := lockout.Check(context.Background(), 1, " PGray@email.com", "10.0.0.1")
+		assert.NoError(t, err, "check should succeed")
+		assert.Zero(t, wait, "attempt should be allowed")
+	})
+
+	t.Run("doubles the lockout with every failure past the threshold", func(t *testing.T) {
+		last := now.Add(-30 * time.Second)
+		lockout := newLockout(
+			domain.LoginFailures{Count: accountFailureThreshold + 2, LastAt: &last},
+			domain.LoginFailures{},
+		)
+
+		wait, err := lockout.Check(context.Background(), 1, "pgray@email.com", "10.0.0.1")
+		assert.NoError(t, err, "check should succeed")
+		assert.Equal(t, 4*lockoutBaseDelay-30*time.Second, wait, "lockout should have doubled twice")
+	})
+
+	t.Run("locks out an ip that fails against many accounts", func(t *testing.T) {
+		last := now
+		lockout := newLockout(
+			domain.LoginFailures{},
+			domain.LoginFailures{Count: ipFailureThreshold + 100, LastAt: &last},
+		)
+
+		wait, err := lockout.Check(context.Background(), 1, "pgray@email.com", "10.0.0.1")
+		assert.NoError(t, err, "check should succeed")
+		assert.Equal(t, lockoutMaxDelay, wait, "lockout should be capped")
+	})
+
+	t.Run("expired lockouts allow attempts", func(t *testing.T) {
+		last := now.Add(-2 * lockoutBaseDelay)
+		lockout := newLockout(
+			domain.LoginFailures{Count: accountFailureThreshold, LastAt: &last},
+			domain.LoginFailures{},
+		)
+
+		wait, _ := lockout.Check(context.Background(), 1, "pgray@email.com", "10.0.0.1")
+		assert.Zero(t, wait, "attempt should be allowed")
+	})
+}
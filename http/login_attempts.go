@@ -0,0 +1,81 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+const (
+	defaultLoginAttemptsLimit = 100
+	maxLoginAttemptsLimit     = 1000
+)
+
+var ErrInvalidLimit = errors.New("login attempts: invalid limit")
+
+// LoginAttemptHandler exposes the login attempts of a tenant so admins can
+// spot attacks on their accounts.
+type LoginAttemptHandler struct {
+	http.Handler
+	store  domain.LoginAttemptStore
+	logger *slog.Logger
+}
+
+func NewLoginAttemptHandler(logger *slog.Logger, store domain.LoginAttemptStore) *LoginAttemptHandler {
+	router := http.NewServeMux()
+
+	handler := &LoginAttemptHandler{
+		Handler: middleware.StripSlashes(router),
+		store:   store,
+		logger:  logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (h *LoginAttemptHandler) registerRoutes(router *http.ServeMux) {
+	router.Handle("GET /api/tenants/{tenantID}/login-attempts", authorize(h.logger, Allow(domain.PermSecurityRead), h.getLoginAttempts))
+}
+
+// getLoginAttempts lists the most recent attempts first, optionally
+// filtered by ?email= and ?outcome= and capped by ?limit=.
+func (h *LoginAttemptHandler) getLoginAttempts(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	query := r.URL.Query()
+	filter := domain.LoginAttemptFilter{
+		Email:   normalizeEmail(query.Get("email")),
+		Outcome: query.Get("outcome"),
+		Limit:   defaultLoginAttemptsLimit,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 {
+			return e.withContext(ErrInvalidLimit, ErrMsgInvalidLimit, ErrStatusBadRequest)
+		}
+		filter.Limit = min(filter.Limit, maxLoginAttemptsLimit)
+	}
+
+	attempts, err := h.store.GetLoginAttempts(r.Context(), tenantID, filter)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[[]domain.LoginAttempt]{
+		Count: len(attempts),
+		Data:  attempts,
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLoginAttempts(t *testing.T) {
+	t.Run("returns attempts matching the filter", func(t *testing.T) {
+		store := new(mock.LoginAttemptStore)
+		store.GetLoginAttemptsFn = func(ctx context.Context, tenantID int, filter domain.LoginAttemptFilter) ([]domain.LoginAttempt, error) {
+			want := domain.LoginAttemptFilter{Email: "pgray@email.com", Outcome: domain.LoginFailed, Limit: 10}
+			assert.Equal(t, want, filter, "filters should be equal")
+			return []domain.LoginAttempt{{ID: 1, TenantID: tenantID, Email: filter.Email, Outcome: filter.Outcome}}, nil
+		}
+
+		req := httptest.NewRequest("GET", "/api/tenants/1/login-attempts?email=PGray@email.com&outcome=failed&limit=10", nil)
+		res := newLoginAttemptRequest(store, req)
+
+		var got Response[[]domain.LoginAttempt]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, 1, got.Count, "counts should be equal")
+	})
+
+	t.Run("returns 400 status code for an invalid limit", func(t *testing.T) {
+		store := new(mock.LoginAttemptStore)
+		req := httptest.NewRequest("GET", "/api/tenants/1/login-attempts?limit=-1", nil)
+		res := newLoginAttemptRequest(store, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("trainer cannot list login attempts", func(t *testing.T) {
+		store := new(mock.LoginAttemptStore)
+		req := httptest.NewRequest("GET", "/api/tenants/1/login-attempts", nil)
+		res := newLoginAttemptRequest(store, asPrincipal(req, testPrincipal(3, domain.RoleTrainer)))
+
+		assertPermissionDenied(t, res)
+	})
+}
+
+func newLoginAttemptRequest(store domain.LoginAttemptStore, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewLoginAttemptHandler(slog.Default(), store)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, withDefaultPrincipal(req))
+	return res
+}
@@ -0,0 +1,400 @@
+package http
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+const (
+	defaultPlatformTenantsLimit = 50
+	maxPlatformTenantsLimit     = 500
+)
+
+var (
+	ErrInvalidPlatformToken = errors.New("platform: invalid operator token")
+	ErrInvalidOffset        = errors.New("platform: invalid offset")
+	ErrInvalidStatusFilter  = errors.New("platform: invalid status filter")
+	ErrInvalidOperator      = errors.New("platform: email and name are required")
+)
+
+// PlatformAuthenticate guards the routes used by the operators of gymulty,
+// which act across tenants and therefore don't accept tenant credentials.
+// Callers present either the access token of a platform operator or the
+// static bootstrap token, which rejects every request while it is empty.
+func PlatformAuthenticate(tokens *TokenManager, token string) middleware.Middleware {
+	return func(logger *slog.Logger, next http.Handler) http.Handler {
+		return errorHandler(func(w http.ResponseWriter, r *http.Request) *appError {
+			e := &appError{Logger: logger}
+
+			got, ok := bearerToken(r)
+			if !ok || !validPlatformToken(tokens, token, got) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				return e.withContext(ErrInvalidPlatformToken, ErrMsgUnauthorized, ErrStatusUnauthorized)
+			}
+
+			next.ServeHTTP(w, r)
+			return nil
+		})
+	}
+}
+
+func validPlatformToken(tokens *TokenManager, bootstrap string, got string) bool {
+	if bootstrap != "" && subtle.ConstantTimeCompare([]byte(got), []byte(bootstrap)) == 1 {
+		return true
+	}
+	claims, err := tokens.VerifyFor(purposePlatform, got)
+	return err == nil && claims.Role == domain.RolePlatformOperator
+}
+
+// PlatformHandler lets the platform operator manage any tenant.
+type PlatformHandler struct {
+	http.Handler
+	store    domain.Store
+	tokens   *TokenManager
+	resolver *TenantResolver
+	closer   *TenantCloser
+	logger   *slog.Logger
+}
+
+func NewPlatformHandler(logger *slog.Logger, store domain.Store, tokens *TokenManager, resolver *TenantResolver, closer *TenantCloser) *PlatformHandler {
+	router := http.NewServeMux()
+
+	handler := &PlatformHandler{
+		Handler:  middleware.StripSlashes(router),
+		store:    store,
+		tokens:   tokens,
+		resolver: resolver,
+		closer:   closer,
+		logger:   logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+// registerRoutes registers the platform routes. All but login must be
+// mounted behind PlatformAuthenticate.
+func (h *PlatformHandler) registerRoutes(router *http.ServeMux) {
+	router.Handle("POST /api/platform/auth/login", errorHandler(h.login))
+	router.Handle("POST /api/platform/operators", errorHandler(h.createOperator))
+	router.Handle("GET /api/platform/tenants", errorHandler(h.getTenants))
+	router.Handle("GET /api/platform/tenants/{tenantID}", errorHandler(h.getTenant))
+	router.Handle("DELETE /api/platform/tenants/{tenantID}", errorHandler(h.deleteTenant))
+	router.Handle("POST /api/platform/tenants/{tenantID}/restore", errorHandler(h.restoreTenant))
+	router.Handle("GET /api/platform/tenants/{tenantID}/deletion-certificate", errorHandler(h.getDeletionCertificate))
+	router.Handle("PUT /api/platform/tenants/{tenantID}/plan", errorHandler(h.setTenantPlan))
+	router.Handle("GET /api/platform/plans", errorHandler(h.getPlans))
+	router.Handle("POST /api/platform/tenants/{tenantID}/suspend", errorHandler(h.suspendTenant))
+	router.Handle("POST /api/platform/tenants/{tenantID}/reactivate", errorHandler(h.reactivateTenant))
+}
+
+// suspendTenant blocks every request of the tenant, e.g. for an unpaid
+// bill or abuse, until the operator reactivates it.
+func (h *PlatformHandler) suspendTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.TenantStatusRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+	if body.Reason == "" {
+		return e.withContext(ErrInvalidTenantStatus, ErrMsgMissingStatusReason, ErrStatusBadRequest)
+	}
+
+	tenant, appErr := changeTenantStatus(r.Context(), h.store, e, tenantID, domain.TenantSuspended, body.Reason, domain.TenantActive, domain.TenantInactive)
+	if appErr != nil {
+		return appErr
+	}
+	writeTenantStatus(w, tenant)
+	return nil
+}
+
+// reactivateTenant lifts a suspension or deactivation.
+func (h *PlatformHandler) reactivateTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.TenantStatusRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	tenant, appErr := changeTenantStatus(r.Context(), h.store, e, tenantID, domain.TenantActive, body.Reason, domain.TenantInactive, domain.TenantSuspended)
+	if appErr != nil {
+		return appErr
+	}
+	writeTenantStatus(w, tenant)
+	return nil
+}
+
+// login exchanges the credentials of a platform operator for an access token.
+// Operators have no refresh tokens and log in again once it expires.
+func (h *PlatformHandler) login(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	var body domain.LoginRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+	if body.Email == "" || body.Password == "" {
+		return e.withContext(ErrInvalidCredentials, ErrMsgMissingCredentials, ErrStatusBadRequest)
+	}
+
+	operator, err := h.store.GetPlatformOperatorByEmail(r.Context(), normalizeEmail(body.Email))
+	if errors.Is(err, sql.ErrNoRows) {
+		CheckPassword(dummyPasswordHash, body.Password)
+		return e.withContext(ErrInvalidCredentials, ErrMsgInvalidCredentials, ErrStatusUnauthorized)
+	}
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	if !CheckPassword(operator.Password, body.Password) {
+		return e.withContext(ErrInvalidCredentials, ErrMsgInvalidCredentials, ErrStatusUnauthorized)
+	}
+
+	token, _, err := h.tokens.IssuePlatform(operator)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	err = h.store.RecordPlatformLogin(r.Context(), operator.ID)
+	if err != nil {
+		h.logger.Error("recording platform login", slog.String("error", err.Error()))
+	}
+
+	res := Response[LoginResponse]{
+		Count: 1,
+		Data: LoginResponse{
+			AccessToken: token,
+			TokenType:   "Bearer",
+			ExpiresIn:   int(h.tokens.TTL().Seconds()),
+		},
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// createOperator adds a platform operator. The first one is created with
+// the bootstrap token.
+func (h *PlatformHandler) createOperator(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	var body domain.PlatformOperatorRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	body.Email = normalizeEmail(body.Email)
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Email == "" || body.Name == "" {
+		return e.withContext(ErrInvalidOperator, ErrMsgInvalidOperator, ErrStatusBadRequest)
+	}
+	if len(body.Password) < minPasswordLength {
+		return e.withContext(ErrWeakPassword, ErrMsgWeakPassword, ErrStatusBadRequest)
+	}
+
+	hash, err := HashPassword(body.Password)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	operator, err := h.store.CreatePlatformOperator(r.Context(), domain.PlatformOperator{
+		Email:    body.Email,
+		Name:     body.Name,
+		Password: hash,
+	})
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[domain.PlatformOperator]{Count: 1, Data: operator}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// getTenants lists tenants with their usage, optionally searched by
+// ?search= in the business name and subdomain, filtered by ?status= and
+// paged with ?limit= and ?offset=.
+func (h *PlatformHandler) getTenants(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	query := r.URL.Query()
+	filter := domain.TenantFilter{
+		Search: strings.TrimSpace(query.Get("search")),
+		Status: query.Get("status"),
+		Limit:  defaultPlatformTenantsLimit,
+	}
+
+	switch filter.Status {
+	case "", domain.TenantActive, domain.TenantInactive, domain.TenantSuspended, domain.TenantPendingDeletion:
+	default:
+		return e.withContext(ErrInvalidStatusFilter, ErrMsgInvalidStatusFilter, ErrStatusBadRequest)
+	}
+
+	var err error
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 {
+			return e.withContext(ErrInvalidLimit, ErrMsgInvalidLimit, ErrStatusBadRequest)
+		}
+		filter.Limit = min(filter.Limit, maxPlatformTenantsLimit)
+	}
+	if offset := query.Get("offset"); offset != "" {
+		filter.Offset, err = strconv.Atoi(offset)
+		if err != nil || filter.Offset < 0 {
+			return e.withContext(ErrInvalidOffset, ErrMsgInvalidOffset, ErrStatusBadRequest)
+		}
+	}
+
+	tenants, err := h.store.GetTenantsUsage(r.Context(), filter)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[[]domain.TenantUsage]{
+		Count: len(tenants),
+		Data:  tenants,
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *PlatformHandler) getTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	tenant, err := h.store.GetTenantUsage(r.Context(), tenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[domain.TenantUsage]{Count: 1, Data: tenant}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// deleteTenant schedules the deletion of a tenant, e.g. one that stopped
+// paying. Until it is purged, the tenant is blocked like a suspended one and
+// only the operator can restore it.
+func (h *PlatformHandler) deleteTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.TenantStatusRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	tenant, err := h.closer.Schedule(r.Context(), tenantID, domain.DeletionByPlatform, body.Reason)
+	if errors.Is(err, ErrInvalidTenantStatus) {
+		return e.withContext(err, ErrMsgInvalidTenantStatus, ErrStatusConflict)
+	}
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	writeTenantStatus(w, tenant)
+	return nil
+}
+
+// restoreTenant cancels the deletion of a tenant, whoever scheduled it, and
+// returns it to its status before, so a suspended tenant stays suspended.
+func (h *PlatformHandler) restoreTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.TenantStatusRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	tenant, err := h.store.RestoreTenant(r.Context(), tenantID, body.Reason, domain.DeletionByTenant, domain.DeletionByPlatform)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tenantStatusConflict(r.Context(), h.store, e, tenantID)
+	}
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	writeTenantStatus(w, tenant)
+	return nil
+}
+
+// getDeletionCertificate shows the proof that a tenant was purged.
+func (h *PlatformHandler) getDeletionCertificate(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	certificate, err := h.store.GetDeletionCertificate(r.Context(), tenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[domain.DeletionCertificate]{Count: 1, Data: certificate}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *PlatformHandler) getPlans(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	plans, err := h.store.GetAllPlans(r.Context())
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[[]domain.Plan]{
+		Count: len(plans),
+		Data:  plans,
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// setTenantPlan moves a tenant to another plan. Going below current usage
+// is allowed, the tenant just cannot create more until it is under the limit.
+func (h *PlatformHandler) setTenantPlan(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.PlanRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	tenant, err := h.store.SetTenantPlan(r.Context(), tenantID, body.Plan)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	writeTenant(w, tenant)
+	return nil
+}
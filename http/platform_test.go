@@ -0,0 +1,324 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPlatformToken = "platform-secret"
+
+func TestPlatformAuthenticate(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		configured string
+		presented  string
+		want       int
+	}{
+		{"accepts the operator token", testPlatformToken, testPlatformToken, 200},
+		{"rejects another token", testPlatformToken, "tenant-token", 401},
+		{"rejects requests without token", testPlatformToken, "", 401},
+		{"rejects everything when no token is configured", "", "", 401},
+	}
+
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/platform/tenants/1/suspend", nil)
+			if tt.presented != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.presented)
+			}
+			res := httptest.NewRecorder()
+			PlatformAuthenticate(tokens, tt.configured)(slog.Default(), next).ServeHTTP(res, req)
+
+			assert.Equal(t, tt.want, res.Code, "status codes should be equal")
+		})
+	}
+
+	t.Run("accepts the access token of an operator", func(t *testing.T) {
+		token, _, _ := tokens.IssuePlatform(domain.PlatformOperator{ID: 1, Role: domain.RolePlatformOperator})
+
+		req := httptest.NewRequest("GET", "/api/platform/tenants", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+		PlatformAuthenticate(tokens, "")(slog.Default(), next).ServeHTTP(res, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+	})
+
+	t.Run("rejects the access token of a tenant admin", func(t *testing.T) {
+		token, _, _ := tokens.Issue(domain.User{ID: 1, TenantID: 1, Role: domain.RoleAdmin}, 1)
+
+		req := httptest.NewRequest("GET", "/api/platform/tenants", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+		PlatformAuthenticate(tokens, testPlatformToken)(slog.Default(), next).ServeHTTP(res, req)
+
+		assert.Equal(t, 401, res.Code, "status codes should be equal")
+	})
+
+	t.Run("operator tokens are not accepted by tenant routes", func(t *testing.T) {
+		token, _, _ := tokens.IssuePlatform(domain.PlatformOperator{ID: 1, Role: domain.RolePlatformOperator})
+
+		_, err := tokens.Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestPlatformLogin(t *testing.T) {
+	hash, _ := HashPassword("ReallySecret1001")
+	store := new(mock.Store)
+	store.GetPlatformOperatorByEmailFn = func(ctx context.Context, email string) (domain.PlatformOperator, error) {
+		if email != "ops@gymulty.app" {
+			return domain.PlatformOperator{}, sql.ErrNoRows
+		}
+		return domain.PlatformOperator{ID: 3, Email: email, Password: hash, Role: domain.RolePlatformOperator}, nil
+	}
+	store.RecordPlatformLoginFn = func(ctx context.Context, operatorID int) error {
+		return nil
+	}
+
+	t.Run("issues a platform token", func(t *testing.T) {
+		body := `{"email": "Ops@gymulty.app", "password": "ReallySecret1001"}`
+		req := httptest.NewRequest("POST", "/api/platform/auth/login", strings.NewReader(body))
+		res := newPlatformRequest(store, req)
+
+		var got Response[LoginResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Empty(t, got.Data.RefreshToken, "operators should not get a refresh token")
+
+		claims, err := testPlatformTokens.VerifyFor(purposePlatform, got.Data.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, claims.UserID, "operator ids should be equal")
+	})
+
+	t.Run("returns 401 status code for invalid credentials", func(t *testing.T) {
+		bodies := []string{
+			`{"email": "ops@gymulty.app", "password": "wrong-password"}`,
+			`{"email": "someone@gymulty.app", "password": "ReallySecret1001"}`,
+		}
+		for _, body := range bodies {
+			req := httptest.NewRequest("POST", "/api/platform/auth/login", strings.NewReader(body))
+			res := newPlatformRequest(store, req)
+
+			assert.Equal(t, 401, res.Code, body)
+		}
+	})
+}
+
+func TestPlatformTenants(t *testing.T) {
+	t.Run("searches tenants", func(t *testing.T) {
+		var got domain.TenantFilter
+		store := new(mock.Store)
+		store.GetTenantsUsageFn = func(ctx context.Context, filter domain.TenantFilter) ([]domain.TenantUsage, error) {
+			got = filter
+			return []domain.TenantUsage{{Tenant: domain.Tenant{ID: 1}, UserCount: 12, ClassCount: 4}}, nil
+		}
+
+		req := httptest.NewRequest("GET", "/api/platform/tenants?search=iron&status=active&limit=10000&offset=20", nil)
+		res := newPlatformRequest(store, req)
+
+		var body Response[[]domain.TenantUsage]
+		json.NewDecoder(res.Body).Decode(&body)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, domain.TenantFilter{Search: "iron", Status: domain.TenantActive, Limit: maxPlatformTenantsLimit, Offset: 20}, got, "filters should be equal")
+		assert.Equal(t, 12, body.Data[0].UserCount, "user counts should be equal")
+	})
+
+	t.Run("returns 400 status code for invalid filters", func(t *testing.T) {
+		for _, query := range []string{"status=deleted", "limit=0", "offset=-1"} {
+			req := httptest.NewRequest("GET", "/api/platform/tenants?"+query, nil)
+			res := newPlatformRequest(new(mock.Store), req)
+
+			assert.Equal(t, 400, res.Code, query)
+		}
+	})
+
+	t.Run("schedules the deletion of a tenant", func(t *testing.T) {
+		var requester string
+		store := new(mock.Store)
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{ID: tenantID, Subdomain: "ironworks", Status: domain.TenantSuspended}, nil
+		}
+		store.ScheduleTenantDeletionFn = func(ctx context.Context, tenantID int, requestedBy string, reason string, scheduledFor time.Time) (domain.Tenant, error) {
+			requester = requestedBy
+			return domain.Tenant{ID: tenantID, Status: domain.TenantPendingDeletion, DeletionScheduledFor: &scheduledFor}, nil
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/platform/tenants/4", strings.NewReader(`{"reason":"unpaid for months"}`))
+		res := newPlatformRequest(store, req)
+
+		var got Response[TenantStatusResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, domain.DeletionByPlatform, requester, "platform should request the deletion")
+		assert.Equal(t, domain.TenantPendingDeletion, got.Data.Status, "statuses should be equal")
+		assert.NotNil(t, got.Data.DeletionScheduledFor, "deletion should be scheduled")
+	})
+
+	t.Run("returns 409 status code when the deletion is already scheduled", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{ID: tenantID, Status: domain.TenantPendingDeletion}, nil
+		}
+		store.ScheduleTenantDeletionFn = func(ctx context.Context, tenantID int, requestedBy string, reason string, scheduledFor time.Time) (domain.Tenant, error) {
+			return domain.Tenant{}, sql.ErrNoRows
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/platform/tenants/4", nil)
+		res := newPlatformRequest(store, req)
+
+		assert.Equal(t, 409, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 404 status code when restoring an unknown tenant", func(t *testing.T) {
+		store := new(mock.Store)
+		store.RestoreTenantFn = func(ctx context.Context, tenantID int, reason string, requestedBy ...string) (domain.Tenant, error) {
+			return domain.Tenant{}, sql.ErrNoRows
+		}
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{}, sql.ErrNoRows
+		}
+
+		req := httptest.NewRequest("POST", "/api/platform/tenants/4/restore", nil)
+		res := newPlatformRequest(store, req)
+
+		assert.Equal(t, 404, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns the deletion certificate of a purged tenant", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetDeletionCertificateFn = func(ctx context.Context, tenantID int) (domain.DeletionCertificate, error) {
+			return domain.DeletionCertificate{ID: 2, TenantID: tenantID, DeletedRecords: map[string]int{"users": 40}}, nil
+		}
+
+		req := httptest.NewRequest("GET", "/api/platform/tenants/4/deletion-certificate", nil)
+		res := newPlatformRequest(store, req)
+
+		var got Response[domain.DeletionCertificate]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, 40, got.Data.DeletedRecords["users"], "deleted records should be equal")
+	})
+
+	t.Run("moves a tenant to another plan", func(t *testing.T) {
+		var plan string
+		store := new(mock.Store)
+		store.SetTenantPlanFn = func(ctx context.Context, tenantID int, code string) (domain.Tenant, error) {
+			plan = code
+			return domain.Tenant{ID: tenantID, Plan: code}, nil
+		}
+
+		req := httptest.NewRequest("PUT", "/api/platform/tenants/4/plan", strings.NewReader(`{"plan": "growth"}`))
+		res := newPlatformRequest(store, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, domain.PlanGrowth, plan, "plans should be equal")
+	})
+}
+
+func TestPlatformTenantStatus(t *testing.T) {
+	newStatusStore := func(status string, updated *[2]string) *mock.Store {
+		store := new(mock.Store)
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{ID: tenantID, Status: status}, nil
+		}
+		store.UpdateTenantStatusFn = func(ctx context.Context, tenantID int, to string, reason string, from []string) (domain.Tenant, error) {
+			if !slices.Contains(from, status) {
+				return domain.Tenant{}, sql.ErrNoRows
+			}
+			*updated = [2]string{to, reason}
+			return domain.Tenant{ID: tenantID, Status: to, StatusReason: reason}, nil
+		}
+		return store
+	}
+
+	t.Run("suspends a tenant with a reason", func(t *testing.T) {
+		var updated [2]string
+		store := newStatusStore(domain.TenantActive, &updated)
+
+		req := httptest.NewRequest("POST", "/api/platform/tenants/4/suspend", strings.NewReader(`{"reason":"unpaid invoice"}`))
+		res := newPlatformRequest(store, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, [2]string{domain.TenantSuspended, "unpaid invoice"}, updated, "tenant should be suspended")
+	})
+
+	t.Run("returns 400 status code when suspending without a reason", func(t *testing.T) {
+		var updated [2]string
+		store := newStatusStore(domain.TenantActive, &updated)
+
+		req := httptest.NewRequest("POST", "/api/platform/tenants/4/suspend", nil)
+		res := newPlatformRequest(store, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+		assert.Empty(t, updated[0], "tenant should not be updated")
+	})
+
+	t.Run("lifts a suspension", func(t *testing.T) {
+		var updated [2]string
+		store := newStatusStore(domain.TenantSuspended, &updated)
+
+		req := httptest.NewRequest("POST", "/api/platform/tenants/4/reactivate", nil)
+		res := newPlatformRequest(store, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, domain.TenantActive, updated[0], "tenant should be reactivated")
+	})
+
+	t.Run("restores a tenant pending deletion to its status before", func(t *testing.T) {
+		var requesters []string
+		store := new(mock.Store)
+		store.RestoreTenantFn = func(ctx context.Context, tenantID int, reason string, requestedBy ...string) (domain.Tenant, error) {
+			requesters = requestedBy
+			return domain.Tenant{ID: tenantID, Status: domain.TenantSuspended, StatusReason: reason}, nil
+		}
+
+		req := httptest.NewRequest("POST", "/api/platform/tenants/4/restore", strings.NewReader(`{"reason":"invoice paid"}`))
+		res := newPlatformRequest(store, req)
+
+		var got Response[TenantStatusResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, "invoice paid", got.Data.Reason, "reasons should be equal")
+		assert.ElementsMatch(t, []string{domain.DeletionByTenant, domain.DeletionByPlatform}, requesters, "any deletion should be restored")
+		assert.Equal(t, domain.TenantSuspended, got.Data.Status, "tenant should stay suspended")
+	})
+
+	t.Run("returns 409 status code when suspending a suspended tenant", func(t *testing.T) {
+		var updated [2]string
+		store := newStatusStore(domain.TenantSuspended, &updated)
+
+		req := httptest.NewRequest("POST", "/api/platform/tenants/4/suspend", strings.NewReader(`{"reason":"again"}`))
+		res := newPlatformRequest(store, req)
+
+		assert.Equal(t, 409, res.Code, "status codes should be equal")
+	})
+}
+
+var testPlatformTokens = NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+
+func newPlatformRequest(store *mock.Store, req *http.Request) *httptest.ResponseRecorder {
+	resolver := NewTenantResolver(store, "gymulty.app", time.Minute)
+	handler := NewPlatformHandler(slog.Default(), store, testPlatformTokens, resolver, newTestCloser(store, resolver))
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
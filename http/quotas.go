@@ -0,0 +1,231 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+var ErrQuotaExceeded = errors.New("plan: quota exceeded")
+
+// Quotas enforces the limits of the plan a tenant is on. The checks count
+// usage through tx, which must be the transaction of domain.Store.WithTx that
+// goes on to create the members or classes: its serializable isolation makes
+// one of two concurrent requests taking the last place retry and fail.
+type Quotas struct {
+	plans    domain.PlanStore
+	settings domain.TenantSettingsStore
+	roles    domain.RoleStore
+	now      func() time.Time
+}
+
+func NewQuotas(plans domain.PlanStore, settings domain.TenantSettingsStore, roles domain.RoleStore) *Quotas {
+	return &Quotas{
+		plans:    plans,
+		settings: settings,
+		roles:    roles,
+		now:      time.Now,
+	}
+}
+
+// CheckMembers returns ErrQuotaExceeded if the tenant cannot add n more members.
+// Pending invitations to member roles count as members already.
+func (q *Quotas) CheckMembers(ctx context.Context, tx domain.PlanStore, tenantID int, n int) error {
+	plan, err := q.plans.GetTenantPlan(ctx, tenantID)
+	if err != nil || plan.MaxMembers == nil {
+		return err
+	}
+	return checkMembers(ctx, tx, tenantID, plan, n)
+}
+
+// CheckRole returns ErrQuotaExceeded if the tenant cannot add n more users
+// with the named role. Only member roles count, see domain.IsMemberRole;
+// unknown roles are left for the caller to reject.
+func (q *Quotas) CheckRole(ctx context.Context, tx domain.PlanStore, tenantID int, role string, n int) error {
+	plan, err := q.plans.GetTenantPlan(ctx, tenantID)
+	if err != nil || plan.MaxMembers == nil {
+		return err
+	}
+
+	member, err := q.isMemberRole(ctx, tenantID, role)
+	if err != nil || !member {
+		return err
+	}
+	return checkMembers(ctx, tx, tenantID, plan, n)
+}
+
+// CheckRoleChange returns ErrQuotaExceeded if moving a user from one role to
+// another makes them a member the tenant has no room for.
+func (q *Quotas) CheckRoleChange(ctx context.Context, tx domain.PlanStore, tenantID int, from string, to string) error {
+	if from == to {
+		return nil
+	}
+	plan, err := q.plans.GetTenantPlan(ctx, tenantID)
+	if err != nil || plan.MaxMembers == nil {
+		return err
+	}
+
+	member, err := q.isMemberRole(ctx, tenantID, from)
+	if err != nil || member {
+		return err // members are counted already
+	}
+	member, err = q.isMemberRole(ctx, tenantID, to)
+	if err != nil || !member {
+		return err
+	}
+	return checkMembers(ctx, tx, tenantID, plan, 1)
+}
+
+func (q *Quotas) isMemberRole(ctx context.Context, tenantID int, name string) (bool, error) {
+	role, err := q.roles.GetRoleByName(ctx, tenantID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return domain.IsMemberRole(role.Permissions), nil
+}
+
+func checkMembers(ctx context.Context, tx domain.PlanStore, tenantID int, plan domain.Plan, n int) error {
+	members, err := tx.CountMembers(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if members+n > *plan.MaxMembers {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// CheckClass returns ErrQuotaExceeded if the tenant cannot schedule another
+// class in the week of startsAt.
+func (q *Quotas) CheckClass(ctx context.Context, tx domain.PlanStore, tenantID int, startsAt time.Time) error {
+	plan, err := q.plans.GetTenantPlan(ctx, tenantID)
+	if err != nil || plan.MaxClassesPerWeek == nil {
+		return err
+	}
+
+	from, to, err := q.week(ctx, tenantID, startsAt)
+	if err != nil {
+		return err
+	}
+	classes, err := tx.CountClassesBetween(ctx, tenantID, from, to)
+	if err != nil {
+		return err
+	}
+	if classes >= *plan.MaxClassesPerWeek {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Usage reports the plan of the tenant and how much of it is used this week.
+func (q *Quotas) Usage(ctx context.Context, tenantID int) (UsageResponse, error) {
+	plan, err := q.plans.GetTenantPlan(ctx, tenantID)
+	if err != nil {
+		return UsageResponse{}, err
+	}
+	members, err := q.plans.CountMembers(ctx, tenantID)
+	if err != nil {
+		return UsageResponse{}, err
+	}
+
+	from, to, err := q.week(ctx, tenantID, q.now())
+	if err != nil {
+		return UsageResponse{}, err
+	}
+	classes, err := q.plans.CountClassesBetween(ctx, tenantID, from, to)
+	if err != nil {
+		return UsageResponse{}, err
+	}
+
+	return UsageResponse{
+		Plan:            plan,
+		Members:         QuotaUsage{Used: members, Limit: plan.MaxMembers},
+		ClassesThisWeek: QuotaUsage{Used: classes, Limit: plan.MaxClassesPerWeek},
+		WeekStartsAt:    from,
+		WeekEndsAt:      to,
+	}, nil
+}
+
+// week returns the bounds of the week containing t, as the tenant counts
+// weeks in its own timezone and from its own first day.
+func (q *Quotas) week(ctx context.Context, tenantID int, t time.Time) (time.Time, time.Time, error) {
+	settings, err := q.settings.GetTenantSettings(ctx, tenantID)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	loc, err := loadTimezone(settings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	from := startOfWeek(t.In(loc), settings.WeekStart)
+	return from, from.AddDate(0, 0, 7), nil
+}
+
+// startOfWeek returns midnight of the first day of the week containing t,
+// where weeks start on weekStart, e.g. "monday".
+func startOfWeek(t time.Time, weekStart string) time.Time {
+	first := time.Monday
+	if i := slices.Index(weekdays, weekStart); i >= 0 {
+		first = time.Weekday((i + 1) % 7)
+	}
+	days := (int(t.Weekday()) - int(first) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-days, 0, 0, 0, 0, t.Location())
+}
+
+// quotaError reports an exceeded quota with msg, and anything else as internal.
+func quotaError(e *appError, err error, msg string) *appError {
+	if errors.Is(err, ErrQuotaExceeded) {
+		return e.withContext(err, msg, ErrStatusQuotaExceeded)
+	}
+	return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+}
+
+// UsageHandler shows a tenant how much of its plan it uses.
+type UsageHandler struct {
+	http.Handler
+	quotas *Quotas
+	logger *slog.Logger
+}
+
+func NewUsageHandler(logger *slog.Logger, quotas *Quotas) *UsageHandler {
+	router := http.NewServeMux()
+
+	handler := &UsageHandler{
+		Handler: middleware.StripSlashes(router),
+		quotas:  quotas,
+		logger:  logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (h *UsageHandler) registerRoutes(router *http.ServeMux) {
+	router.Handle("GET /api/tenants/{tenantID}/usage", authorize(h.logger, Authenticated, h.getUsage))
+}
+
+func (h *UsageHandler) getUsage(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	usage, err := h.quotas.Usage(r.Context(), p.TenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[UsageResponse]{Count: 1, Data: usage}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
@@ -0,0 +1,353 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartOfWeek(t *testing.T) {
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	wednesday := time.Date(2026, 3, 4, 9, 30, 0, 0, lisbon)
+
+	tests := []struct {
+		weekStart string
+		want      time.Time
+	}{
+		{"monday", time.Date(2026, 3, 2, 0, 0, 0, 0, lisbon)},
+		{"sunday", time.Date(2026, 3, 1, 0, 0, 0, 0, lisbon)},
+		{"wednesday", time.Date(2026, 3, 4, 0, 0, 0, 0, lisbon)},
+		{"thursday", time.Date(2026, 2, 26, 0, 0, 0, 0, lisbon)},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, startOfWeek(wednesday, tt.weekStart), tt.weekStart)
+	}
+}
+
+func TestMemberQuota(t *testing.T) {
+	newQuotaStore := func(members int, created *bool) *mock.Store {
+		store := new(mock.Store)
+		store.GetTenantPlanFn = func(ctx context.Context, tenantID int) (domain.Plan, error) {
+			return domain.Plan{Code: domain.PlanStarter, MaxMembers: ptr(100)}, nil
+		}
+		store.CountMembersFn = func(ctx context.Context, tenantID int) (int, error) {
+			return members, nil
+		}
+		store.CreateUserFn = func(ctx context.Context, tenantID int, user domain.User) (domain.User, error) {
+			*created = true
+			return user, nil
+		}
+		store.CreatePasswordResetTokenFn = func(ctx context.Context, tenantID int, userID int, tokenHash string, expiresAt time.Time) error {
+			return nil
+		}
+		store.GetRoleByNameFn = quotaRoles
+		return store
+	}
+
+	t.Run("creates members below the limit", func(t *testing.T) {
+		var created bool
+		store := newQuotaStore(99, &created)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/users", strings.NewReader(`{"email": "new@gym.com", "role": "member"}`))
+		res := newQuotaUserRequest(store, req)
+
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.True(t, created, "user should be created")
+	})
+
+	t.Run("returns 403 status code with quota_exceeded at the limit", func(t *testing.T) {
+		var created bool
+		store := newQuotaStore(100, &created)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/users", strings.NewReader(`{"email": "new@gym.com", "role": "member"}`))
+		res := newQuotaUserRequest(store, req)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrStatusQuotaExceeded, got.Code, "codes should be equal")
+		assert.False(t, created, "user should not be created")
+	})
+
+	t.Run("does not count staff against the member limit", func(t *testing.T) {
+		var created bool
+		store := newQuotaStore(100, &created)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/users", strings.NewReader(`{"email": "coach@gym.com", "role": "trainer"}`))
+		res := newQuotaUserRequest(store, req)
+
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.True(t, created, "user should be created")
+	})
+
+	t.Run("counts roles without staff permissions as members", func(t *testing.T) {
+		var created bool
+		store := newQuotaStore(100, &created)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/users", strings.NewReader(`{"email": "guest@gym.com", "role": "guest"}`))
+		res := newQuotaUserRequest(store, req)
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.False(t, created, "user should not be created")
+	})
+
+	t.Run("returns 403 status code when making staff a member at the limit", func(t *testing.T) {
+		var created bool
+		store := newQuotaStore(100, &created)
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return domain.User{ID: userID, TenantID: tenantID, Role: domain.RoleTrainer}, nil
+		}
+		var updated bool
+		store.UpdateUserFn = func(ctx context.Context, tenantID int, userID int, updates domain.UserUpdate) (domain.User, error) {
+			updated = true
+			return domain.User{ID: userID, Role: *updates.Role}, nil
+		}
+
+		req := httptest.NewRequest("PUT", "/api/tenants/1/users/5", strings.NewReader(`{"role": "member"}`))
+		res := newQuotaUserRequest(store, req)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrStatusQuotaExceeded, got.Code, "codes should be equal")
+		assert.False(t, updated, "user should not be updated")
+	})
+
+	t.Run("counts members in the transaction creating the user", func(t *testing.T) {
+		var created bool
+		store := newQuotaStore(0, &created)
+		tx := newQuotaStore(100, &created) // another request took the last place meanwhile
+		store.WithTxFn = func(ctx context.Context, fn func(tx domain.Store) error) error {
+			return fn(tx)
+		}
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/users", strings.NewReader(`{"email": "new@gym.com", "role": "member"}`))
+		res := newQuotaUserRequest(store, req)
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.False(t, created, "user should not be created")
+	})
+}
+
+func TestInvitationMemberQuota(t *testing.T) {
+	newQuotaStore := func(members int) *mock.Store {
+		store := newActiveTenantStore()
+		store.GetTenantPlanFn = func(ctx context.Context, tenantID int) (domain.Plan, error) {
+			return domain.Plan{Code: domain.PlanStarter, MaxMembers: ptr(100)}, nil
+		}
+		store.CountMembersFn = func(ctx context.Context, tenantID int) (int, error) {
+			return members, nil
+		}
+		store.GetRoleByNameFn = quotaRoles
+		store.GetUserByEmailFn = func(ctx context.Context, tenantID int, email string) (domain.User, error) {
+			return domain.User{}, sql.ErrNoRows
+		}
+		store.CreateInvitationFn = func(ctx context.Context, tenantID int, invitation domain.Invitation) (domain.Invitation, error) {
+			return invitation, nil
+		}
+		store.AcceptInvitationFn = func(ctx context.Context, tenantID int, tokenHash string, user domain.User) (domain.User, error) {
+			user.ID = 8
+			user.Role = domain.RoleMember
+			return user, nil
+		}
+		return store
+	}
+
+	t.Run("returns 403 status code when inviting members at the limit", func(t *testing.T) {
+		store := newQuotaStore(100)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/invitations", strings.NewReader(`{"email": "ann@gym.com", "role": "member"}`))
+		res := httptest.NewRecorder()
+		NewInvitationHandler(slog.Default(), store, NewQuotas(store, store, store), discardMailer(), "https://app.gymulty.test").ServeHTTP(res, withDefaultPrincipal(req))
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrStatusQuotaExceeded, got.Code, "codes should be equal")
+	})
+
+	t.Run("accepts invitations counted against the limit", func(t *testing.T) {
+		store := newQuotaStore(100)
+
+		res := newQuotaAcceptRequest(store)
+
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 403 status code when accepting over the limit", func(t *testing.T) {
+		store := newQuotaStore(101)
+		var rolledBack bool
+		store.WithTxFn = func(ctx context.Context, fn func(tx domain.Store) error) error {
+			err := fn(store)
+			rolledBack = err != nil
+			return err
+		}
+
+		res := newQuotaAcceptRequest(store)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgInvitationQuotaExceeded, got.Message, "messages should be equal")
+		assert.True(t, rolledBack, "acceptance should be rolled back")
+	})
+}
+
+func TestClassQuota(t *testing.T) {
+	newQuotaStore := func(from *time.Time, classes int) *mock.Store {
+		store := new(mock.Store)
+		store.GetTenantPlanFn = func(ctx context.Context, tenantID int) (domain.Plan, error) {
+			return domain.Plan{Code: domain.PlanStarter, MaxClassesPerWeek: ptr(20)}, nil
+		}
+		store.GetTenantSettingsFn = func(ctx context.Context, tenantID int) (domain.TenantSettings, error) {
+			return domain.TenantSettings{Timezone: "Europe/Lisbon", WeekStart: "monday"}, nil
+		}
+		store.CountClassesBetweenFn = func(ctx context.Context, tenantID int, f time.Time, to time.Time) (int, error) {
+			*from = f
+			return classes, nil
+		}
+		store.CreateClassFn = func(ctx context.Context, tenantID int, class domain.Class) (domain.Class, error) {
+			return class, nil
+		}
+		return store
+	}
+
+	class := domain.Class{
+		TrainerID: 5,
+		Name:      "Yoga Session",
+		StartsAt:  time.Date(2026, 3, 4, 7, 0, 0, 0, time.UTC),
+		EndsAt:    time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC),
+	}
+	body, _ := json.Marshal(class)
+
+	t.Run("counts the classes in the tenant week of the new class", func(t *testing.T) {
+		var from time.Time
+		store := newQuotaStore(&from, 19)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/classes", bytes.NewReader(body))
+		res := newQuotaClassRequest(store, req)
+
+		lisbon, _ := time.LoadLocation("Europe/Lisbon")
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.True(t, time.Date(2026, 3, 2, 0, 0, 0, 0, lisbon).Equal(from), "weeks should start on monday in Lisbon")
+	})
+
+	t.Run("returns 403 status code with quota_exceeded at the limit", func(t *testing.T) {
+		var from time.Time
+		store := newQuotaStore(&from, 20)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/classes", bytes.NewReader(body))
+		res := newQuotaClassRequest(store, req)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrStatusQuotaExceeded, got.Code, "codes should be equal")
+		assert.Equal(t, ErrMsgClassQuotaExceeded, got.Message, "messages should be equal")
+	})
+
+	t.Run("counts the classes in the transaction creating the class", func(t *testing.T) {
+		var from time.Time
+		store := newQuotaStore(&from, 0)
+		tx := newQuotaStore(&from, 20)
+		store.WithTxFn = func(ctx context.Context, fn func(tx domain.Store) error) error {
+			return fn(tx)
+		}
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/classes", bytes.NewReader(body))
+		res := newQuotaClassRequest(store, req)
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+	})
+}
+
+func TestGetUsage(t *testing.T) {
+	store := new(mock.Store)
+	store.GetTenantPlanFn = func(ctx context.Context, tenantID int) (domain.Plan, error) {
+		return domain.Plan{Code: domain.PlanStarter, MaxMembers: ptr(100), MaxClassesPerWeek: ptr(20)}, nil
+	}
+	store.GetTenantSettingsFn = func(ctx context.Context, tenantID int) (domain.TenantSettings, error) {
+		return domain.TenantSettings{Timezone: "UTC", WeekStart: "monday"}, nil
+	}
+	store.CountMembersFn = func(ctx context.Context, tenantID int) (int, error) {
+		return 42, nil
+	}
+	store.CountClassesBetweenFn = func(ctx context.Context, tenantID int, from time.Time, to time.Time) (int, error) {
+		return 7, nil
+	}
+
+	req := httptest.NewRequest("GET", "/api/tenants/1/usage", nil)
+	res := httptest.NewRecorder()
+	NewUsageHandler(slog.Default(), NewQuotas(store, store, store)).ServeHTTP(res, asPrincipal(req, testPrincipal(7, domain.RoleMember)))
+
+	var got Response[UsageResponse]
+	json.NewDecoder(res.Body).Decode(&got)
+	assert.Equal(t, 200, res.Code, "status codes should be equal")
+	assert.Equal(t, QuotaUsage{Used: 42, Limit: ptr(100)}, got.Data.Members, "member usage should be equal")
+	assert.Equal(t, QuotaUsage{Used: 7, Limit: ptr(20)}, got.Data.ClassesThisWeek, "class usage should be equal")
+}
+
+func newQuotaUserRequest(store *mock.Store, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewUserHandler(slog.Default(), store, newTestVerifier(discardMailer()), NewQuotas(store, store, store), discardMailer(), "https://app.gymulty.test")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, withDefaultPrincipal(req))
+	return res
+}
+
+func newQuotaAcceptRequest(store *mock.Store) *httptest.ResponseRecorder {
+	body := `{"token": "invite-token", "password": "NewSecret2002"}`
+	req := httptest.NewRequest("POST", "/api/tenants/1/auth/invitations/accept", strings.NewReader(body))
+	tokens := NewTokenManager("test-secret", 15*time.Minute, 24*time.Hour)
+	handler := NewAuthHandler(slog.Default(), store, tokens, NewQuotas(store, store, store), discardMailer(), "https://app.gymulty.test")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
+
+// quotaRoles returns the built-in roles, a "guest" role without any
+// permission and a "front-desk" role of staff.
+func quotaRoles(ctx context.Context, tenantID int, name string) (domain.Role, error) {
+	switch name {
+	case "guest":
+		return domain.Role{Name: name, Permissions: []string{}}, nil
+	case "front-desk":
+		return domain.Role{Name: name, Permissions: []string{domain.PermUsersRead}}, nil
+	}
+	perms, ok := domain.DefaultRolePermissions[name]
+	if !ok {
+		return domain.Role{}, sql.ErrNoRows
+	}
+	return domain.Role{Name: name, Permissions: perms}, nil
+}
+
+func newQuotaClassRequest(store *mock.Store, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewClassHandler(slog.Default(), store, store, NewQuotas(store, store, store))
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, withDefaultPrincipal(req))
+	return res
+}
+
+// unlimitedQuotas lets handlers under test create as much as they like.
+func unlimitedQuotas() *Quotas {
+	plans := new(mock.PlanStore)
+	plans.GetTenantPlanFn = func(ctx context.Context, tenantID int) (domain.Plan, error) {
+		return domain.Plan{Code: domain.PlanUnlimited}, nil
+	}
+	return NewQuotas(plans, new(mock.TenantSettingsStore), new(mock.RoleStore))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -1,6 +1,10 @@
 package http
 
-import "github.com/emanuelquerty/gymulty/domain"
+import (
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
 
 type Response[T any] struct {
 	Count int `json:"count"  bson:"count"`
@@ -12,3 +16,90 @@ type TenantSignupResponse struct {
 	Tenant  domain.Tenant     `json:"tenant,omitempty"  bson:"tenant"`
 	Admin   domain.PublicUser `json:"admin,omitempty"  bson:"admin"`
 }
+
+type TenantStatusResponse struct {
+	ID        int        `json:"id"  bson:"id"`
+	Status    string     `json:"status"  bson:"status"`
+	Reason    string     `json:"status_reason,omitempty"  bson:"status_reason"`
+	ChangedAt *time.Time `json:"status_changed_at,omitempty"  bson:"status_changed_at"`
+
+	// DeletionScheduledFor is when a tenant pending deletion is purged.
+	DeletionScheduledFor *time.Time `json:"deletion_scheduled_for,omitempty"  bson:"deletion_scheduled_for"`
+}
+
+type LoginResponse struct {
+	AccessToken  string `json:"access_token,omitempty"  bson:"access_token"`
+	TokenType    string `json:"token_type,omitempty"  bson:"token_type"`
+	ExpiresIn    int    `json:"expires_in,omitempty"  bson:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"  bson:"refresh_token"`
+
+	// RecoveryCodes is only set when logging in completed a two-factor enrollment.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"  bson:"recovery_codes"`
+}
+
+// TwoFactorChallengeResponse is returned by login instead of tokens when a
+// second factor is needed. The token is exchanged together with a code.
+// APIKeyCreatedResponse is the only response that includes the key itself.
+type APIKeyCreatedResponse struct {
+	domain.APIKey
+	Key string `json:"key"  bson:"key"`
+}
+
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired  bool   `json:"two_factor_required"  bson:"two_factor_required"`
+	EnrollmentRequired bool   `json:"enrollment_required"  bson:"enrollment_required"`
+	TwoFactorToken     string `json:"two_factor_token"  bson:"two_factor_token"`
+	ExpiresIn          int    `json:"expires_in"  bson:"expires_in"`
+}
+
+type TwoFactorEnrollmentResponse struct {
+	Secret          string `json:"secret"  bson:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"  bson:"provisioning_uri"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled   bool       `json:"enabled"  bson:"enabled"`
+	EnabledAt *time.Time `json:"enabled_at,omitempty"  bson:"enabled_at"`
+	Required  bool       `json:"required"  bson:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"  bson:"recovery_codes"`
+}
+
+// QuotaUsage is how much of a plan limit is used. Limit is nil when unlimited.
+type QuotaUsage struct {
+	Used  int  `json:"used"  bson:"used"`
+	Limit *int `json:"limit"  bson:"limit"`
+}
+
+type UsageResponse struct {
+	Plan            domain.Plan `json:"plan"  bson:"plan"`
+	Members         QuotaUsage  `json:"members"  bson:"members"`
+	ClassesThisWeek QuotaUsage  `json:"classes_this_week"  bson:"classes_this_week"`
+	WeekStartsAt    time.Time   `json:"week_starts_at"  bson:"week_starts_at"`
+	WeekEndsAt      time.Time   `json:"week_ends_at"  bson:"week_ends_at"`
+}
+
+// ExportResponse carries a download link once the export completed.
+type ExportResponse struct {
+	domain.Export
+	DownloadURL string `json:"download_url,omitempty"  bson:"download_url"`
+}
+
+// ImportRowError is a problem with a row of an import, numbered by its line
+// in the file with the header as row 1.
+type ImportRowError struct {
+	Row     int    `json:"row"  bson:"row"`
+	Field   string `json:"field"  bson:"field"`
+	Message string `json:"message"  bson:"message"`
+}
+
+// ImportResponse reports an import. Invitations are only set once it has
+// been committed.
+type ImportResponse struct {
+	DryRun      bool                `json:"dry_run"  bson:"dry_run"`
+	Rows        int                 `json:"rows"  bson:"rows"`
+	Errors      []ImportRowError    `json:"errors"  bson:"errors"`
+	Invitations []domain.Invitation `json:"invitations,omitempty"  bson:"invitations"`
+}
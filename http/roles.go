@@ -0,0 +1,278 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrInvalidRole       = errors.New("role: invalid role")
+	ErrBuiltinRole       = errors.New("role: built-in role cannot be modified")
+	ErrRoleNotAssignable = errors.New("role: role grants permissions the caller does not have")
+	ErrOwnRole           = errors.New("role: users cannot change their own role")
+)
+
+type RoleHandler struct {
+	http.Handler
+	store  domain.RoleStore
+	logger *slog.Logger
+}
+
+func NewRoleHandler(logger *slog.Logger, store domain.RoleStore) *RoleHandler {
+	router := http.NewServeMux()
+
+	handler := &RoleHandler{
+		Handler: middleware.StripSlashes(router),
+		store:   store,
+		logger:  logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (h *RoleHandler) registerRoutes(router *http.ServeMux) {
+	readRole := Allow(domain.PermRolesRead, domain.PermRolesWrite)
+	writeRole := Allow(domain.PermRolesWrite)
+
+	router.Handle("POST /api/tenants/{tenantID}/roles", authorize(h.logger, writeRole, verifiedTenant(h.logger, h.createRole)))
+	router.Handle("GET /api/tenants/{tenantID}/roles/{roleID}", authorize(h.logger, readRole, h.getRoleByID))
+	router.Handle("PATCH /api/tenants/{tenantID}/roles/{roleID}", authorize(h.logger, writeRole, h.updateRole))
+	router.Handle("DELETE /api/tenants/{tenantID}/roles/{roleID}", authorize(h.logger, writeRole, h.deleteRoleByID))
+	router.Handle("GET /api/tenants/{tenantID}/roles", authorize(h.logger, readRole, h.getAllRoles))
+}
+
+func (h *RoleHandler) createRole(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var role domain.Role
+	json.NewDecoder(r.Body).Decode(&role)
+
+	if role.Name == "" {
+		return e.withContext(ErrInvalidRole, ErrMsgInvalidRoleName, ErrStatusBadRequest)
+	}
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	if appErr := validatePermissions(e, role.Permissions); appErr != nil {
+		return appErr
+	}
+	p, _ := PrincipalFromContext(r.Context())
+	if !canGrant(p, role.Permissions) {
+		return e.withContext(ErrPermissionDenied, ErrMsgRolePermissionNotHeld, ErrStatusForbidden)
+	}
+
+	role, err = h.store.CreateRole(r.Context(), tenantID, role)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	resourceURI := fmt.Sprintf("%s://%s%s/%d", r.URL.Scheme, r.Host, r.URL.String(), role.ID)
+	w.Header().Set("Location", resourceURI)
+
+	w.WriteHeader(http.StatusCreated)
+	res := Response[[]domain.Role]{Count: 1, Data: []domain.Role{role}}
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *RoleHandler) getRoleByID(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	roleID, err := strconv.Atoi(r.PathValue("roleID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	role, err := h.store.GetRoleByID(r.Context(), tenantID, roleID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	res := Response[[]domain.Role]{Count: 1, Data: []domain.Role{role}}
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *RoleHandler) updateRole(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	roleID, err := strconv.Atoi(r.PathValue("roleID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var update domain.RoleUpdate
+	json.NewDecoder(r.Body).Decode(&update)
+
+	if update.Name != nil && *update.Name == "" {
+		return e.withContext(ErrInvalidRole, ErrMsgInvalidRoleName, ErrStatusBadRequest)
+	}
+	p, _ := PrincipalFromContext(r.Context())
+	if update.Permissions != nil {
+		if appErr := validatePermissions(e, *update.Permissions); appErr != nil {
+			return appErr
+		}
+		if !canGrant(p, *update.Permissions) {
+			return e.withContext(ErrPermissionDenied, ErrMsgRolePermissionNotHeld, ErrStatusForbidden)
+		}
+	}
+
+	if appErr := h.editableRole(r, e, p, tenantID, roleID, update.Name); appErr != nil {
+		return appErr
+	}
+
+	role, err := h.store.UpdateRole(r.Context(), tenantID, roleID, update)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	res := Response[[]domain.Role]{Count: 1, Data: []domain.Role{role}}
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *RoleHandler) deleteRoleByID(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	roleID, err := strconv.Atoi(r.PathValue("roleID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	p, _ := PrincipalFromContext(r.Context())
+	if appErr := h.editableRole(r, e, p, tenantID, roleID, nil); appErr != nil {
+		return appErr
+	}
+
+	err = h.store.DeleteRoleByID(r.Context(), tenantID, roleID)
+	var dbError *pgconn.PgError
+	if errors.As(err, &dbError) && dbError.Code == "23503" {
+		// withContext would report this as an invalid role on a user
+		return &appError{Error: err, Code: ErrStatusConflict, Message: ErrMsgRoleInUse, Logger: h.logger}
+	}
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *RoleHandler) getAllRoles(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	roles, err := h.store.GetAllRoles(r.Context(), tenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	res := Response[[]domain.Role]{
+		Count: len(roles),
+		Data:  roles,
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+// editableRole rejects changes p may not make to the role: the admin role
+// must always exist with every permission so a tenant can never lock itself
+// out, and the other built-in roles, which imports default to and quotas
+// and classes rely on, keep their name. Roles granting permissions p does not
+// have are out of reach, like the users holding them. newName is the name
+// the role is renamed to, if any.
+func (h *RoleHandler) editableRole(r *http.Request, e *appError, p Principal, tenantID int, roleID int, newName *string) *appError {
+	role, err := h.store.GetRoleByID(r.Context(), tenantID, roleID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	if role.Name == domain.RoleAdmin {
+		return e.withContext(ErrBuiltinRole, ErrMsgBuiltinRole, ErrStatusForbidden)
+	}
+	_, builtin := domain.DefaultRolePermissions[role.Name]
+	if builtin && (r.Method == http.MethodDelete || newName != nil && *newName != role.Name) {
+		return e.withContext(ErrBuiltinRole, ErrMsgBuiltinRoleName, ErrStatusForbidden)
+	}
+	if !canGrant(p, role.Permissions) {
+		return e.withContext(ErrPermissionDenied, ErrMsgPermissionDenied, ErrStatusForbidden)
+	}
+	return nil
+}
+
+func validatePermissions(e *appError, perms []string) *appError {
+	for _, perm := range perms {
+		if !domain.IsValidPermission(perm) {
+			err := fmt.Errorf("role: unknown permission %q", perm)
+			return e.withContext(err, ErrMsgInvalidPermission, ErrStatusBadRequest)
+		}
+	}
+	return nil
+}
+
+// canGrant reports whether p holds every one of perms, so that handing them
+// to someone else gives away nothing p could not do itself.
+func canGrant(p Principal, perms []string) bool {
+	for _, perm := range perms {
+		if !p.Can(perm) {
+			return false
+		}
+	}
+	return true
+}
+
+// assignableRole checks that p may give a user the named role. Holders of
+// users:write could otherwise make anyone, themselves included, an admin.
+func assignableRole(ctx context.Context, store domain.RoleStore, e *appError, p Principal, tenantID int, name string) *appError {
+	if p.Can(domain.PermAll) {
+		return nil // nothing to gain, and unknown roles are rejected by the database
+	}
+
+	role, err := store.GetRoleByName(ctx, tenantID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return e.withContext(ErrInvalidRole, constraintErrors["users_tenant_id_role_fkey"], ErrStatusBadRequest)
+	}
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	if !canGrant(p, role.Permissions) {
+		return e.withContext(ErrRoleNotAssignable, ErrMsgRoleNotAssignable, ErrStatusForbidden)
+	}
+	return nil
+}
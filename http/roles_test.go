@@ -0,0 +1,228 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRole(t *testing.T) {
+	role := domain.Role{
+		Name:        "front-desk",
+		Description: "Checks members in",
+		Permissions: []string{domain.PermUsersRead, domain.PermClassesRead},
+	}
+
+	store := new(mock.RoleStore)
+	store.CreateRoleFn = func(ctx context.Context, tenantID int, role domain.Role) (domain.Role, error) {
+		role.ID = 4
+		role.TenantID = tenantID
+		return role, nil
+	}
+
+	t.Run("returns newly created role with 201 status code", func(t *testing.T) {
+		body, _ := json.Marshal(role)
+		req := httptest.NewRequest("POST", "/api/tenants/1/roles", bytes.NewBuffer(body))
+		res := newRoleRequest(store, req)
+
+		want := role
+		want.ID = 4
+		want.TenantID = 1
+
+		var got Response[[]domain.Role]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.Equal(t, []domain.Role{want}, got.Data, "roles should be equal")
+	})
+
+	t.Run("returns 400 status code on unknown permission", func(t *testing.T) {
+		invalid := role
+		invalid.Permissions = []string{"billing:refund"}
+		body, _ := json.Marshal(invalid)
+		req := httptest.NewRequest("POST", "/api/tenants/1/roles", bytes.NewBuffer(body))
+		res := newRoleRequest(store, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 400 status code on wildcard permission", func(t *testing.T) {
+		invalid := role
+		invalid.Permissions = []string{domain.PermAll}
+		body, _ := json.Marshal(invalid)
+		req := httptest.NewRequest("POST", "/api/tenants/1/roles", bytes.NewBuffer(body))
+		res := newRoleRequest(store, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 403 status code for members", func(t *testing.T) {
+		body, _ := json.Marshal(role)
+		req := httptest.NewRequest("POST", "/api/tenants/1/roles", bytes.NewBuffer(body))
+		res := newRoleRequest(store, asPrincipal(req, testPrincipal(7, domain.RoleMember)))
+
+		assertPermissionDenied(t, res)
+	})
+
+	t.Run("returns 403 status code when granting permissions the caller lacks", func(t *testing.T) {
+		escalated := role
+		escalated.Permissions = []string{domain.PermUsersWrite, domain.PermTenantManage}
+		body, _ := json.Marshal(escalated)
+		req := httptest.NewRequest("POST", "/api/tenants/1/roles", bytes.NewBuffer(body))
+		res := newRoleRequest(store, asPrincipal(req, roleManager()))
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgRolePermissionNotHeld, got.Message, "messages should be equal")
+	})
+}
+
+func TestUpdateRole(t *testing.T) {
+	t.Run("returns 403 status code when changing the admin role", func(t *testing.T) {
+		store := new(mock.RoleStore)
+		store.GetRoleByIDFn = func(ctx context.Context, tenantID int, roleID int) (domain.Role, error) {
+			return domain.Role{ID: roleID, Name: domain.RoleAdmin}, nil
+		}
+
+		body, _ := json.Marshal(map[string][]string{"permissions": {domain.PermClassesRead}})
+		req := httptest.NewRequest("PATCH", "/api/tenants/1/roles/1", bytes.NewBuffer(body))
+		res := newRoleRequest(store, req)
+
+		assertPermissionDenied(t, res)
+	})
+
+	t.Run("returns 403 status code when renaming a built-in role", func(t *testing.T) {
+		store := new(mock.RoleStore)
+		store.GetRoleByIDFn = func(ctx context.Context, tenantID int, roleID int) (domain.Role, error) {
+			return domain.Role{ID: roleID, Name: domain.RoleMember, Permissions: domain.DefaultRolePermissions[domain.RoleMember]}, nil
+		}
+
+		body, _ := json.Marshal(map[string]string{"name": "client"})
+		req := httptest.NewRequest("PATCH", "/api/tenants/1/roles/3", bytes.NewBuffer(body))
+		res := newRoleRequest(store, req)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgBuiltinRoleName, got.Message, "messages should be equal")
+	})
+
+	t.Run("returns 403 status code when adding permissions the caller lacks to their role", func(t *testing.T) {
+		store := new(mock.RoleStore)
+		store.GetRoleByIDFn = func(ctx context.Context, tenantID int, roleID int) (domain.Role, error) {
+			return domain.Role{ID: roleID, Name: "role-manager", Permissions: roleManager().Permissions}, nil
+		}
+		var updated bool
+		store.UpdateRoleFn = func(ctx context.Context, tenantID int, roleID int, updates domain.RoleUpdate) (domain.Role, error) {
+			updated = true
+			return domain.Role{}, nil
+		}
+
+		perms := append(roleManager().Permissions, domain.PermDataExport)
+		body, _ := json.Marshal(map[string][]string{"permissions": perms})
+		req := httptest.NewRequest("PATCH", "/api/tenants/1/roles/5", bytes.NewBuffer(body))
+		res := newRoleRequest(store, asPrincipal(req, roleManager()))
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.False(t, updated, "role should not be updated")
+	})
+
+	t.Run("returns 403 status code when changing a role with permissions the caller lacks", func(t *testing.T) {
+		store := new(mock.RoleStore)
+		store.GetRoleByIDFn = func(ctx context.Context, tenantID int, roleID int) (domain.Role, error) {
+			return domain.Role{ID: roleID, Name: "manager", Permissions: []string{domain.PermTenantManage}}, nil
+		}
+
+		body, _ := json.Marshal(map[string][]string{"permissions": {domain.PermClassesRead}})
+		req := httptest.NewRequest("PATCH", "/api/tenants/1/roles/5", bytes.NewBuffer(body))
+		res := newRoleRequest(store, asPrincipal(req, roleManager()))
+
+		assertPermissionDenied(t, res)
+	})
+
+	t.Run("returns updated role", func(t *testing.T) {
+		store := new(mock.RoleStore)
+		store.GetRoleByIDFn = func(ctx context.Context, tenantID int, roleID int) (domain.Role, error) {
+			return domain.Role{ID: roleID, Name: "coach"}, nil
+		}
+		store.UpdateRoleFn = func(ctx context.Context, tenantID int, roleID int, updates domain.RoleUpdate) (domain.Role, error) {
+			return domain.Role{ID: roleID, Name: *updates.Name, Permissions: []string{}}, nil
+		}
+
+		body, _ := json.Marshal(map[string]string{"name": "part-time-coach"})
+		req := httptest.NewRequest("PATCH", "/api/tenants/1/roles/5", bytes.NewBuffer(body))
+		res := newRoleRequest(store, req)
+
+		var got Response[[]domain.Role]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, "part-time-coach", got.Data[0].Name, "names should be equal")
+	})
+}
+
+func TestDeleteRoleByID(t *testing.T) {
+	t.Run("returns 409 status code when role is still assigned", func(t *testing.T) {
+		store := new(mock.RoleStore)
+		store.GetRoleByIDFn = func(ctx context.Context, tenantID int, roleID int) (domain.Role, error) {
+			return domain.Role{ID: roleID, Name: "coach"}, nil
+		}
+		store.DeleteRoleByIDFn = func(ctx context.Context, tenantID int, roleID int) error {
+			return &pgconn.PgError{Code: "23503", ConstraintName: "users_tenant_id_role_fkey"}
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/roles/5", nil)
+		res := newRoleRequest(store, req)
+
+		assert.Equal(t, 409, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 403 status code when deleting a built-in role", func(t *testing.T) {
+		store := new(mock.RoleStore)
+		store.GetRoleByIDFn = func(ctx context.Context, tenantID int, roleID int) (domain.Role, error) {
+			return domain.Role{ID: roleID, Name: domain.RoleTrainer}, nil
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/roles/2", nil)
+		res := newRoleRequest(store, req)
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 204 status code on success", func(t *testing.T) {
+		store := new(mock.RoleStore)
+		store.GetRoleByIDFn = func(ctx context.Context, tenantID int, roleID int) (domain.Role, error) {
+			return domain.Role{ID: roleID, Name: "coach"}, nil
+		}
+		store.DeleteRoleByIDFn = func(ctx context.Context, tenantID int, roleID int) error {
+			return nil
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/roles/5", nil)
+		res := newRoleRequest(store, req)
+
+		assert.Equal(t, 204, res.Code, "status codes should be equal")
+	})
+}
+
+func newRoleRequest(store domain.RoleStore, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewRoleHandler(slog.Default(), store)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, withDefaultPrincipal(req))
+	return res
+}
+
+// roleManager may manage roles and read users and classes, but nothing else.
+func roleManager() Principal {
+	p := testPrincipal(9, "role-manager")
+	p.Permissions = []string{domain.PermRolesRead, domain.PermRolesWrite, domain.PermUsersRead, domain.PermClassesRead}
+	return p
+}
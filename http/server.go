@@ -1,45 +1,102 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
 
+	"github.com/emanuelquerty/gymulty/config"
 	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/export"
 	"github.com/emanuelquerty/gymulty/http/middleware"
-	"github.com/emanuelquerty/gymulty/postgres"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Server struct {
-	router      http.Handler
-	logger      *slog.Logger
-	middlewares []middleware.Middleware
-	store       domain.Store
+	router        http.Handler
+	logger        *slog.Logger
+	middlewares   []middleware.Middleware
+	store         domain.Store
+	tokens        *TokenManager
+	mailer        domain.Mailer
+	appURL        string
+	tenants       *TenantResolver
+	platformToken string
+	exporter      *Exporter
+	closer        *TenantCloser
 }
 
-func NewServer(pool *pgxpool.Pool, logger *slog.Logger) *Server {
-	store := postgres.NewStore(pool)
+func NewServer(store domain.Store, logger *slog.Logger, authConf *config.Auth, tenancyConf *config.Tenancy, exportConf *config.Export, mailer domain.Mailer) *Server {
 	router := http.NewServeMux()
 
 	server := &Server{
-		router: router,
-		logger: logger,
-		store:  store,
+		router:        router,
+		logger:        logger,
+		store:         store,
+		tokens:        NewTokenManager(authConf.TokenSecret, authConf.AccessTokenTTL, authConf.RefreshTokenTTL),
+		mailer:        mailer,
+		appURL:        authConf.AppURL,
+		platformToken: authConf.PlatformToken,
 	}
+	server.tenants = NewTenantResolver(store, tenancyConf.BaseDomain, tenancyConf.CacheTTL)
+	disk := export.NewDisk(exportConf.Dir)
+	server.exporter = NewExporter(logger, store, disk, server.tokens, exportConf.TTL, exportConf.LinkTTL)
+	server.closer = NewTenantCloser(logger, store, disk, server.tenants, tenancyConf.DeletionGracePeriod)
 
 	server.registerRoutes(router)
 	return server
 }
 
 func (s *Server) registerRoutes(router *http.ServeMux) {
-	tenantHandler := NewTenantHandler(s.logger, s.store)
-	userHandler := NewUserHandler(s.logger, s.store)
-	classHandler := NewClassHandler(s.logger, s.store)
+	verifier := NewEmailVerifier(s.tokens, s.mailer, s.appURL)
+	quotas := NewQuotas(s.store, s.store, s.store)
+
+	tenantHandler := NewTenantHandler(s.logger, s.store, verifier, s.tenants, s.closer)
+	authHandler := NewAuthHandler(s.logger, s.store, s.tokens, quotas, s.mailer, s.appURL)
+	userHandler := NewUserHandler(s.logger, s.store, verifier, quotas, s.mailer, s.appURL)
+	classHandler := NewClassHandler(s.logger, s.store, s.store, quotas)
+	roleHandler := NewRoleHandler(s.logger, s.store)
+	sessionHandler := NewSessionHandler(s.logger, s.store)
+	twoFactorHandler := NewTwoFactorHandler(s.logger, s.store)
+	loginAttemptHandler := NewLoginAttemptHandler(s.logger, s.store)
+	apiKeyHandler := NewAPIKeyHandler(s.logger, s.store)
+	invitationHandler := NewInvitationHandler(s.logger, s.store, quotas, s.mailer, s.appURL)
+	platformHandler := NewPlatformHandler(s.logger, s.store, s.tokens, s.tenants, s.closer)
+	tenantSettingsHandler := NewTenantSettingsHandler(s.logger, s.store)
+	locationHandler := NewLocationHandler(s.logger, s.store)
+	usageHandler := NewUsageHandler(s.logger, quotas)
+	exportHandler := NewExportHandler(s.logger, s.store, s.exporter)
+	importHandler := NewImportHandler(s.logger, s.store, quotas, s.mailer, s.appURL)
+
+	authenticate := Authenticate(s.tokens, s.store)
 
 	router.Handle("/api/tenants/", tenantHandler)
-	router.Handle("/api/tenants/{tenantID}/users/", userHandler)
-	router.Handle("/api/tenants/{tenantID}/classes/", classHandler)
+	router.Handle("/api/tenants/signup", tenantHandler)
+	router.Handle("/api/tenants/{tenantID}", authenticate(s.logger, tenantHandler))
+	router.Handle("/api/tenants/{tenantID}/", authenticate(s.logger, tenantHandler))
+	router.Handle("/api/tenants/{tenantID}/auth/", authHandler)
+	router.Handle("/api/tenants/{tenantID}/settings/", authenticate(s.logger, tenantSettingsHandler))
+	router.Handle("/api/tenants/{tenantID}/usage/", authenticate(s.logger, usageHandler))
+	router.Handle("/api/tenants/{tenantID}/locations/", authenticate(s.logger, locationHandler))
+	router.Handle("/api/tenants/{tenantID}/exports/", authenticate(s.logger, exportHandler))
+	router.Handle("/api/tenants/{tenantID}/exports/{exportID}/download", exportHandler)
+	router.Handle("/api/tenants/{tenantID}/exports/{exportID}/download/", exportHandler)
+	router.Handle("/api/tenants/{tenantID}/users/", authenticate(s.logger, userHandler))
+	router.Handle("/api/tenants/{tenantID}/users/import", authenticate(s.logger, importHandler))
+	router.Handle("/api/tenants/{tenantID}/users/import/", authenticate(s.logger, importHandler))
+	router.Handle("/api/tenants/{tenantID}/classes/", authenticate(s.logger, classHandler))
+	router.Handle("/api/tenants/{tenantID}/roles/", authenticate(s.logger, roleHandler))
+	router.Handle("/api/tenants/{tenantID}/me/sessions/", authenticate(s.logger, sessionHandler))
+	router.Handle("/api/tenants/{tenantID}/me/two-factor/", authenticate(s.logger, twoFactorHandler))
+	router.Handle("/api/tenants/{tenantID}/login-attempts/", authenticate(s.logger, loginAttemptHandler))
+	router.Handle("/api/tenants/{tenantID}/api-keys/", authenticate(s.logger, apiKeyHandler))
+	router.Handle("/api/tenants/{tenantID}/invitations/", authenticate(s.logger, invitationHandler))
+	router.Handle("/api/platform/auth/", platformHandler)
+	router.Handle("/api/platform/", PlatformAuthenticate(s.tokens, s.platformToken)(s.logger, platformHandler))
+
+	// Everything else under /api/ is relative to the tenant of the subdomain,
+	// e.g. ironworks.gymulty.app/api/classes.
+	router.Handle("/api/", TenantRoutes(s.tenants)(s.logger, router))
 }
 
 func (s *Server) Use(m middleware.Middleware) {
@@ -59,6 +116,9 @@ func (s *Server) ListenAndServe(port int) error {
 		Addr:    fmt.Sprintf(":%d", port),
 		Handler: s.registerGlobalMiddlewares(),
 	}
+	go s.exporter.Run(context.Background(), expiredExportsInterval)
+	go s.closer.Run(context.Background(), purgeInterval)
+
 	s.logger.Info("server is running", slog.Int("port", port))
 	return server.ListenAndServe()
 }
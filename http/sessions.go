@@ -0,0 +1,90 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+// SessionHandler lets the authenticated user list and revoke their own sessions.
+type SessionHandler struct {
+	http.Handler
+	store  domain.SessionStore
+	logger *slog.Logger
+}
+
+func NewSessionHandler(logger *slog.Logger, store domain.SessionStore) *SessionHandler {
+	router := http.NewServeMux()
+
+	handler := &SessionHandler{
+		Handler: middleware.StripSlashes(router),
+		store:   store,
+		logger:  logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (h *SessionHandler) registerRoutes(router *http.ServeMux) {
+	router.Handle("GET /api/tenants/{tenantID}/me/sessions", authorize(h.logger, AuthenticatedUser, h.getSessions))
+	router.Handle("DELETE /api/tenants/{tenantID}/me/sessions/{sessionID}", authorize(h.logger, AuthenticatedUser, h.revokeSession))
+	router.Handle("DELETE /api/tenants/{tenantID}/me/sessions", authorize(h.logger, AuthenticatedUser, h.revokeAllSessions))
+}
+
+func (h *SessionHandler) getSessions(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	sessions, err := h.store.GetActiveSessions(r.Context(), p.TenantID, p.UserID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == p.SessionID
+	}
+
+	res := Response[[]domain.Session]{
+		Count: len(sessions),
+		Data:  sessions,
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+	return nil
+}
+
+func (h *SessionHandler) revokeSession(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	sessionID, err := strconv.Atoi(r.PathValue("sessionID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	err = h.store.RevokeSession(r.Context(), p.TenantID, p.UserID, sessionID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// revokeAllSessions logs the user out everywhere, including the current device.
+func (h *SessionHandler) revokeAllSessions(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	err := h.store.RevokeAllSessions(r.Context(), p.TenantID, p.UserID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
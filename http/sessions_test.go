@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSessions(t *testing.T) {
+	t.Run("returns own sessions marking the current one", func(t *testing.T) {
+		store := new(mock.SessionStore)
+		store.GetActiveSessionsFn = func(ctx context.Context, tenantID int, userID int) ([]domain.Session, error) {
+			return []domain.Session{
+				{ID: 3, TenantID: tenantID, UserID: userID},
+				{ID: 5, TenantID: tenantID, UserID: userID},
+			}, nil
+		}
+
+		p := testPrincipal(7, domain.RoleMember)
+		p.SessionID = 5
+		req := httptest.NewRequest("GET", "/api/tenants/1/me/sessions", nil)
+		res := newSessionRequest(store, asPrincipal(req, p))
+
+		var got Response[[]domain.Session]
+		json.NewDecoder(res.Body).Decode(&got)
+
+		want := Response[[]domain.Session]{
+			Count: 2,
+			Data: []domain.Session{
+				{ID: 3, TenantID: 1, UserID: 7, Current: false},
+				{ID: 5, TenantID: 1, UserID: 7, Current: true},
+			},
+		}
+		assert.Equal(t, want, got, "responses should match")
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Run("revokes a session of the caller", func(t *testing.T) {
+		var revoked [3]int
+		store := new(mock.SessionStore)
+		store.RevokeSessionFn = func(ctx context.Context, tenantID int, userID int, sessionID int) error {
+			revoked = [3]int{tenantID, userID, sessionID}
+			return nil
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/me/sessions/3", nil)
+		res := newSessionRequest(store, asPrincipal(req, testPrincipal(7, domain.RoleMember)))
+
+		assert.Equal(t, 204, res.Code, "status codes should be equal")
+		assert.Equal(t, [3]int{1, 7, 3}, revoked, "session of the caller should be revoked")
+	})
+
+	t.Run("returns 404 status code for unknown session", func(t *testing.T) {
+		store := new(mock.SessionStore)
+		store.RevokeSessionFn = func(ctx context.Context, tenantID int, userID int, sessionID int) error {
+			return sql.ErrNoRows
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/me/sessions/99", nil)
+		res := newSessionRequest(store, req)
+
+		assert.Equal(t, 404, res.Code, "status codes should be equal")
+	})
+
+	t.Run("logs out everywhere", func(t *testing.T) {
+		called := false
+		store := new(mock.SessionStore)
+		store.RevokeAllSessionsFn = func(ctx context.Context, tenantID int, userID int) error {
+			called = true
+			return nil
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/me/sessions", nil)
+		res := newSessionRequest(store, req)
+
+		assert.Equal(t, 204, res.Code, "status codes should be equal")
+		assert.True(t, called, "all sessions should be revoked")
+	})
+}
+
+func newSessionRequest(store domain.SessionStore, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewSessionHandler(slog.Default(), store)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, withDefaultPrincipal(req))
+	return res
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+var ErrUnknownTenant = errors.New("tenant: no tenant is served from this host")
+
+// TenantResolver maps the subdomain a request was sent to, such as
+// ironworks in ironworks.gymulty.app, onto its tenant. Lookups are cached
+// for ttl so tenant-relative routes don't cost a query on every request.
+type TenantResolver struct {
+	store      domain.TenantStore
+	baseDomain string
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu    sync.RWMutex
+	cache map[string]cachedTenant
+}
+
+type cachedTenant struct {
+	tenant    domain.Tenant
+	expiresAt time.Time
+}
+
+func NewTenantResolver(store domain.TenantStore, baseDomain string, ttl time.Duration) *TenantResolver {
+	return &TenantResolver{
+		store:      store,
+		baseDomain: strings.ToLower(strings.Trim(baseDomain, ".")),
+		ttl:        ttl,
+		now:        time.Now,
+		cache:      make(map[string]cachedTenant),
+	}
+}
+
+// Subdomain returns the tenant label of host. Hosts that are not a direct
+// subdomain of the base domain, including the base domain itself, have none.
+func (tr *TenantResolver) Subdomain(host string) (string, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	subdomain, ok := strings.CutSuffix(host, "."+tr.baseDomain)
+	if !ok || subdomain == "" || strings.Contains(subdomain, ".") {
+		return "", false
+	}
+	return subdomain, true
+}
+
+// Resolve returns the tenant served from host or ErrUnknownTenant.
+func (tr *TenantResolver) Resolve(ctx context.Context, host string) (domain.Tenant, error) {
+	subdomain, ok := tr.Subdomain(host)
+	if !ok {
+		return domain.Tenant{}, ErrUnknownTenant
+	}
+
+	tr.mu.RLock()
+	entry, ok := tr.cache[subdomain]
+	tr.mu.RUnlock()
+	if ok && tr.now().Before(entry.expiresAt) {
+		return entry.tenant, nil
+	}
+
+	tenant, err := tr.store.GetTenantBySubdomain(ctx, subdomain)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Tenant{}, ErrUnknownTenant
+	}
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+
+	tr.mu.Lock()
+	tr.cache[subdomain] = cachedTenant{tenant: tenant, expiresAt: tr.now().Add(tr.ttl)}
+	tr.mu.Unlock()
+	return tenant, nil
+}
+
+// Forget drops subdomain from the cache so the next request looks it up
+// again, e.g. after the tenant was changed.
+func (tr *TenantResolver) Forget(subdomain string) {
+	tr.mu.Lock()
+	delete(tr.cache, strings.ToLower(subdomain))
+	tr.mu.Unlock()
+}
+
+// TenantRoutes serves tenant-relative routes such as /api/classes for the
+// tenant resolved from the Host header by rewriting them onto the matching
+// /api/tenants/{tenantID}/... route of next. Hosts without a known tenant
+// get a 404.
+func TenantRoutes(resolver *TenantResolver) middleware.Middleware {
+	return func(logger *slog.Logger, next http.Handler) http.Handler {
+		return errorHandler(func(w http.ResponseWriter, r *http.Request) *appError {
+			e := &appError{Logger: logger}
+
+			tenant, err := resolver.Resolve(r.Context(), r.Host)
+			if errors.Is(err, ErrUnknownTenant) {
+				return e.withContext(err, ErrMsgUnknownTenant, ErrStatusNotFound)
+			}
+			if err != nil {
+				return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+			}
+
+			// Every tenant handler strips trailing slashes, so adding one
+			// keeps the router from redirecting /api/classes to the
+			// numeric /api/tenants/{tenantID}/classes/ route.
+			path := "/api/tenants/" + strconv.Itoa(tenant.ID) + strings.TrimPrefix(r.URL.Path, "/api")
+			if !strings.HasSuffix(path, "/") {
+				path += "/"
+			}
+
+			r = r.Clone(r.Context())
+			r.URL.Path = path
+			r.URL.RawPath = ""
+			next.ServeHTTP(w, r)
+			return nil
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTenantResolverStore(lookups *int) *mock.TenantStore {
+	store := new(mock.TenantStore)
+	store.GetTenantBySubdomainFn = func(ctx context.Context, subdomain string) (domain.Tenant, error) {
+		*lookups++
+		if subdomain != "ironworks" {
+			return domain.Tenant{}, sql.ErrNoRows
+		}
+		return domain.Tenant{ID: 5, Subdomain: "ironworks"}, nil
+	}
+	return store
+}
+
+func TestTenantResolverSubdomain(t *testing.T) {
+	resolver := NewTenantResolver(new(mock.TenantStore), "gymulty.app", time.Minute)
+
+	tests := []struct {
+		host      string
+		subdomain string
+		ok        bool
+	}{
+		{"ironworks.gymulty.app", "ironworks", true},
+		{"IronWorks.Gymulty.app:8080", "ironworks", true},
+		{"ironworks.gymulty.app.", "ironworks", true},
+		{"gymulty.app", "", false},
+		{"a.b.gymulty.app", "", false},
+		{"ironworks.example.com", "", false},
+		{"ironworksgymulty.app", "", false},
+	}
+
+	for _, tt := range tests {
+		subdomain, ok := resolver.Subdomain(tt.host)
+		assert.Equal(t, tt.subdomain, subdomain, tt.host)
+		assert.Equal(t, tt.ok, ok, tt.host)
+	}
+}
+
+func TestTenantResolverResolve(t *testing.T) {
+	t.Run("caches lookups until they expire", func(t *testing.T) {
+		var lookups int
+		resolver := NewTenantResolver(newTenantResolverStore(&lookups), "gymulty.app", time.Minute)
+		now := time.Now()
+		resolver.now = func() time.Time { return now }
+
+		for range 3 {
+			tenant, err := resolver.Resolve(context.Background(), "ironworks.gymulty.app")
+			assert.NoError(t, err)
+			assert.Equal(t, 5, tenant.ID, "tenant should be resolved")
+		}
+		assert.Equal(t, 1, lookups, "tenant should be looked up once")
+
+		now = now.Add(2 * time.Minute)
+		resolver.Resolve(context.Background(), "ironworks.gymulty.app")
+		assert.Equal(t, 2, lookups, "expired entry should be looked up again")
+
+		resolver.Forget("ironworks")
+		resolver.Resolve(context.Background(), "ironworks.gymulty.app")
+		assert.Equal(t, 3, lookups, "forgotten entry should be looked up again")
+	})
+
+	t.Run("returns ErrUnknownTenant for unknown hosts", func(t *testing.T) {
+		var lookups int
+		resolver := NewTenantResolver(newTenantResolverStore(&lookups), "gymulty.app", time.Minute)
+
+		_, err := resolver.Resolve(context.Background(), "nogym.gymulty.app")
+		assert.ErrorIs(t, err, ErrUnknownTenant)
+
+		_, err = resolver.Resolve(context.Background(), "gymulty.app")
+		assert.ErrorIs(t, err, ErrUnknownTenant)
+		assert.Equal(t, 1, lookups, "hosts without a subdomain should not be looked up")
+	})
+}
+
+func TestTenantRoutes(t *testing.T) {
+	var lookups int
+	resolver := NewTenantResolver(newTenantResolverStore(&lookups), "gymulty.app", time.Minute)
+
+	var tenantID, path string
+	router := http.NewServeMux()
+	router.HandleFunc("/api/tenants/{tenantID}/classes/", func(w http.ResponseWriter, r *http.Request) {
+		tenantID = r.PathValue("tenantID")
+		path = r.URL.Path
+	})
+	router.Handle("/api/", TenantRoutes(resolver)(slog.Default(), router))
+
+	t.Run("maps tenant-relative routes onto the tenant of the subdomain", func(t *testing.T) {
+		tests := map[string]string{
+			"/api/classes":    "/api/tenants/5/classes/",
+			"/api/classes/3":  "/api/tenants/5/classes/3/",
+			"/api/classes/3/": "/api/tenants/5/classes/3/",
+		}
+
+		for target, want := range tests {
+			req := httptest.NewRequest("POST", target, nil)
+			req.Host = "ironworks.gymulty.app"
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			assert.Equal(t, 200, res.Code, target)
+			assert.Equal(t, "5", tenantID, target)
+			assert.Equal(t, want, path, target)
+		}
+	})
+
+	t.Run("returns 404 for unknown subdomains", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/classes", nil)
+		req.Host = "nogym.gymulty.app"
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		assert.Equal(t, 404, res.Code, "status codes should be equal")
+	})
+}
@@ -0,0 +1,170 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrInvalidTimezone     = errors.New("settings: unknown timezone")
+	ErrInvalidLocale       = errors.New("settings: invalid locale")
+	ErrInvalidCurrency     = errors.New("settings: unknown currency")
+	ErrInvalidWeekStart    = errors.New("settings: invalid week start")
+	ErrInvalidOpeningHours = errors.New("settings: invalid opening hours")
+)
+
+// weekdays are the accepted days of the week, as stored.
+var weekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// TenantSettingsHandler serves the regional settings and opening hours of a tenant.
+type TenantSettingsHandler struct {
+	http.Handler
+	store  domain.TenantSettingsStore
+	logger *slog.Logger
+}
+
+func NewTenantSettingsHandler(logger *slog.Logger, store domain.TenantSettingsStore) *TenantSettingsHandler {
+	router := http.NewServeMux()
+
+	handler := &TenantSettingsHandler{
+		Handler: middleware.StripSlashes(router),
+		store:   store,
+		logger:  logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (h *TenantSettingsHandler) registerRoutes(router *http.ServeMux) {
+	router.Handle("GET /api/tenants/{tenantID}/settings", authorize(h.logger, Authenticated, h.getSettings))
+	router.Handle("PATCH /api/tenants/{tenantID}/settings", authorize(h.logger, Allow(domain.PermTenantManage), h.updateSettings))
+}
+
+func (h *TenantSettingsHandler) getSettings(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	settings, err := h.store.GetTenantSettings(r.Context(), p.TenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	writeTenantSettings(w, settings)
+	return nil
+}
+
+func (h *TenantSettingsHandler) updateSettings(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	var update domain.TenantSettingsUpdate
+	json.NewDecoder(r.Body).Decode(&update)
+
+	if appErr := validateTenantSettings(e, &update); appErr != nil {
+		return appErr
+	}
+
+	settings, err := h.store.UpdateTenantSettings(r.Context(), p.TenantID, update)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	writeTenantSettings(w, settings)
+	return nil
+}
+
+func writeTenantSettings(w http.ResponseWriter, settings domain.TenantSettings) {
+	res := Response[domain.TenantSettings]{
+		Count: 1,
+		Data:  settings,
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// validateTenantSettings checks the fields being changed and brings them
+// into their canonical form, e.g. "pt-pt" becomes "pt-PT" and "eur" "EUR".
+func validateTenantSettings(e *appError, update *domain.TenantSettingsUpdate) *appError {
+	if update.Timezone != nil {
+		if _, err := loadTimezone(*update.Timezone); err != nil {
+			return e.withContext(err, ErrMsgInvalidTimezone, ErrStatusBadRequest)
+		}
+	}
+
+	if update.Locale != nil {
+		tag, err := language.Parse(*update.Locale)
+		if err != nil {
+			return e.withContext(ErrInvalidLocale, ErrMsgInvalidLocale, ErrStatusBadRequest)
+		}
+		locale := tag.String()
+		update.Locale = &locale
+	}
+
+	if update.Currency != nil {
+		unit, err := currency.ParseISO(*update.Currency)
+		if err != nil {
+			return e.withContext(ErrInvalidCurrency, ErrMsgInvalidCurrency, ErrStatusBadRequest)
+		}
+		code := unit.String()
+		update.Currency = &code
+	}
+
+	if update.WeekStart != nil {
+		day := strings.ToLower(*update.WeekStart)
+		if !slices.Contains(weekdays, day) {
+			return e.withContext(ErrInvalidWeekStart, ErrMsgInvalidWeekStart, ErrStatusBadRequest)
+		}
+		update.WeekStart = &day
+	}
+
+	if update.OpeningHours != nil {
+		for i, hours := range *update.OpeningHours {
+			hours.Day = strings.ToLower(hours.Day)
+			if !validOpeningHours(hours) {
+				return e.withContext(ErrInvalidOpeningHours, ErrMsgInvalidOpeningHours, ErrStatusBadRequest)
+			}
+			(*update.OpeningHours)[i] = hours
+		}
+	}
+	return nil
+}
+
+// validOpeningHours reports whether hours name a weekday and open before
+// they close. Gyms open past midnight split the hours across both days.
+func validOpeningHours(hours domain.OpeningHours) bool {
+	if !slices.Contains(weekdays, hours.Day) {
+		return false
+	}
+	opens, err := time.Parse("15:04", hours.Opens)
+	if err != nil {
+		return false
+	}
+	closes, err := time.Parse("15:04", hours.Closes)
+	if err != nil {
+		return false
+	}
+	return opens.Before(closes)
+}
+
+// loadTimezone loads an IANA timezone. Unlike time.LoadLocation it refuses
+// the empty name and "Local", which depend on the server.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
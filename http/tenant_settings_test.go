@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTenantSettings(t *testing.T) {
+	store := new(mock.TenantSettingsStore)
+	store.GetTenantSettingsFn = func(ctx context.Context, tenantID int) (domain.TenantSettings, error) {
+		return domain.TenantSettings{TenantID: tenantID, Timezone: "Europe/Lisbon", Currency: "EUR"}, nil
+	}
+
+	req := httptest.NewRequest("GET", "/api/tenants/1/settings", nil)
+	res := newTenantSettingsRequest(store, asPrincipal(req, testPrincipal(7, domain.RoleMember)))
+
+	var got Response[domain.TenantSettings]
+	json.NewDecoder(res.Body).Decode(&got)
+	assert.Equal(t, 200, res.Code, "status codes should be equal")
+	assert.Equal(t, "Europe/Lisbon", got.Data.Timezone, "timezones should be equal")
+}
+
+func TestUpdateTenantSettings(t *testing.T) {
+	newUpdateStore := func(updated *domain.TenantSettingsUpdate) *mock.TenantSettingsStore {
+		store := new(mock.TenantSettingsStore)
+		store.UpdateTenantSettingsFn = func(ctx context.Context, tenantID int, update domain.TenantSettingsUpdate) (domain.TenantSettings, error) {
+			*updated = update
+			return domain.TenantSettings{TenantID: tenantID}, nil
+		}
+		return store
+	}
+
+	t.Run("stores settings in their canonical form", func(t *testing.T) {
+		var updated domain.TenantSettingsUpdate
+		store := newUpdateStore(&updated)
+
+		body := `{
+			"timezone": "Europe/Lisbon",
+			"locale": "pt-pt",
+			"currency": "eur",
+			"week_start": "Sunday",
+			"opening_hours": [{"day": "Monday", "opens": "06:00", "closes": "22:00"}]
+		}`
+		req := httptest.NewRequest("PATCH", "/api/tenants/1/settings", strings.NewReader(body))
+		res := newTenantSettingsRequest(store, withDefaultPrincipal(req))
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, "Europe/Lisbon", *updated.Timezone, "timezones should be equal")
+		assert.Equal(t, "pt-PT", *updated.Locale, "locales should be equal")
+		assert.Equal(t, "EUR", *updated.Currency, "currencies should be equal")
+		assert.Equal(t, "sunday", *updated.WeekStart, "week starts should be equal")
+		assert.Equal(t, []domain.OpeningHours{{Day: "monday", Opens: "06:00", Closes: "22:00"}}, *updated.OpeningHours, "opening hours should be equal")
+	})
+
+	t.Run("returns 400 status code for invalid settings", func(t *testing.T) {
+		bodies := map[string]string{
+			"unknown timezone":       `{"timezone": "Mars/Olympus"}`,
+			"server local timezone":  `{"timezone": "Local"}`,
+			"invalid locale":         `{"locale": "not a locale"}`,
+			"unknown currency":       `{"currency": "XYZ"}`,
+			"invalid week start":     `{"week_start": "someday"}`,
+			"invalid day":            `{"opening_hours": [{"day": "funday", "opens": "06:00", "closes": "22:00"}]}`,
+			"invalid time":           `{"opening_hours": [{"day": "monday", "opens": "6am", "closes": "22:00"}]}`,
+			"closes before it opens": `{"opening_hours": [{"day": "monday", "opens": "22:00", "closes": "06:00"}]}`,
+		}
+
+		for name, body := range bodies {
+			var updated domain.TenantSettingsUpdate
+			store := newUpdateStore(&updated)
+
+			req := httptest.NewRequest("PATCH", "/api/tenants/1/settings", strings.NewReader(body))
+			res := newTenantSettingsRequest(store, withDefaultPrincipal(req))
+
+			assert.Equal(t, 400, res.Code, name)
+		}
+	})
+
+	t.Run("trainer cannot change settings", func(t *testing.T) {
+		var updated domain.TenantSettingsUpdate
+		store := newUpdateStore(&updated)
+
+		req := httptest.NewRequest("PATCH", "/api/tenants/1/settings", strings.NewReader(`{"currency": "EUR"}`))
+		res := newTenantSettingsRequest(store, asPrincipal(req, testPrincipal(3, domain.RoleTrainer)))
+
+		assertPermissionDenied(t, res)
+	})
+}
+
+func newTenantSettingsRequest(store domain.TenantSettingsStore, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewTenantSettingsHandler(slog.Default(), store)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
@@ -1,26 +1,54 @@
 package http
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/emanuelquerty/gymulty/domain"
 	"github.com/emanuelquerty/gymulty/http/middleware"
 )
 
+var (
+	ErrInvalidTenantStatus = errors.New("tenant: invalid status transition")
+	ErrInvalidBusinessName = errors.New("tenant: business name is required")
+	ErrInvalidSubdomain    = errors.New("tenant: invalid subdomain")
+	ErrReservedSubdomain   = errors.New("tenant: subdomain is reserved")
+)
+
+// subdomainPattern matches a single DNS label of at least three characters.
+var subdomainPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$`)
+
+// reservedSubdomains are served by gymulty itself and cannot be taken by a tenant.
+var reservedSubdomains = []string{
+	"admin", "api", "app", "assets", "auth", "billing", "blog", "cdn", "dashboard", "docs",
+	"ftp", "help", "login", "mail", "platform", "signup", "smtp", "static", "status", "support", "www",
+}
+
 type TenantHandler struct {
 	store domain.Store
 	http.Handler
-	logger *slog.Logger
+	verifier *EmailVerifier
+	resolver *TenantResolver
+	closer   *TenantCloser
+	logger   *slog.Logger
 }
 
-func NewTenantHandler(logger *slog.Logger, store domain.Store) *TenantHandler {
+func NewTenantHandler(logger *slog.Logger, store domain.Store, verifier *EmailVerifier, resolver *TenantResolver, closer *TenantCloser) *TenantHandler {
 	router := http.NewServeMux()
 	handler := &TenantHandler{
-		store:   store,
-		Handler: middleware.StripSlashes(router),
-		logger:  logger,
+		store:    store,
+		Handler:  middleware.StripSlashes(router),
+		verifier: verifier,
+		resolver: resolver,
+		closer:   closer,
+		logger:   logger,
 	}
 
 	handler.registerRoutes(router)
@@ -29,38 +57,75 @@ func NewTenantHandler(logger *slog.Logger, store domain.Store) *TenantHandler {
 
 func (t *TenantHandler) registerRoutes(router *http.ServeMux) {
 	router.Handle("POST /api/tenants/signup", errorHandler(t.createTenant))
+	router.Handle("GET /api/tenants/{tenantID}", authorize(t.logger, Authenticated, t.getTenant))
+	router.Handle("PATCH /api/tenants/{tenantID}", authorize(t.logger, Allow(domain.PermTenantManage), t.updateTenant))
+	router.Handle("DELETE /api/tenants/{tenantID}", authorizeInactive(t.logger, AllOf(AuthenticatedUser, Allow(domain.PermTenantManage)), t.deleteTenant))
+	router.Handle("POST /api/tenants/{tenantID}/restore", authorizeInactive(t.logger, AllOf(AuthenticatedUser, Allow(domain.PermTenantManage)), t.restoreTenant))
+	router.Handle("POST /api/tenants/{tenantID}/deactivate", authorize(t.logger, Allow(domain.PermTenantManage), t.deactivateTenant))
+	router.Handle("POST /api/tenants/{tenantID}/reactivate", authorizeInactive(t.logger, Allow(domain.PermTenantManage), t.reactivateTenant))
 }
 
 func (t *TenantHandler) createTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: t.logger}
+
 	var body domain.TenantRequestBody
 	json.NewDecoder(r.Body).Decode(&body)
 
+	body.Subdomain = normalizeSubdomain(body.Subdomain)
+	if appErr := validateTenant(e, &body.BusinessName, &body.Subdomain); appErr != nil {
+		return appErr
+	}
+	body.Email = normalizeEmail(body.Email)
+	if !validEmail(body.Email) {
+		return e.withContext(ErrInvalidEmail, ErrMsgInvalidEmail, ErrStatusBadRequest)
+	}
+	if len(body.Password) < minPasswordLength {
+		return e.withContext(ErrWeakPassword, ErrMsgWeakPassword, ErrStatusBadRequest)
+	}
+
 	tenant := domain.Tenant{
 		BusinessName: body.BusinessName,
 		Subdomain:    body.Subdomain,
+
+		RequireEmailVerification: body.RequireEmailVerification,
+		RequireAdminTwoFactor:    body.RequireAdminTwoFactor,
 	}
-	newTenant, err := t.store.CreateTenant(r.Context(), tenant)
-	e := &appError{Logger: t.logger}
+
+	hash, err := HashPassword(body.Password)
 	if err != nil {
 		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
 	}
-
-	userBody := domain.User{
-		TenantID:  newTenant.ID,
+	admin := domain.User{
 		FirstName: body.FirstName,
 		LastName:  body.LastName,
 		Email:     body.Email,
-		Password:  body.Password,
+		Password:  hash,
 		Role:      "admin",
 	}
-	userBody.Password, err = HashPassword(userBody.Password)
+
+	// a tenant without its admin, e.g. because the email is taken, would
+	// keep the subdomain forever, so both are created or neither is
+	var newTenant domain.Tenant
+	var newUser domain.User
+	err = t.store.WithTx(r.Context(), func(tx domain.Store) error {
+		var err error
+		newTenant, err = tx.CreateTenant(r.Context(), tenant)
+		if err != nil {
+			return err
+		}
+
+		admin.TenantID = newTenant.ID
+		newUser, err = tx.CreateUser(r.Context(), newTenant.ID, admin)
+		return err
+	})
 	if err != nil {
 		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
 	}
 
-	newUser, err := t.store.CreateUser(r.Context(), newTenant.ID, userBody)
+	// the tenant stays limited until the admin follows the link in this email
+	err = t.verifier.Send(r.Context(), newUser)
 	if err != nil {
-		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+		t.logger.Error("sending verification email", slog.String("error", err.Error()))
 	}
 
 	res := Response[TenantSignupResponse]{
@@ -74,3 +139,191 @@ func (t *TenantHandler) createTenant(w http.ResponseWriter, r *http.Request) *ap
 	json.NewEncoder(w).Encode(res)
 	return nil
 }
+
+func (t *TenantHandler) getTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: t.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	tenant, err := t.store.GetTenantByID(r.Context(), p.TenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	writeTenant(w, tenant)
+	return nil
+}
+
+// updateTenant renames the business, moves it to another subdomain or
+// changes its security requirements.
+func (t *TenantHandler) updateTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: t.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	var update domain.TenantUpdate
+	json.NewDecoder(r.Body).Decode(&update)
+
+	if update.Subdomain != nil {
+		*update.Subdomain = normalizeSubdomain(*update.Subdomain)
+	}
+	if appErr := validateTenant(e, update.BusinessName, update.Subdomain); appErr != nil {
+		return appErr
+	}
+
+	old, err := t.store.GetTenantByID(r.Context(), p.TenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	tenant, err := t.store.UpdateTenant(r.Context(), p.TenantID, update)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	// the old subdomain stops resolving right away instead of once its cache entry expires
+	if tenant.Subdomain != old.Subdomain {
+		t.resolver.Forget(old.Subdomain)
+	}
+
+	writeTenant(w, tenant)
+	return nil
+}
+
+// deleteTenant closes the account. The tenant turns read only and is purged
+// with all its users and classes once the grace period ends, unless an admin
+// restores it first. Only users may do this, never an integration.
+func (t *TenantHandler) deleteTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: t.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	var body domain.TenantStatusRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	tenant, err := t.closer.Schedule(r.Context(), p.TenantID, domain.DeletionByTenant, body.Reason)
+	if errors.Is(err, ErrInvalidTenantStatus) {
+		return e.withContext(err, ErrMsgInvalidTenantStatus, ErrStatusConflict)
+	}
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	writeTenantStatus(w, tenant)
+	return nil
+}
+
+// restoreTenant cancels the deletion of the tenant during its grace period,
+// returning it to its status before. Deletions the platform operator
+// scheduled can only be cancelled by them.
+func (t *TenantHandler) restoreTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: t.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	tenant, err := t.store.RestoreTenant(r.Context(), p.TenantID, "", domain.DeletionByTenant)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tenantStatusConflict(r.Context(), t.store, e, p.TenantID)
+	}
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	writeTenantStatus(w, tenant)
+	return nil
+}
+
+// deactivateTenant lets the admin put their own tenant on hold. Its users
+// can still log in and read their data until it is reactivated.
+func (t *TenantHandler) deactivateTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: t.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	var body domain.TenantStatusRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	tenant, appErr := changeTenantStatus(r.Context(), t.store, e, p.TenantID, domain.TenantInactive, body.Reason, domain.TenantActive)
+	if appErr != nil {
+		return appErr
+	}
+	writeTenantStatus(w, tenant)
+	return nil
+}
+
+// reactivateTenant undoes a deactivation. Suspensions can only be lifted
+// by the platform operator.
+func (t *TenantHandler) reactivateTenant(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: t.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	tenant, appErr := changeTenantStatus(r.Context(), t.store, e, p.TenantID, domain.TenantActive, "", domain.TenantInactive)
+	if appErr != nil {
+		return appErr
+	}
+	writeTenantStatus(w, tenant)
+	return nil
+}
+
+// changeTenantStatus moves the tenant to status, provided its current
+// status is one of from.
+func changeTenantStatus(ctx context.Context, store domain.TenantStore, e *appError, tenantID int, status string, reason string, from ...string) (domain.Tenant, *appError) {
+	tenant, err := store.UpdateTenantStatus(ctx, tenantID, status, reason, from)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Tenant{}, tenantStatusConflict(ctx, store, e, tenantID)
+	}
+	if err != nil {
+		return domain.Tenant{}, e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	return tenant, nil
+}
+
+// tenantStatusConflict reports a status change the tenant was not in a status
+// for, unless the tenant does not exist at all.
+func tenantStatusConflict(ctx context.Context, store domain.TenantStore, e *appError, tenantID int) *appError {
+	_, err := store.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	return e.withContext(ErrInvalidTenantStatus, ErrMsgInvalidTenantStatus, ErrStatusConflict)
+}
+
+func writeTenantStatus(w http.ResponseWriter, tenant domain.Tenant) {
+	res := Response[TenantStatusResponse]{
+		Count: 1,
+		Data: TenantStatusResponse{
+			ID:                   tenant.ID,
+			Status:               tenant.Status,
+			Reason:               tenant.StatusReason,
+			ChangedAt:            tenant.StatusChangedAt,
+			DeletionScheduledFor: tenant.DeletionScheduledFor,
+		},
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func writeTenant(w http.ResponseWriter, tenant domain.Tenant) {
+	res := Response[domain.Tenant]{
+		Count: 1,
+		Data:  tenant,
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func normalizeSubdomain(subdomain string) string {
+	return strings.ToLower(strings.TrimSpace(subdomain))
+}
+
+// validateTenant checks the business name and the already normalized
+// subdomain. Nil fields are not being changed and are skipped.
+func validateTenant(e *appError, businessName *string, subdomain *string) *appError {
+	if businessName != nil && strings.TrimSpace(*businessName) == "" {
+		return e.withContext(ErrInvalidBusinessName, ErrMsgInvalidBusinessName, ErrStatusBadRequest)
+	}
+	if subdomain == nil {
+		return nil
+	}
+	if !subdomainPattern.MatchString(*subdomain) {
+		return e.withContext(ErrInvalidSubdomain, ErrMsgInvalidSubdomain, ErrStatusBadRequest)
+	}
+	if slices.Contains(reservedSubdomains, *subdomain) {
+		return e.withContext(ErrReservedSubdomain, ErrMsgReservedSubdomain, ErrStatusBadRequest)
+	}
+	return nil
+}
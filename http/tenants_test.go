@@ -3,14 +3,19 @@ package http
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/emanuelquerty/gymulty/domain"
 	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,11 +70,431 @@ func TestCreateTenant(t *testing.T) {
 		json.NewDecoder(res.Body).Decode(&got)
 		assert.Equal(t, want, got, "tenants should match")
 	})
+
+	t.Run("creates tenant and admin in one transaction", func(t *testing.T) {
+		var inTx bool
+		var createdInTx []bool
+		store := new(mock.Store)
+		store.WithTxFn = func(ctx context.Context, fn func(tx domain.Store) error) error {
+			inTx = true
+			defer func() { inTx = false }()
+			return fn(store)
+		}
+		store.CreateTenantFn = func(ctx context.Context, data domain.Tenant) (domain.Tenant, error) {
+			createdInTx = append(createdInTx, inTx)
+			data.ID = 1
+			return data, nil
+		}
+		store.CreateUserFn = func(ctx context.Context, tenantID int, data domain.User) (domain.User, error) {
+			createdInTx = append(createdInTx, inTx)
+			data.ID = 1
+			return data, nil
+		}
+
+		jsonBody, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/api/tenants/signup", bytes.NewBuffer(jsonBody))
+		res := newTenantRequest(store, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, []bool{true, true}, createdInTx, "tenant and admin should be created in the transaction")
+	})
+
+	t.Run("fails the signup when the admin cannot be created", func(t *testing.T) {
+		var txErr error
+		store := new(mock.Store)
+		store.WithTxFn = func(ctx context.Context, fn func(tx domain.Store) error) error {
+			txErr = fn(store)
+			return txErr
+		}
+		store.CreateTenantFn = func(ctx context.Context, data domain.Tenant) (domain.Tenant, error) {
+			data.ID = 1
+			return data, nil
+		}
+		store.CreateUserFn = func(ctx context.Context, tenantID int, data domain.User) (domain.User, error) {
+			return domain.User{}, &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}
+		}
+
+		jsonBody, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/api/tenants/signup", bytes.NewBuffer(jsonBody))
+		res := newTenantRequest(store, req)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 409, res.Code, "status codes should be equal")
+		assert.Equal(t, "Email already exists", got.Message, "messages should be equal")
+		assert.Error(t, txErr, "transaction should be rolled back")
+	})
+
+	t.Run("returns 400 status code for an invalid admin email or a weak password", func(t *testing.T) {
+		invalidEmail := body
+		invalidEmail.Email = "not-an-email"
+		weakPassword := body
+		weakPassword.Password = "short"
+
+		for want, signup := range map[string]domain.TenantRequestBody{
+			ErrMsgInvalidEmail: invalidEmail,
+			ErrMsgWeakPassword: weakPassword,
+		} {
+			store := new(mock.Store)
+			jsonBody, _ := json.Marshal(signup)
+			req := httptest.NewRequest("POST", "/api/tenants/signup", bytes.NewBuffer(jsonBody))
+			res := newTenantRequest(store, req)
+
+			var got appError
+			json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, 400, res.Code, "status codes should be equal")
+			assert.Equal(t, want, got.Message, "messages should be equal")
+		}
+	})
+
+	t.Run("returns 400 status code for a reserved subdomain", func(t *testing.T) {
+		store := new(mock.Store)
+
+		reserved := body
+		reserved.Subdomain = "WWW"
+		jsonBody, _ := json.Marshal(reserved)
+		req := httptest.NewRequest("POST", "/api/tenants/signup", bytes.NewBuffer(jsonBody))
+		res := newTenantRequest(store, req)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgReservedSubdomain, got.Message, "messages should be equal")
+	})
+
+	t.Run("emails a verification link to the admin", func(t *testing.T) {
+		store := new(mock.Store)
+		store.CreateTenantFn = func(ctx context.Context, data domain.Tenant) (domain.Tenant, error) {
+			data.ID = 1
+			return data, nil
+		}
+		store.CreateUserFn = func(ctx context.Context, tenantID int, data domain.User) (domain.User, error) {
+			data.ID = 1
+			return data, nil
+		}
+
+		var sent domain.Email
+		mailer := new(mock.Mailer)
+		mailer.SendFn = func(ctx context.Context, email domain.Email) error {
+			sent = email
+			return nil
+		}
+
+		jsonBody, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/api/tenants/signup", bytes.NewBuffer(jsonBody))
+		resolver := NewTenantResolver(store, "gymulty.app", time.Minute)
+		handler := NewTenantHandler(slog.Default(), store, newTestVerifier(mailer), resolver, newTestCloser(store, resolver))
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, body.Email, sent.To, "recipients should be equal")
+		assert.Contains(t, sent.Body, "/verify-email?", "email should contain the verification link")
+	})
 }
 
 func newTenantRequest(store *mock.Store, req *http.Request) *httptest.ResponseRecorder {
-	handler := NewTenantHandler(slog.Default(), store)
+	resolver := NewTenantResolver(store, "gymulty.app", time.Minute)
+	handler := NewTenantHandler(slog.Default(), store, newTestVerifier(discardMailer()), resolver, newTestCloser(store, resolver))
 	res := httptest.NewRecorder()
 	handler.ServeHTTP(res, req)
 	return res
 }
+
+func TestTenantStatus(t *testing.T) {
+	newStatusStore := func(status string, updated *[2]string) *mock.Store {
+		store := new(mock.Store)
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{ID: tenantID, Status: status}, nil
+		}
+		store.UpdateTenantStatusFn = func(ctx context.Context, tenantID int, to string, reason string, from []string) (domain.Tenant, error) {
+			if !slices.Contains(from, status) {
+				return domain.Tenant{}, sql.ErrNoRows
+			}
+			*updated = [2]string{to, reason}
+			now := time.Now()
+			return domain.Tenant{ID: tenantID, Status: to, StatusReason: reason, StatusChangedAt: &now}, nil
+		}
+		return store
+	}
+
+	t.Run("admin deactivates their tenant with a reason", func(t *testing.T) {
+		var updated [2]string
+		store := newStatusStore(domain.TenantActive, &updated)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/deactivate", strings.NewReader(`{"reason":"closed for renovation"}`))
+		res := newTenantRequest(store, withDefaultPrincipal(req))
+
+		var got Response[TenantStatusResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, [2]string{domain.TenantInactive, "closed for renovation"}, updated, "tenant should be deactivated")
+		assert.Equal(t, domain.TenantInactive, got.Data.Status, "statuses should be equal")
+		assert.NotNil(t, got.Data.ChangedAt, "change time should be returned")
+	})
+
+	t.Run("admin reactivates a deactivated tenant", func(t *testing.T) {
+		var updated [2]string
+		store := newStatusStore(domain.TenantInactive, &updated)
+
+		admin := testPrincipal(1, domain.RoleAdmin)
+		admin.TenantReadOnly = true
+		req := httptest.NewRequest("POST", "/api/tenants/1/reactivate", nil)
+		res := newTenantRequest(store, asPrincipal(req, admin))
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, domain.TenantActive, updated[0], "tenant should be reactivated")
+	})
+
+	t.Run("returns 409 status code when reactivating an active tenant", func(t *testing.T) {
+		var updated [2]string
+		store := newStatusStore(domain.TenantActive, &updated)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/reactivate", nil)
+		res := newTenantRequest(store, withDefaultPrincipal(req))
+
+		assert.Equal(t, 409, res.Code, "status codes should be equal")
+		assert.Empty(t, updated[0], "tenant should not be updated")
+	})
+
+	t.Run("trainer cannot deactivate the tenant", func(t *testing.T) {
+		var updated [2]string
+		store := newStatusStore(domain.TenantActive, &updated)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/deactivate", nil)
+		res := newTenantRequest(store, asPrincipal(req, testPrincipal(3, domain.RoleTrainer)))
+
+		assertPermissionDenied(t, res)
+	})
+}
+
+func TestGetTenant(t *testing.T) {
+	store := new(mock.Store)
+	store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+		return domain.Tenant{ID: tenantID, BusinessName: "SwoleGym", Subdomain: "swolegym", Status: domain.TenantActive}, nil
+	}
+
+	req := httptest.NewRequest("GET", "/api/tenants/1", nil)
+	res := newTenantRequest(store, asPrincipal(req, testPrincipal(7, domain.RoleMember)))
+
+	var got map[string]any
+	json.NewDecoder(res.Body).Decode(&got)
+	data, _ := got["data"].(map[string]any)
+	assert.Equal(t, 200, res.Code, "status codes should be equal")
+	assert.Equal(t, "swolegym", data["subdomain"], "tenant should use json field names")
+	assert.Equal(t, "SwoleGym", data["business_name"], "tenant should use json field names")
+}
+
+func TestUpdateTenant(t *testing.T) {
+	newUpdateStore := func(updated *domain.TenantUpdate) *mock.Store {
+		store := new(mock.Store)
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{ID: tenantID, BusinessName: "SwoleGym", Subdomain: "swolegym"}, nil
+		}
+		store.UpdateTenantFn = func(ctx context.Context, tenantID int, update domain.TenantUpdate) (domain.Tenant, error) {
+			*updated = update
+			tenant := domain.Tenant{ID: tenantID, BusinessName: "SwoleGym", Subdomain: "swolegym"}
+			if update.BusinessName != nil {
+				tenant.BusinessName = *update.BusinessName
+			}
+			if update.Subdomain != nil {
+				tenant.Subdomain = *update.Subdomain
+			}
+			return tenant, nil
+		}
+		return store
+	}
+
+	t.Run("renames the business", func(t *testing.T) {
+		var updated domain.TenantUpdate
+		store := newUpdateStore(&updated)
+
+		req := httptest.NewRequest("PATCH", "/api/tenants/1", strings.NewReader(`{"business_name":"Iron Works"}`))
+		res := newTenantRequest(store, withDefaultPrincipal(req))
+
+		var got Response[domain.Tenant]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, "Iron Works", got.Data.BusinessName, "business names should be equal")
+		assert.Nil(t, updated.Subdomain, "subdomain should not be updated")
+	})
+
+	t.Run("normalizes a new subdomain", func(t *testing.T) {
+		var updated domain.TenantUpdate
+		store := newUpdateStore(&updated)
+
+		req := httptest.NewRequest("PATCH", "/api/tenants/1", strings.NewReader(`{"subdomain":" IronWorks "}`))
+		res := newTenantRequest(store, withDefaultPrincipal(req))
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, "ironworks", *updated.Subdomain, "subdomains should be equal")
+	})
+
+	t.Run("forgets the cached old subdomain", func(t *testing.T) {
+		var updated domain.TenantUpdate
+		store := newUpdateStore(&updated)
+		var lookups int
+		store.GetTenantBySubdomainFn = func(ctx context.Context, subdomain string) (domain.Tenant, error) {
+			lookups++
+			return domain.Tenant{ID: 1, Subdomain: subdomain}, nil
+		}
+		resolver := NewTenantResolver(store, "gymulty.app", time.Minute)
+		resolver.Resolve(context.Background(), "swolegym.gymulty.app")
+
+		req := httptest.NewRequest("PATCH", "/api/tenants/1", strings.NewReader(`{"subdomain":"ironworks"}`))
+		handler := NewTenantHandler(slog.Default(), store, newTestVerifier(discardMailer()), resolver, newTestCloser(store, resolver))
+		handler.ServeHTTP(httptest.NewRecorder(), withDefaultPrincipal(req))
+
+		resolver.Resolve(context.Background(), "swolegym.gymulty.app")
+		assert.Equal(t, 2, lookups, "old subdomain should be looked up again")
+	})
+
+	t.Run("returns 400 status code for invalid and reserved subdomains", func(t *testing.T) {
+		for _, subdomain := range []string{"ab", "-iron", "iron-", "iron.works", "iron_works", "www", "api"} {
+			var updated domain.TenantUpdate
+			store := newUpdateStore(&updated)
+
+			body, _ := json.Marshal(domain.TenantUpdate{Subdomain: &subdomain})
+			req := httptest.NewRequest("PATCH", "/api/tenants/1", bytes.NewBuffer(body))
+			res := newTenantRequest(store, withDefaultPrincipal(req))
+
+			assert.Equal(t, 400, res.Code, subdomain)
+			assert.Nil(t, updated.Subdomain, subdomain)
+		}
+	})
+
+	t.Run("returns 400 status code for an empty business name", func(t *testing.T) {
+		var updated domain.TenantUpdate
+		store := newUpdateStore(&updated)
+
+		req := httptest.NewRequest("PATCH", "/api/tenants/1", strings.NewReader(`{"business_name":"  "}`))
+		res := newTenantRequest(store, withDefaultPrincipal(req))
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("trainer cannot update the tenant", func(t *testing.T) {
+		var updated domain.TenantUpdate
+		store := newUpdateStore(&updated)
+
+		req := httptest.NewRequest("PATCH", "/api/tenants/1", strings.NewReader(`{"business_name":"Iron Works"}`))
+		res := newTenantRequest(store, asPrincipal(req, testPrincipal(3, domain.RoleTrainer)))
+
+		assertPermissionDenied(t, res)
+	})
+}
+
+func TestDeleteTenant(t *testing.T) {
+	newDeleteStore := func(status string, requestedBy string, scheduled *time.Time) *mock.Store {
+		store := new(mock.Store)
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{ID: tenantID, Subdomain: "swolegym", Status: status, DeletionRequestedBy: &requestedBy}, nil
+		}
+		store.ScheduleTenantDeletionFn = func(ctx context.Context, tenantID int, requestedBy string, reason string, scheduledFor time.Time) (domain.Tenant, error) {
+			if status == domain.TenantPendingDeletion {
+				return domain.Tenant{}, sql.ErrNoRows
+			}
+			*scheduled = scheduledFor
+			return domain.Tenant{ID: tenantID, Status: domain.TenantPendingDeletion, DeletionRequestedBy: &requestedBy, DeletionScheduledFor: &scheduledFor}, nil
+		}
+		store.RestoreTenantFn = func(ctx context.Context, tenantID int, reason string, by ...string) (domain.Tenant, error) {
+			if status != domain.TenantPendingDeletion || !slices.Contains(by, requestedBy) {
+				return domain.Tenant{}, sql.ErrNoRows
+			}
+			return domain.Tenant{ID: tenantID, Status: domain.TenantActive}, nil
+		}
+		return store
+	}
+
+	t.Run("admin closes their account after a grace period", func(t *testing.T) {
+		var scheduled time.Time
+		store := newDeleteStore(domain.TenantActive, "", &scheduled)
+
+		req := httptest.NewRequest("DELETE", "/api/tenants/1", strings.NewReader(`{"reason":"moving away"}`))
+		res := newTenantRequest(store, withDefaultPrincipal(req))
+
+		var got Response[TenantStatusResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, domain.TenantPendingDeletion, got.Data.Status, "statuses should be equal")
+		assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), scheduled, time.Minute, "deletion should be scheduled after the grace period")
+		assert.Equal(t, scheduled.Unix(), got.Data.DeletionScheduledFor.Unix(), "scheduled dates should be equal")
+	})
+
+	t.Run("admin of a deactivated tenant can close their account", func(t *testing.T) {
+		var scheduled time.Time
+		store := newDeleteStore(domain.TenantInactive, "", &scheduled)
+
+		admin := testPrincipal(1, domain.RoleAdmin)
+		admin.TenantReadOnly = true
+		req := httptest.NewRequest("DELETE", "/api/tenants/1", nil)
+		res := newTenantRequest(store, asPrincipal(req, admin))
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.False(t, scheduled.IsZero(), "deletion should be scheduled")
+	})
+
+	t.Run("returns 409 status code when the deletion is already scheduled", func(t *testing.T) {
+		var scheduled time.Time
+		store := newDeleteStore(domain.TenantPendingDeletion, domain.DeletionByTenant, &scheduled)
+
+		admin := testPrincipal(1, domain.RoleAdmin)
+		admin.TenantReadOnly = true
+		admin.TenantPendingDeletion = true
+		req := httptest.NewRequest("DELETE", "/api/tenants/1", nil)
+		res := newTenantRequest(store, asPrincipal(req, admin))
+
+		assert.Equal(t, 409, res.Code, "status codes should be equal")
+		assert.True(t, scheduled.IsZero(), "deletion should not be rescheduled")
+	})
+
+	t.Run("api keys cannot close the account", func(t *testing.T) {
+		var scheduled time.Time
+		store := newDeleteStore(domain.TenantActive, "", &scheduled)
+
+		key := Principal{TenantID: 1, APIKeyID: 5, Permissions: []string{domain.PermTenantManage}}
+		req := httptest.NewRequest("DELETE", "/api/tenants/1", nil)
+		res := newTenantRequest(store, asPrincipal(req, key))
+
+		assertPermissionDenied(t, res)
+		assert.True(t, scheduled.IsZero(), "deletion should not be scheduled")
+	})
+
+	t.Run("admin restores their account during the grace period", func(t *testing.T) {
+		var scheduled time.Time
+		store := newDeleteStore(domain.TenantPendingDeletion, domain.DeletionByTenant, &scheduled)
+
+		admin := testPrincipal(1, domain.RoleAdmin)
+		admin.TenantReadOnly = true
+		admin.TenantPendingDeletion = true
+		req := httptest.NewRequest("POST", "/api/tenants/1/restore", nil)
+		res := newTenantRequest(store, asPrincipal(req, admin))
+
+		var got Response[TenantStatusResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, domain.TenantActive, got.Data.Status, "tenant should be active again")
+	})
+
+	t.Run("returns 409 status code when restoring a deletion the platform scheduled", func(t *testing.T) {
+		var scheduled time.Time
+		store := newDeleteStore(domain.TenantPendingDeletion, domain.DeletionByPlatform, &scheduled)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/restore", nil)
+		res := newTenantRequest(store, withDefaultPrincipal(req))
+
+		assert.Equal(t, 409, res.Code, "status codes should be equal")
+	})
+
+	t.Run("admin of a tenant pending deletion cannot change users", func(t *testing.T) {
+		admin := testPrincipal(1, domain.RoleAdmin)
+		admin.TenantReadOnly = true
+		admin.TenantPendingDeletion = true
+		req := httptest.NewRequest("PUT", "/api/tenants/1/users/8", strings.NewReader(`{"first_name":"Ann"}`))
+		res := newUserRequest(new(mock.Store), asPrincipal(req, admin))
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgTenantPendingDeletion, got.Message, "messages should be equal")
+	})
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var (
+	ErrInvalidToken = errors.New("token: invalid token")
+	ErrExpiredToken = errors.New("token: token has expired")
+)
+
+// jwtHeader is the fixed header of every token we issue. We only ever
+// sign with HS256, so tokens claiming any other algorithm are rejected.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+type Claims struct {
+	UserID    int    `json:"user_id"`
+	TenantID  int    `json:"tenant_id"`
+	Role      string `json:"role"`
+	SessionID int    `json:"sid,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Purpose   string `json:"purpose,omitempty"` // empty for access tokens
+	ExportID  int    `json:"export_id,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// TokenManager issues and verifies HMAC-SHA256 signed JWT access tokens
+// and hands out the opaque refresh tokens that are stored server-side.
+type TokenManager struct {
+	secret     []byte
+	ttl        time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+func NewTokenManager(secret string, ttl time.Duration, refreshTTL time.Duration) *TokenManager {
+	return &TokenManager{
+		secret:     []byte(secret),
+		ttl:        ttl,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+	}
+}
+
+func (tm *TokenManager) TTL() time.Duration {
+	return tm.ttl
+}
+
+// RefreshExpiry returns when a refresh token issued now expires.
+func (tm *TokenManager) RefreshExpiry() time.Time {
+	return tm.now().Add(tm.refreshTTL)
+}
+
+// Issue signs an access token for user within the given session.
+func (tm *TokenManager) Issue(user domain.User, sessionID int) (string, Claims, error) {
+	claims := Claims{
+		UserID:    user.ID,
+		TenantID:  user.TenantID,
+		Role:      user.Role,
+		SessionID: sessionID,
+	}
+	return tm.issue(claims, tm.ttl)
+}
+
+// IssueFor signs a short-lived token that is only accepted by VerifyFor
+// with the same purpose, e.g. the token in an email verification link.
+func (tm *TokenManager) IssueFor(purpose string, user domain.User, ttl time.Duration) (string, error) {
+	claims := Claims{
+		UserID:   user.ID,
+		TenantID: user.TenantID,
+		Email:    user.Email,
+		Purpose:  purpose,
+	}
+	token, _, err := tm.issue(claims, ttl)
+	return token, err
+}
+
+// Verify checks an access token. Tokens issued for another purpose are rejected.
+func (tm *TokenManager) Verify(token string) (Claims, error) {
+	claims, err := tm.verify(token)
+	if err != nil {
+		return Claims{}, err
+	}
+	if claims.Purpose != "" {
+		return Claims{}, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (tm *TokenManager) VerifyFor(purpose string, token string) (Claims, error) {
+	claims, err := tm.verify(token)
+	if err != nil {
+		return Claims{}, err
+	}
+	if claims.Purpose != purpose {
+		return Claims{}, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// purposePlatform marks the access tokens of platform operators, which
+// Verify rejects so they can never be used against a tenant.
+const purposePlatform = "platform"
+
+// IssuePlatform signs an access token for a platform operator.
+func (tm *TokenManager) IssuePlatform(operator domain.PlatformOperator) (string, Claims, error) {
+	claims := Claims{
+		UserID:  operator.ID,
+		Role:    operator.Role,
+		Email:   operator.Email,
+		Purpose: purposePlatform,
+	}
+	return tm.issue(claims, tm.ttl)
+}
+
+func (tm *TokenManager) issue(claims Claims, ttl time.Duration) (string, Claims, error) {
+	now := tm.now()
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", Claims{}, err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + tm.sign(unsigned), claims, nil
+}
+
+func (tm *TokenManager) verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return Claims{}, ErrInvalidToken
+	}
+
+	expected := tm.sign(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if tm.now().Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrExpiredToken
+	}
+	return claims, nil
+}
+
+func (tm *TokenManager) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, tm.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NewOpaqueToken returns a random url-safe token together with the hash
+// that should be persisted in its place.
+func NewOpaqueToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
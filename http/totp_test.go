@@ -0,0 +1,56 @@
+package http
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6238Secret is the SHA1 test key of RFC 6238 appendix B, base32 encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTP(t *testing.T) {
+	t.Run("matches the RFC 6238 test vectors", func(t *testing.T) {
+		vectors := map[int64]string{
+			59:         "287082",
+			1111111109: "081804",
+			1234567890: "005924",
+			2000000000: "279037",
+		}
+		for unix, want := range vectors {
+			got, err := totpCode(rfc6238Secret, unix/totpPeriod)
+			assert.NoError(t, err, "code should be generated")
+			assert.Equal(t, want, got, "codes should be equal")
+		}
+	})
+
+	t.Run("accepts codes from adjacent steps only", func(t *testing.T) {
+		now := time.Unix(1111111109, 0)
+		code, _ := totpCode(rfc6238Secret, now.Unix()/totpPeriod-1)
+
+		step, ok := ValidateTOTP(rfc6238Secret, code, now)
+		assert.True(t, ok, "previous step should be accepted")
+		assert.Equal(t, now.Unix()/totpPeriod-1, step, "matched step should be returned")
+
+		_, ok = ValidateTOTP(rfc6238Secret, code, now.Add(2*totpPeriod*time.Second))
+		assert.False(t, ok, "older steps should be rejected")
+	})
+
+	t.Run("provisioning uri carries secret and issuer", func(t *testing.T) {
+		uri := TOTPProvisioningURI(rfc6238Secret, "pgray@email.com")
+		assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Gymulty:pgray@email.com?"), "uri should be an otpauth uri")
+		assert.Contains(t, uri, "secret="+rfc6238Secret, "uri should contain the secret")
+		assert.Contains(t, uri, "issuer=Gymulty", "uri should contain the issuer")
+	})
+
+	t.Run("recovery codes are hashed ignoring formatting", func(t *testing.T) {
+		codes, hashes, err := NewRecoveryCodes()
+		assert.NoError(t, err, "codes should be generated")
+		assert.Len(t, codes, recoveryCodeCount, "all codes should be generated")
+
+		typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))
+		assert.Equal(t, hashes[0], HashRecoveryCode(typed), "hashes should be equal")
+	})
+}
@@ -0,0 +1,282 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/http/middleware"
+)
+
+var (
+	ErrInvalidTwoFactorCode = errors.New("two factor: invalid code")
+	ErrTwoFactorNotEnrolled = errors.New("two factor: no enrollment in progress")
+	ErrTwoFactorEnabled     = errors.New("two factor: already enabled")
+	ErrTwoFactorRequired    = errors.New("two factor: required by tenant")
+)
+
+// TwoFactorHandler lets the authenticated user manage their own TOTP enrollment.
+type TwoFactorHandler struct {
+	http.Handler
+	store  domain.Store
+	logger *slog.Logger
+}
+
+func NewTwoFactorHandler(logger *slog.Logger, store domain.Store) *TwoFactorHandler {
+	router := http.NewServeMux()
+
+	handler := &TwoFactorHandler{
+		Handler: middleware.StripSlashes(router),
+		store:   store,
+		logger:  logger,
+	}
+	handler.registerRoutes(router)
+	return handler
+}
+
+func (h *TwoFactorHandler) registerRoutes(router *http.ServeMux) {
+	router.Handle("GET /api/tenants/{tenantID}/me/two-factor", authorize(h.logger, AuthenticatedUser, h.getStatus))
+	router.Handle("POST /api/tenants/{tenantID}/me/two-factor", authorize(h.logger, AuthenticatedUser, h.enroll))
+	router.Handle("POST /api/tenants/{tenantID}/me/two-factor/confirm", authorize(h.logger, AuthenticatedUser, h.confirm))
+	router.Handle("DELETE /api/tenants/{tenantID}/me/two-factor", authorize(h.logger, AuthenticatedUser, h.disable))
+	router.Handle("POST /api/tenants/{tenantID}/me/two-factor/recovery-codes", authorize(h.logger, AuthenticatedUser, h.regenerateRecoveryCodes))
+}
+
+func (h *TwoFactorHandler) getStatus(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	tenant, err := h.store.GetTenantByID(r.Context(), p.TenantID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	status := TwoFactorStatusResponse{
+		Required: tenant.RequireAdminTwoFactor && p.Role == domain.RoleAdmin,
+	}
+
+	twoFactor, err := h.store.GetTwoFactor(r.Context(), p.TenantID, p.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	if err == nil && twoFactor.EnabledAt != nil {
+		status.Enabled = true
+		status.EnabledAt = twoFactor.EnabledAt
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response[TwoFactorStatusResponse]{Count: 1, Data: status})
+	return nil
+}
+
+func (h *TwoFactorHandler) enroll(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	user, err := h.store.GetUserByID(r.Context(), p.TenantID, p.UserID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	enrollment, err := enrollTwoFactor(r.Context(), h.store, user)
+	if err != nil {
+		return twoFactorError(e, err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(Response[TwoFactorEnrollmentResponse]{Count: 1, Data: enrollment})
+	return nil
+}
+
+func (h *TwoFactorHandler) confirm(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	var body domain.TwoFactorCodeRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	codes, err := confirmTwoFactor(r.Context(), h.store, p.TenantID, p.UserID, body.Code)
+	if err != nil {
+		return twoFactorError(e, err)
+	}
+	return writeRecoveryCodes(w, codes)
+}
+
+// disable turns two-factor authentication off after checking a second
+// factor, unless the tenant requires it for the caller.
+func (h *TwoFactorHandler) disable(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	var body domain.TwoFactorCodeRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	if p.Role == domain.RoleAdmin {
+		tenant, err := h.store.GetTenantByID(r.Context(), p.TenantID)
+		if err != nil {
+			return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+		}
+		if tenant.RequireAdminTwoFactor {
+			return e.withContext(ErrTwoFactorRequired, ErrMsgTwoFactorRequired, ErrStatusForbidden)
+		}
+	}
+
+	err := h.checkEnabled(r.Context(), p, body)
+	if err != nil {
+		return twoFactorError(e, err)
+	}
+
+	err = h.store.DisableTwoFactor(r.Context(), p.TenantID, p.UserID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// regenerateRecoveryCodes replaces all recovery codes, used or not.
+func (h *TwoFactorHandler) regenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: h.logger}
+	p, _ := PrincipalFromContext(r.Context())
+
+	var body domain.TwoFactorCodeRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	err := h.checkEnabled(r.Context(), p, body)
+	if err != nil {
+		return twoFactorError(e, err)
+	}
+
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	err = h.store.ReplaceRecoveryCodes(r.Context(), p.TenantID, p.UserID, hashes)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	return writeRecoveryCodes(w, codes)
+}
+
+// checkEnabled verifies the second factor in body against the caller's
+// enabled enrollment.
+func (h *TwoFactorHandler) checkEnabled(ctx context.Context, p Principal, body domain.TwoFactorCodeRequestBody) error {
+	twoFactor, err := h.store.GetTwoFactor(ctx, p.TenantID, p.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+	if twoFactor.EnabledAt == nil {
+		return ErrTwoFactorNotEnrolled
+	}
+	return checkSecondFactor(ctx, h.store, twoFactor, body.Code, body.RecoveryCode)
+}
+
+func writeRecoveryCodes(w http.ResponseWriter, codes []string) *appError {
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Response[RecoveryCodesResponse]{Count: len(codes), Data: RecoveryCodesResponse{RecoveryCodes: codes}})
+	return nil
+}
+
+// enrollTwoFactor starts a pending enrollment for user with a new secret.
+func enrollTwoFactor(ctx context.Context, store domain.TwoFactorStore, user domain.User) (TwoFactorEnrollmentResponse, error) {
+	existing, err := store.GetTwoFactor(ctx, user.TenantID, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return TwoFactorEnrollmentResponse{}, err
+	}
+	if err == nil && existing.EnabledAt != nil {
+		return TwoFactorEnrollmentResponse{}, ErrTwoFactorEnabled
+	}
+
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		return TwoFactorEnrollmentResponse{}, err
+	}
+
+	err = store.SaveTwoFactorSecret(ctx, user.TenantID, user.ID, secret)
+	if err != nil {
+		return TwoFactorEnrollmentResponse{}, err
+	}
+	return TwoFactorEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: TOTPProvisioningURI(secret, user.Email),
+	}, nil
+}
+
+// confirmTwoFactor enables the user's pending enrollment once code proves
+// the authenticator app was set up, and returns their new recovery codes.
+func confirmTwoFactor(ctx context.Context, store domain.TwoFactorStore, tenantID int, userID int, code string) ([]string, error) {
+	twoFactor, err := store.GetTwoFactor(ctx, tenantID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.EnabledAt != nil {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	err = checkSecondFactor(ctx, store, twoFactor, code, "")
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.EnableTwoFactor(ctx, tenantID, userID, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkSecondFactor accepts either a current TOTP code, each usable once,
+// or one of the user's unused recovery codes.
+func checkSecondFactor(ctx context.Context, store domain.TwoFactorStore, twoFactor domain.TwoFactor, code string, recoveryCode string) error {
+	switch {
+	case code != "":
+		step, ok := ValidateTOTP(twoFactor.Secret, code, time.Now())
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		err := store.UseTwoFactorStep(ctx, twoFactor.TenantID, twoFactor.UserID, step)
+		if errors.Is(err, domain.ErrTwoFactorCodeReused) {
+			return ErrInvalidTwoFactorCode
+		}
+		return err
+	case recoveryCode != "":
+		err := store.ConsumeRecoveryCode(ctx, twoFactor.TenantID, twoFactor.UserID, HashRecoveryCode(recoveryCode))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+	return ErrInvalidTwoFactorCode
+}
+
+func twoFactorError(e *appError, err error) *appError {
+	switch {
+	case errors.Is(err, ErrInvalidTwoFactorCode):
+		return e.withContext(err, ErrMsgInvalidTwoFactorCode, ErrStatusBadRequest)
+	case errors.Is(err, ErrTwoFactorNotEnrolled):
+		return e.withContext(err, ErrMsgTwoFactorNotEnrolled, ErrStatusBadRequest)
+	case errors.Is(err, ErrTwoFactorEnabled):
+		return e.withContext(err, ErrMsgTwoFactorEnabled, ErrStatusConflict)
+	}
+	return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+}
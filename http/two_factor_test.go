@@ -0,0 +1,164 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/emanuelquerty/gymulty/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrollTwoFactor(t *testing.T) {
+	t.Run("returns a new secret with its provisioning uri", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return domain.User{ID: userID, TenantID: tenantID, Email: "pgray@email.com"}, nil
+		}
+		store.GetTwoFactorFn = func(ctx context.Context, tenantID int, userID int) (domain.TwoFactor, error) {
+			return domain.TwoFactor{}, sql.ErrNoRows
+		}
+		var saved string
+		store.SaveTwoFactorSecretFn = func(ctx context.Context, tenantID int, userID int, secret string) error {
+			saved = secret
+			return nil
+		}
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/me/two-factor", nil)
+		res := newTwoFactorRequest(store, req)
+
+		var got Response[TwoFactorEnrollmentResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.Equal(t, saved, got.Data.Secret, "secrets should be equal")
+		assert.Equal(t, TOTPProvisioningURI(saved, "pgray@email.com"), got.Data.ProvisioningURI, "uris should be equal")
+	})
+
+	t.Run("returns 409 status code when already enabled", func(t *testing.T) {
+		enabledAt := time.Now()
+		store := new(mock.Store)
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return domain.User{ID: userID, TenantID: tenantID}, nil
+		}
+		store.GetTwoFactorFn = func(ctx context.Context, tenantID int, userID int) (domain.TwoFactor, error) {
+			return domain.TwoFactor{EnabledAt: &enabledAt}, nil
+		}
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/me/two-factor", nil)
+		res := newTwoFactorRequest(store, req)
+
+		assert.Equal(t, 409, res.Code, "status codes should be equal")
+	})
+}
+
+func TestConfirmTwoFactor(t *testing.T) {
+	pending := func(ctx context.Context, tenantID int, userID int) (domain.TwoFactor, error) {
+		return domain.TwoFactor{TenantID: tenantID, UserID: userID, Secret: rfc6238Secret}, nil
+	}
+
+	t.Run("enables two factor and returns recovery codes", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetTwoFactorFn = pending
+		store.UseTwoFactorStepFn = func(ctx context.Context, tenantID int, userID int, step int64) error {
+			return nil
+		}
+		var stored []string
+		store.EnableTwoFactorFn = func(ctx context.Context, tenantID int, userID int, recoveryCodeHashes []string) error {
+			stored = recoveryCodeHashes
+			return nil
+		}
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/me/two-factor/confirm", strings.NewReader(`{"code":"`+currentTOTP(rfc6238Secret)+`"}`))
+		res := newTwoFactorRequest(store, req)
+
+		var got Response[RecoveryCodesResponse]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Len(t, got.Data.RecoveryCodes, recoveryCodeCount, "recovery codes should be returned")
+		assert.Equal(t, HashRecoveryCode(got.Data.RecoveryCodes[0]), stored[0], "only hashes should be stored")
+	})
+
+	t.Run("returns 400 status code on wrong code", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetTwoFactorFn = pending
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/me/two-factor/confirm", strings.NewReader(`{"code":"000000x"}`))
+		res := newTwoFactorRequest(store, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("returns 400 status code for a reused code", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetTwoFactorFn = pending
+		store.UseTwoFactorStepFn = func(ctx context.Context, tenantID int, userID int, step int64) error {
+			return domain.ErrTwoFactorCodeReused
+		}
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/me/two-factor/confirm", strings.NewReader(`{"code":"`+currentTOTP(rfc6238Secret)+`"}`))
+		res := newTwoFactorRequest(store, req)
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	enabledAt := time.Now()
+	enabled := func(ctx context.Context, tenantID int, userID int) (domain.TwoFactor, error) {
+		return domain.TwoFactor{TenantID: tenantID, UserID: userID, Secret: rfc6238Secret, EnabledAt: &enabledAt}, nil
+	}
+
+	t.Run("disables two factor with a recovery code", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{ID: tenantID}, nil
+		}
+		store.GetTwoFactorFn = enabled
+		store.ConsumeRecoveryCodeFn = func(ctx context.Context, tenantID int, userID int, codeHash string) error {
+			assert.Equal(t, HashRecoveryCode("abcd-efgh"), codeHash, "code hashes should be equal")
+			return nil
+		}
+		var disabled bool
+		store.DisableTwoFactorFn = func(ctx context.Context, tenantID int, userID int) error {
+			disabled = true
+			return nil
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/me/two-factor", strings.NewReader(`{"recovery_code":"ABCD-EFGH"}`))
+		res := newTwoFactorRequest(store, req)
+
+		assert.Equal(t, 204, res.Code, "status codes should be equal")
+		assert.True(t, disabled, "two factor should be disabled")
+	})
+
+	t.Run("returns 403 status code for admins when the tenant requires it", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetTenantByIDFn = func(ctx context.Context, tenantID int) (domain.Tenant, error) {
+			return domain.Tenant{ID: tenantID, RequireAdminTwoFactor: true}, nil
+		}
+
+		req := httptest.NewRequest("DELETE", "/api/tenants/1/me/two-factor", strings.NewReader(`{"code":"`+currentTOTP(rfc6238Secret)+`"}`))
+		res := newTwoFactorRequest(store, req)
+
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+	})
+}
+
+func currentTOTP(secret string) string {
+	code, _ := totpCode(secret, time.Now().Unix()/totpPeriod)
+	return code
+}
+
+func newTwoFactorRequest(store *mock.Store, req *http.Request) *httptest.ResponseRecorder {
+	handler := NewTwoFactorHandler(slog.Default(), store)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, withDefaultPrincipal(req))
+	return res
+}
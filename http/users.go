@@ -6,35 +6,54 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/emanuelquerty/gymulty/domain"
 	"github.com/emanuelquerty/gymulty/http/middleware"
 )
 
+// passwordSetupTTL is how long users created by an admin have to pick their
+// password, as long as an invitation lasts.
+const passwordSetupTTL = invitationTTL
+
 type UserHandler struct {
 	store domain.Store
 	http.Handler
-	logger *slog.Logger
+	verifier *EmailVerifier
+	quotas   *Quotas
+	mailer   domain.Mailer
+	appURL   string
+	logger   *slog.Logger
 }
 
-func NewUserHandler(logger *slog.Logger, store domain.Store) *UserHandler {
+func NewUserHandler(logger *slog.Logger, store domain.Store, verifier *EmailVerifier, quotas *Quotas, mailer domain.Mailer, appURL string) *UserHandler {
 	router := http.NewServeMux()
 	userHandler := &UserHandler{
-		store:   store,
-		Handler: middleware.StripSlashes(router),
-		logger:  logger,
+		store:    store,
+		Handler:  middleware.StripSlashes(router),
+		verifier: verifier,
+		quotas:   quotas,
+		mailer:   mailer,
+		appURL:   appURL,
+		logger:   logger,
 	}
 	userHandler.registerRoutes(router)
 	return userHandler
 }
 
 func (u *UserHandler) registerRoutes(router *http.ServeMux) {
-	router.Handle("GET /api/tenants/{tenantID}/users/{userID}", errorHandler(u.getUserByID))
-	router.Handle("POST /api/tenants/{tenantID}/users", errorHandler(u.createUser))
-
-	router.Handle("PUT /api/tenants/{tenantID}/users/{userID}", errorHandler(u.updateUser))
-	router.Handle("DELETE /api/tenants/{tenantID}/users/{userID}", errorHandler(u.deleteUserByID))
-	router.Handle("GET /api/tenants/{tenantID}/users", errorHandler(u.getAllUsers))
+	readUser := AnyOf(Allow(domain.PermUsersRead), Self("userID"))
+	writeUser := AnyOf(Allow(domain.PermUsersWrite), Self("userID"))
+
+	router.Handle("GET /api/tenants/{tenantID}/users/{userID}", authorize(u.logger, readUser, u.getUserByID))
+	router.Handle("POST /api/tenants/{tenantID}/users", authorize(u.logger, Allow(domain.PermUsersWrite), verifiedTenant(u.logger, u.createUser)))
+
+	router.Handle("PUT /api/tenants/{tenantID}/users/{userID}", authorize(u.logger, writeUser, u.updateUser))
+	router.Handle("DELETE /api/tenants/{tenantID}/users/{userID}", authorize(u.logger, Allow(domain.PermUsersWrite), u.deleteUserByID))
+	router.Handle("GET /api/tenants/{tenantID}/users", authorize(u.logger, Allow(domain.PermUsersRead), u.getAllUsers))
+	router.Handle("POST /api/tenants/{tenantID}/users/{userID}/unlock", authorize(u.logger, Allow(domain.PermUsersWrite), u.unlockUser))
+	router.Handle("GET /api/tenants/{tenantID}/users/{userID}/locations", authorize(u.logger, readUser, u.getUserLocations))
+	router.Handle("PUT /api/tenants/{tenantID}/users/{userID}/locations", authorize(u.logger, Allow(domain.PermUsersWrite), u.setUserLocations))
 }
 
 func (u *UserHandler) getUserByID(w http.ResponseWriter, r *http.Request) *appError {
@@ -66,6 +85,8 @@ func (u *UserHandler) getUserByID(w http.ResponseWriter, r *http.Request) *appEr
 	return nil
 }
 
+// createUser adds a user to the tenant. Any password in the request is
+// ignored, the user is emailed a link to set their own instead.
 func (u *UserHandler) createUser(w http.ResponseWriter, r *http.Request) *appError {
 	e := &appError{Logger: u.logger}
 
@@ -76,17 +97,54 @@ func (u *UserHandler) createUser(w http.ResponseWriter, r *http.Request) *appErr
 
 	var user domain.User
 	json.NewDecoder(r.Body).Decode(&user)
+	user.VerifiedAt = nil
+
+	p, _ := PrincipalFromContext(r.Context())
+	if appErr := assignableRole(r.Context(), u.store, e, p, tenantID, user.Role); appErr != nil {
+		return appErr
+	}
 
-	user.Password, err = HashPassword(user.Password)
+	// nobody but the user gets to know their password: it is unusable until
+	// they pick their own through the link they are emailed
+	unusable, _, err := NewOpaqueToken()
 	if err != nil {
 		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
 	}
-
-	newUser, err := u.store.CreateUser(r.Context(), tenantID, user)
+	user.Password, err = HashPassword(unusable)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	token, tokenHash, err := NewOpaqueToken()
 	if err != nil {
 		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
 	}
 
+	var newUser domain.User
+	err = u.store.WithTx(r.Context(), func(tx domain.Store) error {
+		err := u.quotas.CheckRole(r.Context(), tx, tenantID, user.Role, 1)
+		if err != nil {
+			return err
+		}
+		newUser, err = tx.CreateUser(r.Context(), tenantID, user)
+		if err != nil {
+			return err
+		}
+		return tx.CreatePasswordResetToken(r.Context(), tenantID, newUser.ID, tokenHash, time.Now().Add(passwordSetupTTL))
+	})
+	if err != nil {
+		return quotaError(e, err, ErrMsgMemberQuotaExceeded)
+	}
+
+	// the user exists either way, they can ask for new links if these are lost
+	err = u.mailer.Send(r.Context(), passwordSetupEmail(u.appURL, newUser, token))
+	if err != nil {
+		u.logger.Error("sending password setup email", slog.String("error", err.Error()))
+	}
+	err = u.verifier.Send(r.Context(), newUser)
+	if err != nil {
+		u.logger.Error("sending verification email", slog.String("error", err.Error()))
+	}
+
 	res := Response[[]domain.PublicUser]{
 		Count: 1,
 		Data: []domain.PublicUser{
@@ -116,7 +174,35 @@ func (u *UserHandler) updateUser(w http.ResponseWriter, r *http.Request) *appErr
 	var update domain.UserUpdate
 	json.NewDecoder(r.Body).Decode(&update)
 
+	p, _ := PrincipalFromContext(r.Context())
+	if appErr := u.manageableUser(r, e, p, tenantID, userID); appErr != nil {
+		return appErr
+	}
+	if update.Role != nil {
+		if userID == p.UserID {
+			return e.withContext(ErrOwnRole, ErrMsgOwnRole, ErrStatusForbidden)
+		}
+		if appErr := assignableRole(r.Context(), u.store, e, p, tenantID, *update.Role); appErr != nil {
+			return appErr
+		}
+	}
+
+	self := p.APIKeyID == 0 && userID == p.UserID
 	if update.Password != nil {
+		if len(*update.Password) < minPasswordLength {
+			return e.withContext(ErrWeakPassword, ErrMsgWeakPassword, ErrStatusBadRequest)
+		}
+		// a stolen access token alone must not be enough to take over the account
+		if self {
+			current, err := u.store.GetUserByID(r.Context(), tenantID, userID)
+			if err != nil {
+				return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+			}
+			if update.CurrentPassword == nil || !CheckPassword(current.Password, *update.CurrentPassword) {
+				return e.withContext(ErrWrongPassword, ErrMsgWrongPassword, ErrStatusForbidden)
+			}
+		}
+
 		hash, err := HashPassword(*update.Password)
 		if err != nil {
 			return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
@@ -124,9 +210,41 @@ func (u *UserHandler) updateUser(w http.ResponseWriter, r *http.Request) *appErr
 		update.Password = &hash
 	}
 
-	user, err := u.store.UpdateUser(r.Context(), tenantID, userID, update)
+	var user domain.User
+	err = u.store.WithTx(r.Context(), func(tx domain.Store) error {
+		if update.Role != nil {
+			current, err := tx.GetUserByID(r.Context(), tenantID, userID)
+			if err != nil {
+				return err
+			}
+			err = u.quotas.CheckRoleChange(r.Context(), tx, tenantID, current.Role, *update.Role)
+			if err != nil {
+				return err
+			}
+		}
+
+		user, err = tx.UpdateUser(r.Context(), tenantID, userID, update)
+		if err != nil || update.Password == nil {
+			return err
+		}
+
+		// whoever knew the old password must not stay logged in, though
+		// users changing their own keep the session they changed it from
+		if self {
+			return tx.RevokeOtherSessions(r.Context(), tenantID, userID, p.SessionID)
+		}
+		return tx.RevokeAllSessions(r.Context(), tenantID, userID)
+	})
 	if err != nil {
-		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+		return quotaError(e, err, ErrMsgMemberQuotaExceeded)
+	}
+
+	// changing the email clears its verification, the new address needs confirming
+	if update.Email != nil && user.VerifiedAt == nil {
+		err = u.verifier.Send(r.Context(), user)
+		if err != nil {
+			u.logger.Error("sending verification email", slog.String("error", err.Error()))
+		}
 	}
 
 	res := Response[[]domain.PublicUser]{
@@ -152,6 +270,11 @@ func (u *UserHandler) deleteUserByID(w http.ResponseWriter, r *http.Request) *ap
 		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
 	}
 
+	p, _ := PrincipalFromContext(r.Context())
+	if appErr := u.manageableUser(r, e, p, tenantID, userID); appErr != nil {
+		return appErr
+	}
+
 	err = u.store.DeleteUserByID(r.Context(), tenantID, userID)
 	if err != nil {
 		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
@@ -166,6 +289,34 @@ func (u *UserHandler) deleteUserByID(w http.ResponseWriter, r *http.Request) *ap
 	return nil
 }
 
+// unlockUser lifts the lockout of a user's account after failed logins.
+// Lockouts of the IP addresses involved are left to expire.
+func (u *UserHandler) unlockUser(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: u.logger}
+	userID, err := strconv.Atoi(r.PathValue("userID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	user, err := u.store.GetUserByID(r.Context(), tenantID, userID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	err = NewLockout(u.store).Record(r.Context(), tenantID, user.Email, clientIP(r), domain.LoginUnlocked)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 func (u *UserHandler) getAllUsers(w http.ResponseWriter, r *http.Request) *appError {
 	e := &appError{Logger: u.logger}
 	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
@@ -173,7 +324,17 @@ func (u *UserHandler) getAllUsers(w http.ResponseWriter, r *http.Request) *appEr
 		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
 	}
 
-	users, err := u.store.GetAllUsers(r.Context(), tenantID)
+	locationID, appErr := locationFilter(r, e)
+	if appErr != nil {
+		return appErr
+	}
+
+	var users []domain.User
+	if locationID != 0 {
+		users, err = u.store.GetUsersByLocation(r.Context(), tenantID, locationID)
+	} else {
+		users, err = u.store.GetAllUsers(r.Context(), tenantID)
+	}
 	if err != nil {
 		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
 	}
@@ -189,15 +350,84 @@ func (u *UserHandler) getAllUsers(w http.ResponseWriter, r *http.Request) *appEr
 	return nil
 }
 
+func (u *UserHandler) getUserLocations(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: u.logger}
+	userID, err := strconv.Atoi(r.PathValue("userID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	locations, err := u.store.GetUserLocations(r.Context(), tenantID, userID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+
+	writeLocations(w, locations)
+	return nil
+}
+
+// setUserLocations replaces the locations a member trains at or a trainer works at.
+func (u *UserHandler) setUserLocations(w http.ResponseWriter, r *http.Request) *appError {
+	e := &appError{Logger: u.logger}
+	userID, err := strconv.Atoi(r.PathValue("userID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	tenantID, err := strconv.Atoi(r.PathValue("tenantID"))
+	if err != nil {
+		return e.withContext(err, ErrMsgInvalidResourceID, ErrStatusBadRequest)
+	}
+
+	var body domain.LocationsRequestBody
+	json.NewDecoder(r.Body).Decode(&body)
+
+	locations, err := u.store.SetUserLocations(r.Context(), tenantID, userID, body.LocationIDs)
+	if err != nil {
+		return locationsError(e, err)
+	}
+
+	writeLocations(w, locations)
+	return nil
+}
+
+// manageableUser checks that p may change or delete another user, whose
+// role must be one p could assign. Staff holding users:write can then look
+// after members but not take over the accounts of admins.
+func (u *UserHandler) manageableUser(r *http.Request, e *appError, p Principal, tenantID int, userID int) *appError {
+	if p.Can(domain.PermAll) || userID == p.UserID {
+		return nil
+	}
+
+	user, err := u.store.GetUserByID(r.Context(), tenantID, userID)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	role, err := u.store.GetRoleByName(r.Context(), tenantID, user.Role)
+	if err != nil {
+		return e.withContext(err, ErrMsgInternal, ErrStatusInternal)
+	}
+	if !canGrant(p, role.Permissions) {
+		return e.withContext(ErrPermissionDenied, ErrMsgPermissionDenied, ErrStatusForbidden)
+	}
+	return nil
+}
+
 func MapToPublicUser(user domain.User) domain.PublicUser {
 	return domain.PublicUser{
-		ID:        user.ID,
-		TenantID:  user.TenantID,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Role:      user.Role,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:         user.ID,
+		TenantID:   user.TenantID,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		Role:       user.Role,
+		VerifiedAt: user.VerifiedAt,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
 	}
 }
 
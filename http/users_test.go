@@ -8,7 +8,9 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/emanuelquerty/gymulty/domain"
 	"github.com/emanuelquerty/gymulty/mock"
@@ -135,7 +137,7 @@ func TestCreateUser(t *testing.T) {
 	}
 
 	t.Run("returns 201 status code", func(t *testing.T) {
-		store := new(mock.Store)
+		store := newCreateUserStore()
 		store.CreateUserFn = func(ctx context.Context, tenantID int, user domain.User) (domain.User, error) {
 			return domain.User{}, nil
 		}
@@ -150,7 +152,7 @@ func TestCreateUser(t *testing.T) {
 	})
 
 	t.Run("returns newly created user", func(t *testing.T) {
-		store := new(mock.Store)
+		store := newCreateUserStore()
 		store.CreateUserFn = func(ctx context.Context, tenantID int, user domain.User) (domain.User, error) {
 			return user, nil
 		}
@@ -181,8 +183,41 @@ func TestCreateUser(t *testing.T) {
 		assert.Equal(t, want, got, "users should be equal")
 	})
 
+	t.Run("emails a link to set a password instead of taking one", func(t *testing.T) {
+		var stored domain.User
+		var tokenHash string
+		store := newCreateUserStore()
+		store.CreateUserFn = func(ctx context.Context, tenantID int, user domain.User) (domain.User, error) {
+			stored = user
+			return user, nil
+		}
+		store.CreatePasswordResetTokenFn = func(ctx context.Context, tenantID int, userID int, hash string, expiresAt time.Time) error {
+			tokenHash = hash
+			assert.WithinDuration(t, time.Now().Add(passwordSetupTTL), expiresAt, time.Minute, "link should last as long as an invitation")
+			return nil
+		}
+		var sent []domain.Email
+		mailer := new(mock.Mailer)
+		mailer.SendFn = func(ctx context.Context, email domain.Email) error {
+			sent = append(sent, email)
+			return nil
+		}
+
+		body, _ := json.Marshal(user)
+		req := httptest.NewRequest(http.MethodPost, "/api/tenants/1/users", bytes.NewBuffer(body))
+		handler := NewUserHandler(slog.Default(), store, newTestVerifier(mailer), unlimitedQuotas(), mailer, "https://app.gymulty.test")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, withDefaultPrincipal(req))
+
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.False(t, CheckPassword(stored.Password, user.Password), "given password should be ignored")
+		assert.NotEmpty(t, tokenHash, "a password setup token should be stored")
+		assert.Len(t, sent, 2, "setup and verification links should be sent")
+		assert.Contains(t, sent[0].Body, "/reset-password?", "email should contain the setup link")
+	})
+
 	t.Run("returns location header with full resource uri", func(t *testing.T) {
-		store := new(mock.Store)
+		store := newCreateUserStore()
 		store.CreateUserFn = func(ctx context.Context, tenantID int, user domain.User) (domain.User, error) {
 			return user, nil
 		}
@@ -199,6 +234,14 @@ func TestCreateUser(t *testing.T) {
 	})
 }
 
+func newCreateUserStore() *mock.Store {
+	store := new(mock.Store)
+	store.CreatePasswordResetTokenFn = func(ctx context.Context, tenantID int, userID int, tokenHash string, expiresAt time.Time) error {
+		return nil
+	}
+	return store
+}
+
 func TestUpdateUserByID(t *testing.T) {
 	user := domain.User{
 		ID:        3,
@@ -218,6 +261,9 @@ func TestUpdateUserByID(t *testing.T) {
 			Role:      &newRole,
 		}
 		store := new(mock.Store)
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return user, nil
+		}
 		store.UpdateUserFn = func(ctx context.Context, tenantID int, userID int, update domain.UserUpdate) (domain.User, error) {
 			updatedUser := user
 			updatedUser.FirstName = *update.FirstName
@@ -250,6 +296,33 @@ func TestUpdateUserByID(t *testing.T) {
 		assert.Equal(t, want, got, "they should be equal")
 	})
 
+	t.Run("sends a verification link to a changed email", func(t *testing.T) {
+		store := new(mock.Store)
+		store.UpdateUserFn = func(ctx context.Context, tenantID int, userID int, update domain.UserUpdate) (domain.User, error) {
+			updatedUser := user
+			updatedUser.Email = *update.Email
+			return updatedUser, nil // the store cleared verified_at
+		}
+		var sent domain.Email
+		mailer := new(mock.Mailer)
+		mailer.SendFn = func(ctx context.Context, email domain.Email) error {
+			sent = email
+			return nil
+		}
+
+		req := httptest.NewRequest("PUT", "/api/tenants/1/users/3", strings.NewReader(`{"email":"johnny@email.com"}`))
+		handler := NewUserHandler(slog.Default(), store, newTestVerifier(mailer), unlimitedQuotas(), mailer, "https://app.gymulty.test")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, withDefaultPrincipal(req))
+
+		var got Response[[]domain.PublicUser]
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Nil(t, got.Data[0].VerifiedAt, "user should no longer be verified")
+		assert.Equal(t, "johnny@email.com", sent.To, "recipients should be equal")
+		assert.Contains(t, sent.Body, "/verify-email?", "email should contain the verification link")
+	})
+
 	t.Run("returns 400 status code for invalid id", func(t *testing.T) {
 		store := new(mock.Store)
 		store.UpdateUserFn = func(ctx context.Context, tenantID int, userID int, update domain.UserUpdate) (domain.User, error) {
@@ -276,6 +349,86 @@ func TestUpdateUserByID(t *testing.T) {
 		assert.Equal(t, want, got, "status codes should be equal")
 	})
 
+	t.Run("changes the password of the user themself and keeps only the current session", func(t *testing.T) {
+		hash, _ := HashPassword("ReallySecret1001")
+		store := new(mock.Store)
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return domain.User{ID: userID, TenantID: tenantID, Password: hash, Role: "member"}, nil
+		}
+		var updated domain.UserUpdate
+		store.UpdateUserFn = func(ctx context.Context, tenantID int, userID int, update domain.UserUpdate) (domain.User, error) {
+			updated = update
+			return user, nil
+		}
+		var keptSessionID int
+		store.RevokeOtherSessionsFn = func(ctx context.Context, tenantID int, userID int, sessionID int) error {
+			keptSessionID = sessionID
+			return nil
+		}
+
+		p := testPrincipal(3, "member")
+		p.SessionID = 12
+		body := `{"password":"EvenMoreSecret2002","current_password":"ReallySecret1001"}`
+		req := asPrincipal(httptest.NewRequest("PUT", "/api/tenants/1/users/3", strings.NewReader(body)), p)
+		res := newUserRequest(store, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.True(t, CheckPassword(*updated.Password, "EvenMoreSecret2002"), "new password should be stored hashed")
+		assert.Equal(t, 12, keptSessionID, "current session should be kept")
+	})
+
+	t.Run("returns 403 status code when the user themself gives a wrong or no current password", func(t *testing.T) {
+		hash, _ := HashPassword("ReallySecret1001")
+		store := new(mock.Store)
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return domain.User{ID: userID, TenantID: tenantID, Password: hash, Role: "member"}, nil
+		}
+
+		for _, body := range []string{
+			`{"password":"EvenMoreSecret2002"}`,
+			`{"password":"EvenMoreSecret2002","current_password":"NotTheSecret"}`,
+		} {
+			req := asPrincipal(httptest.NewRequest("PUT", "/api/tenants/1/users/3", strings.NewReader(body)), testPrincipal(3, "member"))
+			res := newUserRequest(store, req)
+
+			var got appError
+			json.NewDecoder(res.Body).Decode(&got)
+			assert.Equal(t, 403, res.Code, "status codes should be equal")
+			assert.Equal(t, ErrMsgWrongPassword, got.Message, "messages should be equal")
+		}
+	})
+
+	t.Run("returns 400 status code for a password that is too short", func(t *testing.T) {
+		store := new(mock.Store)
+
+		body := `{"password":"short","current_password":"ReallySecret1001"}`
+		req := asPrincipal(httptest.NewRequest("PUT", "/api/tenants/1/users/3", strings.NewReader(body)), testPrincipal(3, "member"))
+		res := newUserRequest(store, req)
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgWeakPassword, got.Message, "messages should be equal")
+	})
+
+	t.Run("logs a user out everywhere when an admin changes their password", func(t *testing.T) {
+		store := new(mock.Store)
+		store.UpdateUserFn = func(ctx context.Context, tenantID int, userID int, update domain.UserUpdate) (domain.User, error) {
+			return user, nil
+		}
+		var revokedUserID int
+		store.RevokeAllSessionsFn = func(ctx context.Context, tenantID int, userID int) error {
+			revokedUserID = userID
+			return nil
+		}
+
+		req := httptest.NewRequest("PUT", "/api/tenants/1/users/3", strings.NewReader(`{"password":"EvenMoreSecret2002"}`))
+		res := newUserRequest(store, req)
+
+		assert.Equal(t, 200, res.Code, "status codes should be equal")
+		assert.Equal(t, 3, revokedUserID, "sessions of the user should be revoked")
+	})
+
 }
 
 func TestDeleteUserByID(t *testing.T) {
@@ -372,9 +525,139 @@ func TestGetAllUsers(t *testing.T) {
 	})
 }
 
+func TestUnlockUser(t *testing.T) {
+	t.Run("records an unlock for the user's email", func(t *testing.T) {
+		store := new(mock.Store)
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			return domain.User{ID: userID, TenantID: tenantID, Email: "PGray@email.com"}, nil
+		}
+		var recorded domain.LoginAttempt
+		store.RecordLoginAttemptFn = func(ctx context.Context, tenantID int, attempt domain.LoginAttempt) error {
+			recorded = attempt
+			return nil
+		}
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/users/4/unlock", nil)
+		res := newUserRequest(store, req)
+
+		assert.Equal(t, 204, res.Code, "status codes should be equal")
+		assert.Equal(t, "pgray@email.com", recorded.Email, "emails should be equal")
+		assert.Equal(t, domain.LoginUnlocked, recorded.Outcome, "outcomes should be equal")
+	})
+
+	t.Run("member cannot unlock users", func(t *testing.T) {
+		store := new(mock.Store)
+		req := httptest.NewRequest("POST", "/api/tenants/1/users/4/unlock", nil)
+		res := newUserRequest(store, asPrincipal(req, testPrincipal(7, domain.RoleMember)))
+
+		assertPermissionDenied(t, res)
+	})
+}
+
+func TestAssignRole(t *testing.T) {
+	frontDesk := Principal{UserID: 5, TenantID: 1, Role: "front_desk", Permissions: []string{domain.PermUsersRead, domain.PermUsersWrite, domain.PermClassesRead}}
+
+	newRoleStore := func(created *domain.User, updated *domain.UserUpdate) *mock.Store {
+		store := newCreateUserStore()
+		store.GetRoleByNameFn = func(ctx context.Context, tenantID int, name string) (domain.Role, error) {
+			if name == "front_desk" {
+				return domain.Role{Name: name, Permissions: frontDesk.Permissions}, nil
+			}
+			perms, ok := domain.DefaultRolePermissions[name]
+			if !ok {
+				return domain.Role{}, sql.ErrNoRows
+			}
+			return domain.Role{Name: name, Permissions: perms}, nil
+		}
+		store.GetUserByIDFn = func(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+			if userID == 1 {
+				return domain.User{ID: userID, Role: domain.RoleAdmin}, nil
+			}
+			return domain.User{ID: userID, Role: domain.RoleMember}, nil
+		}
+		store.CreateUserFn = func(ctx context.Context, tenantID int, user domain.User) (domain.User, error) {
+			*created = user
+			return user, nil
+		}
+		store.UpdateUserFn = func(ctx context.Context, tenantID int, userID int, update domain.UserUpdate) (domain.User, error) {
+			*updated = update
+			return domain.User{ID: userID}, nil
+		}
+		return store
+	}
+
+	t.Run("staff can add members", func(t *testing.T) {
+		var created domain.User
+		store := newRoleStore(&created, nil)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/users", strings.NewReader(`{"email":"ann@gym.com","role":"member"}`))
+		res := newUserRequest(store, asPrincipal(req, frontDesk))
+
+		assert.Equal(t, 201, res.Code, "status codes should be equal")
+		assert.Equal(t, domain.RoleMember, created.Role, "roles should be equal")
+	})
+
+	t.Run("staff cannot add admins", func(t *testing.T) {
+		var created domain.User
+		store := newRoleStore(&created, nil)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/users", strings.NewReader(`{"email":"ann@gym.com","role":"admin"}`))
+		res := newUserRequest(store, asPrincipal(req, frontDesk))
+
+		assertPermissionDenied(t, res)
+		assert.Empty(t, created.Email, "user should not be created")
+	})
+
+	t.Run("returns 400 status code for an unknown role", func(t *testing.T) {
+		var created domain.User
+		store := newRoleStore(&created, nil)
+
+		req := httptest.NewRequest("POST", "/api/tenants/1/users", strings.NewReader(`{"email":"ann@gym.com","role":"owner"}`))
+		res := newUserRequest(store, asPrincipal(req, frontDesk))
+
+		assert.Equal(t, 400, res.Code, "status codes should be equal")
+	})
+
+	t.Run("staff cannot promote members to admin", func(t *testing.T) {
+		var updated domain.UserUpdate
+		store := newRoleStore(nil, &updated)
+
+		req := httptest.NewRequest("PUT", "/api/tenants/1/users/8", strings.NewReader(`{"role":"admin"}`))
+		res := newUserRequest(store, asPrincipal(req, frontDesk))
+
+		assertPermissionDenied(t, res)
+		assert.Nil(t, updated.Role, "role should not be updated")
+	})
+
+	t.Run("users cannot change their own role", func(t *testing.T) {
+		var updated domain.UserUpdate
+		store := newRoleStore(nil, &updated)
+
+		req := httptest.NewRequest("PUT", "/api/tenants/1/users/5", strings.NewReader(`{"role":"member"}`))
+		res := newUserRequest(store, asPrincipal(req, frontDesk))
+
+		var got appError
+		json.NewDecoder(res.Body).Decode(&got)
+		assert.Equal(t, 403, res.Code, "status codes should be equal")
+		assert.Equal(t, ErrMsgOwnRole, got.Message, "messages should be equal")
+		assert.Nil(t, updated.Role, "role should not be updated")
+	})
+
+	t.Run("staff cannot change the password of an admin", func(t *testing.T) {
+		var updated domain.UserUpdate
+		store := newRoleStore(nil, &updated)
+
+		req := httptest.NewRequest("PUT", "/api/tenants/1/users/1", strings.NewReader(`{"password":"NewPassword123"}`))
+		res := newUserRequest(store, asPrincipal(req, frontDesk))
+
+		assertPermissionDenied(t, res)
+		assert.Nil(t, updated.Password, "password should not be updated")
+	})
+}
+
 func newUserRequest(store *mock.Store, req *http.Request) *httptest.ResponseRecorder {
-	userHandler := NewUserHandler(slog.Default(), store)
+	userHandler := NewUserHandler(slog.Default(), store, newTestVerifier(discardMailer()), unlimitedQuotas(), discardMailer(), "https://app.gymulty.test")
 	res := httptest.NewRecorder()
-	userHandler.ServeHTTP(res, req)
+	userHandler.ServeHTTP(res, withDefaultPrincipal(req))
 	return res
 }
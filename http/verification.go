@@ -0,0 +1,39 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+const (
+	purposeEmailVerification = "email_verification"
+	emailVerificationTTL     = 48 * time.Hour
+)
+
+// EmailVerifier sends signed email verification links and checks the
+// tokens they carry. No state is stored until the link is followed.
+type EmailVerifier struct {
+	tokens *TokenManager
+	mailer domain.Mailer
+	appURL string
+}
+
+func NewEmailVerifier(tokens *TokenManager, mailer domain.Mailer, appURL string) *EmailVerifier {
+	return &EmailVerifier{tokens: tokens, mailer: mailer, appURL: appURL}
+}
+
+func (v *EmailVerifier) Send(ctx context.Context, user domain.User) error {
+	token, err := v.tokens.IssueFor(purposeEmailVerification, user, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return v.mailer.Send(ctx, emailVerificationEmail(v.appURL, user, token))
+}
+
+// Verify returns the claims of a valid verification token. Callers must
+// check that claims.Email still is the user's email address.
+func (v *EmailVerifier) Verify(token string) (Claims, error) {
+	return v.tokens.VerifyFor(purposeEmailVerification, token)
+}
@@ -0,0 +1,27 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/emanuelquerty/gymulty/config"
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+// New returns the Mailer selected by conf.Driver.
+func New(conf config.Mail) (domain.Mailer, error) {
+	switch conf.Driver {
+	case "", "stdout":
+		return NewWriterMailer(os.Stdout, conf.From), nil
+	case "file":
+		f, err := os.OpenFile(conf.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("error opening mail file: %w", err)
+		}
+		return NewWriterMailer(f, conf.From), nil
+	case "smtp":
+		return NewSMTPMailer(conf), nil
+	default:
+		return nil, fmt.Errorf("mail: unknown driver %q", conf.Driver)
+	}
+}
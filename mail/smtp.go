@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/emanuelquerty/gymulty/config"
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.Mailer = (*SMTPMailer)(nil)
+
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(conf config.Mail) *SMTPMailer {
+	return &SMTPMailer{
+		addr: net.JoinHostPort(conf.Host, conf.Port),
+		auth: smtp.PlainAuth("", conf.Username, conf.Password, conf.Host),
+		from: conf.From,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, email domain.Email) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", headerValue(email.To))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", headerValue(email.Subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	msg.WriteString(email.Body)
+
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{email.To}, []byte(msg.String()))
+}
+
+// headerValue strips line breaks so user supplied values cannot inject headers.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.Mailer = (*WriterMailer)(nil)
+
+// WriterMailer writes every email to w instead of delivering it.
+// It is meant for local development, where w is stdout or a file.
+type WriterMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+func NewWriterMailer(w io.Writer, from string) *WriterMailer {
+	return &WriterMailer{w: w, from: from}
+}
+
+func (m *WriterMailer) Send(ctx context.Context, email domain.Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "From: %s\nTo: %s\nDate: %s\nSubject: %s\n\n%s\n\n",
+		m.from, email.To, time.Now().Format(time.RFC1123Z), email.Subject, email.Body)
+	return err
+}
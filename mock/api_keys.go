@@ -0,0 +1,37 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.APIKeyStore = (*APIKeyStore)(nil)
+
+type APIKeyStore struct {
+	CreateAPIKeyFn    func(ctx context.Context, tenantID int, key domain.APIKey) (domain.APIKey, error)
+	GetAPIKeyByHashFn func(ctx context.Context, keyHash string) (domain.APIKey, error)
+	GetAllAPIKeysFn   func(ctx context.Context, tenantID int) ([]domain.APIKey, error)
+	RevokeAPIKeyFn    func(ctx context.Context, tenantID int, keyID int) error
+	TouchAPIKeyFn     func(ctx context.Context, keyID int) error
+}
+
+func (a *APIKeyStore) CreateAPIKey(ctx context.Context, tenantID int, key domain.APIKey) (domain.APIKey, error) {
+	return a.CreateAPIKeyFn(ctx, tenantID, key)
+}
+
+func (a *APIKeyStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	return a.GetAPIKeyByHashFn(ctx, keyHash)
+}
+
+func (a *APIKeyStore) GetAllAPIKeys(ctx context.Context, tenantID int) ([]domain.APIKey, error) {
+	return a.GetAllAPIKeysFn(ctx, tenantID)
+}
+
+func (a *APIKeyStore) RevokeAPIKey(ctx context.Context, tenantID int, keyID int) error {
+	return a.RevokeAPIKeyFn(ctx, tenantID, keyID)
+}
+
+func (a *APIKeyStore) TouchAPIKey(ctx context.Context, keyID int) error {
+	return a.TouchAPIKeyFn(ctx, keyID)
+}
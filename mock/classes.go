@@ -13,6 +13,10 @@ type ClassStore struct {
 	GetClassByIDFn    func(ctx context.Context, tenantID int, classID int) (domain.Class, error)
 	DeleteClassByIDFn func(ctx context.Context, tenantID int, classID int) error
 	GetAllClassesFn   func(ctx context.Context, tenantID int) ([]domain.Class, error)
+
+	GetClassesByLocationFn func(ctx context.Context, tenantID int, locationID int) ([]domain.Class, error)
+	GetClassLocationsFn    func(ctx context.Context, tenantID int, classID int) ([]domain.Location, error)
+	SetClassLocationsFn    func(ctx context.Context, tenantID int, classID int, locationIDs []int) ([]domain.Location, error)
 }
 
 func (c *ClassStore) CreateClass(ctx context.Context, tenantID int, class domain.Class) (domain.Class, error) {
@@ -30,3 +34,15 @@ func (c *ClassStore) DeleteClassByID(ctx context.Context, tenantID int, classID
 func (c *ClassStore) GetAllClasses(ctx context.Context, tenantID int) ([]domain.Class, error) {
 	return c.GetAllClassesFn(ctx, tenantID)
 }
+
+func (c *ClassStore) GetClassesByLocation(ctx context.Context, tenantID int, locationID int) ([]domain.Class, error) {
+	return c.GetClassesByLocationFn(ctx, tenantID, locationID)
+}
+
+func (c *ClassStore) GetClassLocations(ctx context.Context, tenantID int, classID int) ([]domain.Location, error) {
+	return c.GetClassLocationsFn(ctx, tenantID, classID)
+}
+
+func (c *ClassStore) SetClassLocations(ctx context.Context, tenantID int, classID int, locationIDs []int) ([]domain.Location, error) {
+	return c.SetClassLocationsFn(ctx, tenantID, classID, locationIDs)
+}
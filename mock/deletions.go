@@ -0,0 +1,28 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.DeletionStore = (*DeletionStore)(nil)
+
+type DeletionStore struct {
+	GetTenantsDueForPurgeFn  func(ctx context.Context, now time.Time) ([]domain.Tenant, error)
+	PurgeTenantFn            func(ctx context.Context, tenantID int, now time.Time) (domain.DeletionCertificate, error)
+	GetDeletionCertificateFn func(ctx context.Context, tenantID int) (domain.DeletionCertificate, error)
+}
+
+func (d *DeletionStore) GetTenantsDueForPurge(ctx context.Context, now time.Time) ([]domain.Tenant, error) {
+	return d.GetTenantsDueForPurgeFn(ctx, now)
+}
+
+func (d *DeletionStore) PurgeTenant(ctx context.Context, tenantID int, now time.Time) (domain.DeletionCertificate, error) {
+	return d.PurgeTenantFn(ctx, tenantID, now)
+}
+
+func (d *DeletionStore) GetDeletionCertificate(ctx context.Context, tenantID int) (domain.DeletionCertificate, error) {
+	return d.GetDeletionCertificateFn(ctx, tenantID)
+}
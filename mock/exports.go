@@ -0,0 +1,38 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.ExportStore = (*ExportStore)(nil)
+
+type ExportStore struct {
+	CreateExportFn     func(ctx context.Context, tenantID int, export domain.Export) (domain.Export, error)
+	GetExportByIDFn    func(ctx context.Context, tenantID int, exportID int) (domain.Export, error)
+	SetExportStatusFn  func(ctx context.Context, tenantID int, exportID int, status string, expiresAt *time.Time) (domain.Export, error)
+	ExpireExportsFn    func(ctx context.Context, now time.Time) ([]domain.Export, error)
+	GetTenantRecordsFn func(ctx context.Context, tenantID int) (domain.TenantRecords, error)
+}
+
+func (e *ExportStore) CreateExport(ctx context.Context, tenantID int, export domain.Export) (domain.Export, error) {
+	return e.CreateExportFn(ctx, tenantID, export)
+}
+
+func (e *ExportStore) GetExportByID(ctx context.Context, tenantID int, exportID int) (domain.Export, error) {
+	return e.GetExportByIDFn(ctx, tenantID, exportID)
+}
+
+func (e *ExportStore) SetExportStatus(ctx context.Context, tenantID int, exportID int, status string, expiresAt *time.Time) (domain.Export, error) {
+	return e.SetExportStatusFn(ctx, tenantID, exportID, status, expiresAt)
+}
+
+func (e *ExportStore) ExpireExports(ctx context.Context, now time.Time) ([]domain.Export, error) {
+	return e.ExpireExportsFn(ctx, now)
+}
+
+func (e *ExportStore) GetTenantRecords(ctx context.Context, tenantID int) (domain.TenantRecords, error) {
+	return e.GetTenantRecordsFn(ctx, tenantID)
+}
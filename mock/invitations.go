@@ -0,0 +1,43 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.InvitationStore = (*InvitationStore)(nil)
+
+type InvitationStore struct {
+	CreateInvitationFn  func(ctx context.Context, tenantID int, invitation domain.Invitation) (domain.Invitation, error)
+	GetInvitationByIDFn func(ctx context.Context, tenantID int, invitationID int) (domain.Invitation, error)
+	GetAllInvitationsFn func(ctx context.Context, tenantID int) ([]domain.Invitation, error)
+	RenewInvitationFn   func(ctx context.Context, tenantID int, invitationID int, tokenHash string, expiresAt time.Time) (domain.Invitation, error)
+	RevokeInvitationFn  func(ctx context.Context, tenantID int, invitationID int) error
+	AcceptInvitationFn  func(ctx context.Context, tenantID int, tokenHash string, user domain.User) (domain.User, error)
+}
+
+func (i *InvitationStore) CreateInvitation(ctx context.Context, tenantID int, invitation domain.Invitation) (domain.Invitation, error) {
+	return i.CreateInvitationFn(ctx, tenantID, invitation)
+}
+
+func (i *InvitationStore) GetInvitationByID(ctx context.Context, tenantID int, invitationID int) (domain.Invitation, error) {
+	return i.GetInvitationByIDFn(ctx, tenantID, invitationID)
+}
+
+func (i *InvitationStore) GetAllInvitations(ctx context.Context, tenantID int) ([]domain.Invitation, error) {
+	return i.GetAllInvitationsFn(ctx, tenantID)
+}
+
+func (i *InvitationStore) RenewInvitation(ctx context.Context, tenantID int, invitationID int, tokenHash string, expiresAt time.Time) (domain.Invitation, error) {
+	return i.RenewInvitationFn(ctx, tenantID, invitationID, tokenHash, expiresAt)
+}
+
+func (i *InvitationStore) RevokeInvitation(ctx context.Context, tenantID int, invitationID int) error {
+	return i.RevokeInvitationFn(ctx, tenantID, invitationID)
+}
+
+func (i *InvitationStore) AcceptInvitation(ctx context.Context, tenantID int, tokenHash string, user domain.User) (domain.User, error) {
+	return i.AcceptInvitationFn(ctx, tenantID, tokenHash, user)
+}
@@ -0,0 +1,37 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.LocationStore = (*LocationStore)(nil)
+
+type LocationStore struct {
+	CreateLocationFn     func(ctx context.Context, tenantID int, location domain.Location) (domain.Location, error)
+	GetLocationByIDFn    func(ctx context.Context, tenantID int, locationID int) (domain.Location, error)
+	GetAllLocationsFn    func(ctx context.Context, tenantID int) ([]domain.Location, error)
+	UpdateLocationFn     func(ctx context.Context, tenantID int, locationID int, updates domain.LocationUpdate) (domain.Location, error)
+	DeleteLocationByIDFn func(ctx context.Context, tenantID int, locationID int) error
+}
+
+func (l *LocationStore) CreateLocation(ctx context.Context, tenantID int, location domain.Location) (domain.Location, error) {
+	return l.CreateLocationFn(ctx, tenantID, location)
+}
+
+func (l *LocationStore) GetLocationByID(ctx context.Context, tenantID int, locationID int) (domain.Location, error) {
+	return l.GetLocationByIDFn(ctx, tenantID, locationID)
+}
+
+func (l *LocationStore) GetAllLocations(ctx context.Context, tenantID int) ([]domain.Location, error) {
+	return l.GetAllLocationsFn(ctx, tenantID)
+}
+
+func (l *LocationStore) UpdateLocation(ctx context.Context, tenantID int, locationID int, updates domain.LocationUpdate) (domain.Location, error) {
+	return l.UpdateLocationFn(ctx, tenantID, locationID, updates)
+}
+
+func (l *LocationStore) DeleteLocationByID(ctx context.Context, tenantID int, locationID int) error {
+	return l.DeleteLocationByIDFn(ctx, tenantID, locationID)
+}
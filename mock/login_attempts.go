@@ -0,0 +1,33 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.LoginAttemptStore = (*LoginAttemptStore)(nil)
+
+type LoginAttemptStore struct {
+	RecordLoginAttemptFn   func(ctx context.Context, tenantID int, attempt domain.LoginAttempt) error
+	CountAccountFailuresFn func(ctx context.Context, tenantID int, email string, since time.Time) (domain.LoginFailures, error)
+	CountIPFailuresFn      func(ctx context.Context, ip string, since time.Time) (domain.LoginFailures, error)
+	GetLoginAttemptsFn     func(ctx context.Context, tenantID int, filter domain.LoginAttemptFilter) ([]domain.LoginAttempt, error)
+}
+
+func (l *LoginAttemptStore) RecordLoginAttempt(ctx context.Context, tenantID int, attempt domain.LoginAttempt) error {
+	return l.RecordLoginAttemptFn(ctx, tenantID, attempt)
+}
+
+func (l *LoginAttemptStore) CountAccountFailures(ctx context.Context, tenantID int, email string, since time.Time) (domain.LoginFailures, error) {
+	return l.CountAccountFailuresFn(ctx, tenantID, email, since)
+}
+
+func (l *LoginAttemptStore) CountIPFailures(ctx context.Context, ip string, since time.Time) (domain.LoginFailures, error) {
+	return l.CountIPFailuresFn(ctx, ip, since)
+}
+
+func (l *LoginAttemptStore) GetLoginAttempts(ctx context.Context, tenantID int, filter domain.LoginAttemptFilter) ([]domain.LoginAttempt, error) {
+	return l.GetLoginAttemptsFn(ctx, tenantID, filter)
+}
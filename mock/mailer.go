@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.Mailer = (*Mailer)(nil)
+
+type Mailer struct {
+	SendFn func(ctx context.Context, email domain.Email) error
+}
+
+func (m *Mailer) Send(ctx context.Context, email domain.Email) error {
+	return m.SendFn(ctx, email)
+}
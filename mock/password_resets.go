@@ -0,0 +1,23 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.PasswordResetStore = (*PasswordResetStore)(nil)
+
+type PasswordResetStore struct {
+	CreatePasswordResetTokenFn  func(ctx context.Context, tenantID int, userID int, tokenHash string, expiresAt time.Time) error
+	ConsumePasswordResetTokenFn func(ctx context.Context, tenantID int, tokenHash string) (int, error)
+}
+
+func (p *PasswordResetStore) CreatePasswordResetToken(ctx context.Context, tenantID int, userID int, tokenHash string, expiresAt time.Time) error {
+	return p.CreatePasswordResetTokenFn(ctx, tenantID, userID, tokenHash, expiresAt)
+}
+
+func (p *PasswordResetStore) ConsumePasswordResetToken(ctx context.Context, tenantID int, tokenHash string) (int, error) {
+	return p.ConsumePasswordResetTokenFn(ctx, tenantID, tokenHash)
+}
@@ -0,0 +1,38 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.PlanStore = (*PlanStore)(nil)
+
+type PlanStore struct {
+	GetAllPlansFn         func(ctx context.Context) ([]domain.Plan, error)
+	GetTenantPlanFn       func(ctx context.Context, tenantID int) (domain.Plan, error)
+	SetTenantPlanFn       func(ctx context.Context, tenantID int, code string) (domain.Tenant, error)
+	CountMembersFn        func(ctx context.Context, tenantID int) (int, error)
+	CountClassesBetweenFn func(ctx context.Context, tenantID int, from time.Time, to time.Time) (int, error)
+}
+
+func (p *PlanStore) GetAllPlans(ctx context.Context) ([]domain.Plan, error) {
+	return p.GetAllPlansFn(ctx)
+}
+
+func (p *PlanStore) GetTenantPlan(ctx context.Context, tenantID int) (domain.Plan, error) {
+	return p.GetTenantPlanFn(ctx, tenantID)
+}
+
+func (p *PlanStore) SetTenantPlan(ctx context.Context, tenantID int, code string) (domain.Tenant, error) {
+	return p.SetTenantPlanFn(ctx, tenantID, code)
+}
+
+func (p *PlanStore) CountMembers(ctx context.Context, tenantID int) (int, error) {
+	return p.CountMembersFn(ctx, tenantID)
+}
+
+func (p *PlanStore) CountClassesBetween(ctx context.Context, tenantID int, from time.Time, to time.Time) (int, error) {
+	return p.CountClassesBetweenFn(ctx, tenantID, from, to)
+}
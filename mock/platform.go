@@ -0,0 +1,37 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.PlatformStore = (*PlatformStore)(nil)
+
+type PlatformStore struct {
+	CreatePlatformOperatorFn     func(ctx context.Context, operator domain.PlatformOperator) (domain.PlatformOperator, error)
+	GetPlatformOperatorByEmailFn func(ctx context.Context, email string) (domain.PlatformOperator, error)
+	RecordPlatformLoginFn        func(ctx context.Context, operatorID int) error
+	GetTenantsUsageFn            func(ctx context.Context, filter domain.TenantFilter) ([]domain.TenantUsage, error)
+	GetTenantUsageFn             func(ctx context.Context, tenantID int) (domain.TenantUsage, error)
+}
+
+func (p *PlatformStore) CreatePlatformOperator(ctx context.Context, operator domain.PlatformOperator) (domain.PlatformOperator, error) {
+	return p.CreatePlatformOperatorFn(ctx, operator)
+}
+
+func (p *PlatformStore) GetPlatformOperatorByEmail(ctx context.Context, email string) (domain.PlatformOperator, error) {
+	return p.GetPlatformOperatorByEmailFn(ctx, email)
+}
+
+func (p *PlatformStore) RecordPlatformLogin(ctx context.Context, operatorID int) error {
+	return p.RecordPlatformLoginFn(ctx, operatorID)
+}
+
+func (p *PlatformStore) GetTenantsUsage(ctx context.Context, filter domain.TenantFilter) ([]domain.TenantUsage, error) {
+	return p.GetTenantsUsageFn(ctx, filter)
+}
+
+func (p *PlatformStore) GetTenantUsage(ctx context.Context, tenantID int) (domain.TenantUsage, error) {
+	return p.GetTenantUsageFn(ctx, tenantID)
+}
@@ -0,0 +1,42 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.RoleStore = (*RoleStore)(nil)
+
+type RoleStore struct {
+	CreateRoleFn     func(ctx context.Context, tenantID int, role domain.Role) (domain.Role, error)
+	GetRoleByIDFn    func(ctx context.Context, tenantID int, roleID int) (domain.Role, error)
+	GetRoleByNameFn  func(ctx context.Context, tenantID int, name string) (domain.Role, error)
+	UpdateRoleFn     func(ctx context.Context, tenantID int, roleID int, updates domain.RoleUpdate) (domain.Role, error)
+	DeleteRoleByIDFn func(ctx context.Context, tenantID int, roleID int) error
+	GetAllRolesFn    func(ctx context.Context, tenantID int) ([]domain.Role, error)
+}
+
+func (r *RoleStore) CreateRole(ctx context.Context, tenantID int, role domain.Role) (domain.Role, error) {
+	return r.CreateRoleFn(ctx, tenantID, role)
+}
+
+func (r *RoleStore) GetRoleByID(ctx context.Context, tenantID int, roleID int) (domain.Role, error) {
+	return r.GetRoleByIDFn(ctx, tenantID, roleID)
+}
+
+func (r *RoleStore) GetRoleByName(ctx context.Context, tenantID int, name string) (domain.Role, error) {
+	return r.GetRoleByNameFn(ctx, tenantID, name)
+}
+
+func (r *RoleStore) UpdateRole(ctx context.Context, tenantID int, roleID int, updates domain.RoleUpdate) (domain.Role, error) {
+	return r.UpdateRoleFn(ctx, tenantID, roleID, updates)
+}
+
+func (r *RoleStore) DeleteRoleByID(ctx context.Context, tenantID int, roleID int) error {
+	return r.DeleteRoleByIDFn(ctx, tenantID, roleID)
+}
+
+func (r *RoleStore) GetAllRoles(ctx context.Context, tenantID int) ([]domain.Role, error) {
+	return r.GetAllRolesFn(ctx, tenantID)
+}
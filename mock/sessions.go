@@ -0,0 +1,48 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.SessionStore = (*SessionStore)(nil)
+
+type SessionStore struct {
+	CreateSessionFn       func(ctx context.Context, tenantID int, session domain.Session, tokenHash string) (domain.Session, error)
+	RotateRefreshTokenFn  func(ctx context.Context, tenantID int, oldHash string, newHash string, expiresAt time.Time) (domain.Session, error)
+	GetActiveSessionsFn   func(ctx context.Context, tenantID int, userID int) ([]domain.Session, error)
+	GetSessionByIDFn      func(ctx context.Context, tenantID int, sessionID int) (domain.Session, error)
+	RevokeSessionFn       func(ctx context.Context, tenantID int, userID int, sessionID int) error
+	RevokeAllSessionsFn   func(ctx context.Context, tenantID int, userID int) error
+	RevokeOtherSessionsFn func(ctx context.Context, tenantID int, userID int, sessionID int) error
+}
+
+func (s *SessionStore) CreateSession(ctx context.Context, tenantID int, session domain.Session, tokenHash string) (domain.Session, error) {
+	return s.CreateSessionFn(ctx, tenantID, session, tokenHash)
+}
+
+func (s *SessionStore) RotateRefreshToken(ctx context.Context, tenantID int, oldHash string, newHash string, expiresAt time.Time) (domain.Session, error) {
+	return s.RotateRefreshTokenFn(ctx, tenantID, oldHash, newHash, expiresAt)
+}
+
+func (s *SessionStore) GetActiveSessions(ctx context.Context, tenantID int, userID int) ([]domain.Session, error) {
+	return s.GetActiveSessionsFn(ctx, tenantID, userID)
+}
+
+func (s *SessionStore) GetSessionByID(ctx context.Context, tenantID int, sessionID int) (domain.Session, error) {
+	return s.GetSessionByIDFn(ctx, tenantID, sessionID)
+}
+
+func (s *SessionStore) RevokeSession(ctx context.Context, tenantID int, userID int, sessionID int) error {
+	return s.RevokeSessionFn(ctx, tenantID, userID, sessionID)
+}
+
+func (s *SessionStore) RevokeAllSessions(ctx context.Context, tenantID int, userID int) error {
+	return s.RevokeAllSessionsFn(ctx, tenantID, userID)
+}
+
+func (s *SessionStore) RevokeOtherSessions(ctx context.Context, tenantID int, userID int, sessionID int) error {
+	return s.RevokeOtherSessionsFn(ctx, tenantID, userID, sessionID)
+}
@@ -1,7 +1,39 @@
 package mock
 
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.Store = (*Store)(nil)
+
 type Store struct {
 	TenantStore
 	UserStore
 	ClassStore
+	RoleStore
+	SessionStore
+	PasswordResetStore
+	TwoFactorStore
+	LoginAttemptStore
+	APIKeyStore
+	InvitationStore
+	TenantSettingsStore
+	LocationStore
+	PlatformStore
+	PlanStore
+	ExportStore
+	DeletionStore
+
+	WithTxFn func(ctx context.Context, fn func(tx domain.Store) error) error
+}
+
+// WithTx runs fn against the mock itself unless WithTxFn is set, since
+// there is nothing to roll back.
+func (s *Store) WithTx(ctx context.Context, fn func(tx domain.Store) error) error {
+	if s.WithTxFn != nil {
+		return s.WithTxFn(ctx, fn)
+	}
+	return fn(s)
 }
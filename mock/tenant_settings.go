@@ -0,0 +1,22 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.TenantSettingsStore = (*TenantSettingsStore)(nil)
+
+type TenantSettingsStore struct {
+	GetTenantSettingsFn    func(ctx context.Context, tenantID int) (domain.TenantSettings, error)
+	UpdateTenantSettingsFn func(ctx context.Context, tenantID int, updates domain.TenantSettingsUpdate) (domain.TenantSettings, error)
+}
+
+func (t *TenantSettingsStore) GetTenantSettings(ctx context.Context, tenantID int) (domain.TenantSettings, error) {
+	return t.GetTenantSettingsFn(ctx, tenantID)
+}
+
+func (t *TenantSettingsStore) UpdateTenantSettings(ctx context.Context, tenantID int, updates domain.TenantSettingsUpdate) (domain.TenantSettings, error) {
+	return t.UpdateTenantSettingsFn(ctx, tenantID, updates)
+}
@@ -2,6 +2,7 @@ package mock
 
 import (
 	"context"
+	"time"
 
 	"github.com/emanuelquerty/gymulty/domain"
 )
@@ -9,9 +10,45 @@ import (
 var _ domain.TenantStore = (*TenantStore)(nil)
 
 type TenantStore struct {
-	CreateTenantFn func(ctx context.Context, data domain.Tenant) (domain.Tenant, error)
+	CreateTenantFn         func(ctx context.Context, data domain.Tenant) (domain.Tenant, error)
+	GetTenantByIDFn        func(ctx context.Context, tenantID int) (domain.Tenant, error)
+	GetTenantBySubdomainFn func(ctx context.Context, subdomain string) (domain.Tenant, error)
+	VerifyTenantFn         func(ctx context.Context, tenantID int) error
+	UpdateTenantStatusFn   func(ctx context.Context, tenantID int, status string, reason string, from []string) (domain.Tenant, error)
+	UpdateTenantFn         func(ctx context.Context, tenantID int, updates domain.TenantUpdate) (domain.Tenant, error)
+
+	ScheduleTenantDeletionFn func(ctx context.Context, tenantID int, requestedBy string, reason string, scheduledFor time.Time) (domain.Tenant, error)
+	RestoreTenantFn          func(ctx context.Context, tenantID int, reason string, requestedBy ...string) (domain.Tenant, error)
 }
 
 func (t *TenantStore) CreateTenant(ctx context.Context, data domain.Tenant) (domain.Tenant, error) {
 	return t.CreateTenantFn(ctx, data)
 }
+
+func (t *TenantStore) GetTenantByID(ctx context.Context, tenantID int) (domain.Tenant, error) {
+	return t.GetTenantByIDFn(ctx, tenantID)
+}
+
+func (t *TenantStore) GetTenantBySubdomain(ctx context.Context, subdomain string) (domain.Tenant, error) {
+	return t.GetTenantBySubdomainFn(ctx, subdomain)
+}
+
+func (t *TenantStore) VerifyTenant(ctx context.Context, tenantID int) error {
+	return t.VerifyTenantFn(ctx, tenantID)
+}
+
+func (t *TenantStore) UpdateTenantStatus(ctx context.Context, tenantID int, status string, reason string, from []string) (domain.Tenant, error) {
+	return t.UpdateTenantStatusFn(ctx, tenantID, status, reason, from)
+}
+
+func (t *TenantStore) UpdateTenant(ctx context.Context, tenantID int, updates domain.TenantUpdate) (domain.Tenant, error) {
+	return t.UpdateTenantFn(ctx, tenantID, updates)
+}
+
+func (t *TenantStore) ScheduleTenantDeletion(ctx context.Context, tenantID int, requestedBy string, reason string, scheduledFor time.Time) (domain.Tenant, error) {
+	return t.ScheduleTenantDeletionFn(ctx, tenantID, requestedBy, reason, scheduledFor)
+}
+
+func (t *TenantStore) RestoreTenant(ctx context.Context, tenantID int, reason string, requestedBy ...string) (domain.Tenant, error) {
+	return t.RestoreTenantFn(ctx, tenantID, reason, requestedBy...)
+}
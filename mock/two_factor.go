@@ -0,0 +1,47 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+)
+
+var _ domain.TwoFactorStore = (*TwoFactorStore)(nil)
+
+type TwoFactorStore struct {
+	SaveTwoFactorSecretFn  func(ctx context.Context, tenantID int, userID int, secret string) error
+	GetTwoFactorFn         func(ctx context.Context, tenantID int, userID int) (domain.TwoFactor, error)
+	EnableTwoFactorFn      func(ctx context.Context, tenantID int, userID int, recoveryCodeHashes []string) error
+	DisableTwoFactorFn     func(ctx context.Context, tenantID int, userID int) error
+	ReplaceRecoveryCodesFn func(ctx context.Context, tenantID int, userID int, recoveryCodeHashes []string) error
+	ConsumeRecoveryCodeFn  func(ctx context.Context, tenantID int, userID int, codeHash string) error
+	UseTwoFactorStepFn     func(ctx context.Context, tenantID int, userID int, step int64) error
+}
+
+func (t *TwoFactorStore) SaveTwoFactorSecret(ctx context.Context, tenantID int, userID int, secret string) error {
+	return t.SaveTwoFactorSecretFn(ctx, tenantID, userID, secret)
+}
+
+func (t *TwoFactorStore) GetTwoFactor(ctx context.Context, tenantID int, userID int) (domain.TwoFactor, error) {
+	return t.GetTwoFactorFn(ctx, tenantID, userID)
+}
+
+func (t *TwoFactorStore) EnableTwoFactor(ctx context.Context, tenantID int, userID int, recoveryCodeHashes []string) error {
+	return t.EnableTwoFactorFn(ctx, tenantID, userID, recoveryCodeHashes)
+}
+
+func (t *TwoFactorStore) DisableTwoFactor(ctx context.Context, tenantID int, userID int) error {
+	return t.DisableTwoFactorFn(ctx, tenantID, userID)
+}
+
+func (t *TwoFactorStore) ReplaceRecoveryCodes(ctx context.Context, tenantID int, userID int, recoveryCodeHashes []string) error {
+	return t.ReplaceRecoveryCodesFn(ctx, tenantID, userID, recoveryCodeHashes)
+}
+
+func (t *TwoFactorStore) ConsumeRecoveryCode(ctx context.Context, tenantID int, userID int, codeHash string) error {
+	return t.ConsumeRecoveryCodeFn(ctx, tenantID, userID, codeHash)
+}
+
+func (t *TwoFactorStore) UseTwoFactorStep(ctx context.Context, tenantID int, userID int, step int64) error {
+	return t.UseTwoFactorStepFn(ctx, tenantID, userID, step)
+}
@@ -9,17 +9,36 @@ import (
 var _ domain.UserStore = (*UserStore)(nil)
 
 type UserStore struct {
-	GetUserByIDFn func(ctx context.Context, tenantID int, userID int) (domain.User, error)
-	CreateUserFn  func(ctx context.Context, tenantID int, user domain.User) (domain.User, error)
-	UpdateUserFn  func(ctx context.Context, tenantID int, userID int, update domain.UserUpdate) (domain.User, error)
-	DeleteByIDFn  func(ctx context.Context, tenantID int, userID int) error
-	GetAllUsersFn func(ctx context.Context, tenantID int) ([]domain.User, error)
+	GetUserByIDFn     func(ctx context.Context, tenantID int, userID int) (domain.User, error)
+	GetUserByEmailFn  func(ctx context.Context, tenantID int, email string) (domain.User, error)
+	GetTakenEmailsFn  func(ctx context.Context, tenantID int, emails []string) ([]string, error)
+	VerifyUserEmailFn func(ctx context.Context, tenantID int, userID int) (domain.User, error)
+	CreateUserFn      func(ctx context.Context, tenantID int, user domain.User) (domain.User, error)
+	UpdateUserFn      func(ctx context.Context, tenantID int, userID int, update domain.UserUpdate) (domain.User, error)
+	DeleteByIDFn      func(ctx context.Context, tenantID int, userID int) error
+	GetAllUsersFn     func(ctx context.Context, tenantID int) ([]domain.User, error)
+
+	GetUsersByLocationFn func(ctx context.Context, tenantID int, locationID int) ([]domain.User, error)
+	GetUserLocationsFn   func(ctx context.Context, tenantID int, userID int) ([]domain.Location, error)
+	SetUserLocationsFn   func(ctx context.Context, tenantID int, userID int, locationIDs []int) ([]domain.Location, error)
 }
 
 func (u *UserStore) GetUserByID(ctx context.Context, tenantID int, userID int) (domain.User, error) {
 	return u.GetUserByIDFn(ctx, tenantID, userID)
 }
 
+func (u *UserStore) GetUserByEmail(ctx context.Context, tenantID int, email string) (domain.User, error) {
+	return u.GetUserByEmailFn(ctx, tenantID, email)
+}
+
+func (u *UserStore) GetTakenEmails(ctx context.Context, tenantID int, emails []string) ([]string, error) {
+	return u.GetTakenEmailsFn(ctx, tenantID, emails)
+}
+
+func (u *UserStore) VerifyUserEmail(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+	return u.VerifyUserEmailFn(ctx, tenantID, userID)
+}
+
 func (u *UserStore) CreateUser(ctx context.Context, tenantID int, user domain.User) (domain.User, error) {
 	return u.CreateUserFn(ctx, tenantID, user)
 }
@@ -35,3 +54,15 @@ func (u *UserStore) DeleteUserByID(ctx context.Context, tenantID int, userID int
 func (u *UserStore) GetAllUsers(ctx context.Context, tenantID int) ([]domain.User, error) {
 	return u.GetAllUsersFn(ctx, tenantID)
 }
+
+func (u *UserStore) GetUsersByLocation(ctx context.Context, tenantID int, locationID int) ([]domain.User, error) {
+	return u.GetUsersByLocationFn(ctx, tenantID, locationID)
+}
+
+func (u *UserStore) GetUserLocations(ctx context.Context, tenantID int, userID int) ([]domain.Location, error) {
+	return u.GetUserLocationsFn(ctx, tenantID, userID)
+}
+
+func (u *UserStore) SetUserLocations(ctx context.Context, tenantID int, userID int, locationIDs []int) ([]domain.Location, error) {
+	return u.SetUserLocationsFn(ctx, tenantID, userID, locationIDs)
+}
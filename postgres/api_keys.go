@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Store) CreateAPIKey(ctx context.Context, tenantID int, data domain.APIKey) (domain.APIKey, error) {
+	query :=
+		`INSERT INTO api_keys (tenant_id, name, prefix, key_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING *`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, data.Name, data.Prefix, data.KeyHash, data.Scopes, data.CreatedBy, data.ExpiresAt)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	key, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.APIKey])
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	return key, nil
+}
+
+func (s *Store) GetAPIKeyByHash(ctx context.Context, keyHash string) (domain.APIKey, error) {
+	query := "SELECT * FROM api_keys WHERE key_hash=$1"
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, keyHash)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	key, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.APIKey])
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	return key, nil
+}
+
+func (s *Store) GetAllAPIKeys(ctx context.Context, tenantID int) ([]domain.APIKey, error) {
+	query := "SELECT * FROM api_keys WHERE tenant_id=$1 ORDER BY created_at DESC"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.APIKey{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID)
+	if err != nil {
+		return []domain.APIKey{}, err
+	}
+
+	keys, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.APIKey])
+	if err != nil {
+		return []domain.APIKey{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.APIKey{}, err
+	}
+	return keys, nil
+}
+
+func (s *Store) RevokeAPIKey(ctx context.Context, tenantID int, keyID int) error {
+	query := "UPDATE api_keys SET revoked_at=NOW() WHERE tenant_id=$1 AND id=$2 AND revoked_at IS NULL"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, tenantID, keyID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) TouchAPIKey(ctx context.Context, keyID int) error {
+	// only write once a minute, busy integrations would otherwise update the row on every request
+	query :=
+		`UPDATE api_keys SET last_used_at=NOW()
+		WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, keyID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
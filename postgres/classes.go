@@ -13,20 +13,25 @@ func (s *Store) CreateClass(ctx context.Context, tenantID int, data domain.Class
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at, updated_at`
 
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.beginTx(ctx, tenantID)
 	if err != nil {
 		return domain.Class{}, err
 	}
 	defer tx.Rollback(ctx)
 
-	row := tx.QueryRow(ctx, query, data.TenantID, data.TrainerID, data.Name,
+	row := tx.QueryRow(ctx, query, tenantID, data.TrainerID, data.Name,
 		data.Description, data.Capacity, data.StartsAt, data.EndsAt)
 
 	class := data
+	class.TenantID = tenantID
 	err = row.Scan(&class.ID, &class.CreatedAt, &class.UpdatedAt)
 	if err != nil {
 		return domain.Class{}, err
 	}
+	err = linkDefaultLocation(ctx, tx, classLocations, tenantID, class.ID)
+	if err != nil {
+		return domain.Class{}, err
+	}
 	err = tx.Commit(ctx)
 	if err != nil {
 		return domain.Class{}, err
@@ -38,7 +43,7 @@ func (s *Store) GetClassByID(ctx context.Context, tenantID int, classID int) (do
 	query :=
 		`SELECT * FROM classes 
 		WHERE tenant_id=$1 AND id=$2`
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.beginTx(ctx, tenantID)
 	if err != nil {
 		return domain.Class{}, err
 	}
@@ -63,7 +68,7 @@ func (s *Store) GetClassByID(ctx context.Context, tenantID int, classID int) (do
 func (s *Store) DeleteClassByID(ctx context.Context, tenantID int, classID int) error {
 	query := `DELETE FROM classes WHERE tenant_id=$1 AND id=$2`
 
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.beginTx(ctx, tenantID)
 	if err != nil {
 		return err
 	}
@@ -80,7 +85,7 @@ func (s *Store) DeleteClassByID(ctx context.Context, tenantID int, classID int)
 func (s *Store) GetAllClasses(ctx context.Context, tenantID int) ([]domain.Class, error) {
 	query := "SELECT * FROM classes WHERE tenant_id=$1"
 
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.beginTx(ctx, tenantID)
 	if err != nil {
 		return []domain.Class{}, err
 	}
@@ -102,3 +107,67 @@ func (s *Store) GetAllClasses(ctx context.Context, tenantID int) ([]domain.Class
 
 	return classes, nil
 }
+
+func (s *Store) GetClassesByLocation(ctx context.Context, tenantID int, locationID int) ([]domain.Class, error) {
+	query :=
+		`SELECT classes.* FROM classes
+		JOIN class_locations ON class_locations.class_id = classes.id
+		WHERE classes.tenant_id=$1 AND class_locations.location_id=$2`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.Class{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, locationID)
+	if err != nil {
+		return []domain.Class{}, err
+	}
+
+	classes, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.Class])
+	if err != nil {
+		return []domain.Class{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Class{}, err
+	}
+	return classes, nil
+}
+
+func (s *Store) GetClassLocations(ctx context.Context, tenantID int, classID int) ([]domain.Location, error) {
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	locations, err := getLinkedLocations(ctx, tx, classLocations, tenantID, classID)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	return locations, nil
+}
+
+func (s *Store) SetClassLocations(ctx context.Context, tenantID int, classID int, locationIDs []int) ([]domain.Location, error) {
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	locations, err := setLinkedLocations(ctx, tx, classLocations, tenantID, classID, locationIDs)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	return locations, nil
+}
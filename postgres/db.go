@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
+	"io/fs"
 
 	"github.com/emanuelquerty/gymulty/config"
 	"github.com/emanuelquerty/gymulty/domain"
@@ -15,12 +16,36 @@ import (
 
 var _ domain.Store = (*Store)(nil)
 
+// Store methods each run in their own transaction, begun on db. Inside
+// WithTx, db begins savepoints of the surrounding transaction instead.
+// With schemas set, every tenant signing up gets a schema of its own.
 type Store struct {
-	pool *pgxpool.Pool
+	db      txBeginner
+	inTx    bool
+	schemas *tenantSchemas
+
+	// newSchemas collects the schemas created inside WithTx, which are
+	// dropped if its transaction rolls back.
+	newSchemas *[]string
+}
+
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
 }
 
 func NewStore(pool *pgxpool.Pool) *Store {
-	return &Store{pool: pool}
+	return &Store{db: pool}
+}
+
+// NewTenantSchemaStore returns a store that keeps the data of every new
+// tenant in its own schema, migrated with the tenant migrations of
+// embedMigrations.
+func NewTenantSchemaStore(pool *pgxpool.Pool, embedMigrations fs.FS) (*Store, error) {
+	schemas, err := newTenantSchemas(pool.Config().ConnConfig, embedMigrations)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: pool, schemas: schemas}, nil
 }
 
 func Connect(conf config.Database) (*pgxpool.Pool, error) {
@@ -66,6 +91,8 @@ func CreateDBIfNotExists(conf config.Database) error {
 	return nil
 }
 
+// RunMigrations migrates the shared tables and, in the schema per tenant
+// mode, the schema of every tenant.
 func RunMigrations(embedMigrations embed.FS, conf config.Database) error {
 	dsn := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s",
 		conf.User, conf.Password, conf.Host, conf.Port, conf.Name)
@@ -85,5 +112,17 @@ func RunMigrations(embedMigrations embed.FS, conf config.Database) error {
 	if err := goose.Up(db, "postgres/migrations"); err != nil {
 		return fmt.Errorf("error running up migration: %w", err)
 	}
-	return nil
+
+	if !conf.SchemaPerTenant {
+		return nil
+	}
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return err
+	}
+	schemas, err := newTenantSchemas(connConfig, embedMigrations)
+	if err != nil {
+		return err
+	}
+	return schemas.migrateAll(context.Background(), db)
 }
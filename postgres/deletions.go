@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+// purgedTables are the tables whose deleted rows a deletion certificate
+// counts. Purging deletes the rows of every other tenant table too.
+var purgedTables = []string{
+	"users", "roles", "classes", "locations", "invitations", "sessions", "api_keys", "login_attempts", "exports",
+}
+
+func (s *Store) GetTenantsDueForPurge(ctx context.Context, now time.Time) ([]domain.Tenant, error) {
+	query :=
+		`SELECT * FROM tenants WHERE status='pending_deletion' AND deletion_scheduled_for <= $1
+		ORDER BY deletion_scheduled_for`
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return []domain.Tenant{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, now)
+	if err != nil {
+		return []domain.Tenant{}, err
+	}
+
+	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.Tenant])
+	if err != nil {
+		return []domain.Tenant{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Tenant{}, err
+	}
+	return tenants, nil
+}
+
+// PurgeTenant deletes the tenant and, through ON DELETE CASCADE, all of its
+// data, after counting the rows about to go. The schema of the tenant, if
+// it has one, is dropped with it. The tenant row stays locked throughout, so
+// it cannot be restored halfway through.
+func (s *Store) PurgeTenant(ctx context.Context, tenantID int, now time.Time) (domain.DeletionCertificate, error) {
+	query :=
+		`SELECT * FROM tenants
+		WHERE id=$1 AND status='pending_deletion' AND deletion_scheduled_for <= $2
+		FOR UPDATE`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.DeletionCertificate{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, now)
+	if err != nil {
+		return domain.DeletionCertificate{}, err
+	}
+	tenant, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Tenant])
+	if err != nil {
+		return domain.DeletionCertificate{}, err
+	}
+
+	deleted := make(map[string]int, len(purgedTables))
+	for _, table := range purgedTables {
+		var count int
+		query = "SELECT COUNT(*) FROM " + pgx.Identifier{table}.Sanitize() + " WHERE tenant_id=$1"
+		err = tx.QueryRow(ctx, query, tenantID).Scan(&count)
+		if err != nil {
+			return domain.DeletionCertificate{}, err
+		}
+		deleted[table] = count
+	}
+
+	if s.schemas != nil {
+		_, err = tx.Exec(ctx, "DROP SCHEMA IF EXISTS "+pgx.Identifier{tenantSchema(tenantID)}.Sanitize()+" CASCADE")
+		if err != nil {
+			return domain.DeletionCertificate{}, err
+		}
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM tenants WHERE id=$1", tenantID)
+	if err != nil {
+		return domain.DeletionCertificate{}, err
+	}
+
+	query =
+		`INSERT INTO deletion_certificates
+		(tenant_id, business_name, subdomain, requested_by, requested_at, scheduled_for, deleted_records)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING *`
+	rows, err = tx.Query(ctx, query, tenant.ID, tenant.BusinessName, tenant.Subdomain,
+		tenant.DeletionRequestedBy, tenant.StatusChangedAt, tenant.DeletionScheduledFor, deleted)
+	if err != nil {
+		return domain.DeletionCertificate{}, err
+	}
+
+	certificate, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.DeletionCertificate])
+	if err != nil {
+		return domain.DeletionCertificate{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.DeletionCertificate{}, err
+	}
+	return certificate, nil
+}
+
+func (s *Store) GetDeletionCertificate(ctx context.Context, tenantID int) (domain.DeletionCertificate, error) {
+	query := "SELECT * FROM deletion_certificates WHERE tenant_id=$1"
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return domain.DeletionCertificate{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID)
+	if err != nil {
+		return domain.DeletionCertificate{}, err
+	}
+
+	certificate, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.DeletionCertificate])
+	if err != nil {
+		return domain.DeletionCertificate{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.DeletionCertificate{}, err
+	}
+	return certificate, nil
+}
@@ -0,0 +1,214 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Store) CreateExport(ctx context.Context, tenantID int, data domain.Export) (domain.Export, error) {
+	query := "INSERT INTO exports (tenant_id, status, requested_by) VALUES ($1, $2, $3) RETURNING *"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Export{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, data.Status, data.RequestedBy)
+	if err != nil {
+		return domain.Export{}, err
+	}
+
+	export, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Export])
+	if err != nil {
+		return domain.Export{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Export{}, err
+	}
+	return export, nil
+}
+
+func (s *Store) GetExportByID(ctx context.Context, tenantID int, exportID int) (domain.Export, error) {
+	query := "SELECT * FROM exports WHERE tenant_id=$1 AND id=$2"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Export{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, exportID)
+	if err != nil {
+		return domain.Export{}, err
+	}
+
+	export, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Export])
+	if err != nil {
+		return domain.Export{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Export{}, err
+	}
+	return export, nil
+}
+
+func (s *Store) SetExportStatus(ctx context.Context, tenantID int, exportID int, status string, expiresAt *time.Time) (domain.Export, error) {
+	query :=
+		`UPDATE exports SET status=$1, expires_at=$2, updated_at=NOW(),
+		completed_at=CASE WHEN $1 = 'completed' THEN NOW() ELSE completed_at END
+		WHERE tenant_id=$3 AND id=$4 RETURNING *`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Export{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, status, expiresAt, tenantID, exportID)
+	if err != nil {
+		return domain.Export{}, err
+	}
+
+	export, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Export])
+	if err != nil {
+		return domain.Export{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Export{}, err
+	}
+	return export, nil
+}
+
+func (s *Store) ExpireExports(ctx context.Context, now time.Time) ([]domain.Export, error) {
+	query :=
+		`UPDATE exports SET status='expired', updated_at=NOW()
+		WHERE status='completed' AND expires_at <= $1 RETURNING *`
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return []domain.Export{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, now)
+	if err != nil {
+		return []domain.Export{}, err
+	}
+
+	exports, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.Export])
+	if err != nil {
+		return []domain.Export{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Export{}, err
+	}
+	return exports, nil
+}
+
+// GetTenantRecords reads every table in one repeatable read transaction, so
+// the records reference each other consistently. Inside WithTx it uses the
+// isolation of the surrounding transaction instead.
+func (s *Store) GetTenantRecords(ctx context.Context, tenantID int) (domain.TenantRecords, error) {
+	tx, err := s.beginTxOptions(ctx, tenantID, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var records domain.TenantRecords
+	records.Tenant, err = collectOne[domain.Tenant](ctx, tx, "SELECT * FROM tenants WHERE id=$1", tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	records.Settings, err = collectOne[domain.TenantSettings](ctx, tx, "SELECT * FROM tenant_settings WHERE tenant_id=$1", tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+
+	// the password column is never read, not even to be left out later
+	usersQuery :=
+		`SELECT id, tenant_id, first_name, last_name, email, role, verified_at, created_at, updated_at
+		FROM users WHERE tenant_id=$1 ORDER BY id`
+	rows, err := tx.Query(ctx, usersQuery, tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	records.Users, err = pgx.CollectRows(rows, pgx.RowToStructByNameLax[domain.User])
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+
+	records.Roles, err = collectAll[domain.Role](ctx, tx, "SELECT * FROM roles WHERE tenant_id=$1 ORDER BY id", tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	records.Locations, err = collectAll[domain.Location](ctx, tx, "SELECT * FROM locations WHERE tenant_id=$1 ORDER BY id", tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	records.Classes, err = collectAll[domain.Class](ctx, tx, "SELECT * FROM classes WHERE tenant_id=$1 ORDER BY id", tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	records.ClassLocations, err = collectAll[domain.ClassLocation](ctx, tx,
+		`SELECT class_locations.* FROM class_locations
+		JOIN classes ON classes.id = class_locations.class_id
+		WHERE classes.tenant_id=$1 ORDER BY class_id, location_id`, tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	records.UserLocations, err = collectAll[domain.UserLocation](ctx, tx,
+		`SELECT user_locations.* FROM user_locations
+		JOIN users ON users.id = user_locations.user_id
+		WHERE users.tenant_id=$1 ORDER BY user_id, location_id`, tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	records.Invitations, err = collectAll[domain.Invitation](ctx, tx, "SELECT * FROM invitations WHERE tenant_id=$1 ORDER BY id", tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	records.APIKeys, err = collectAll[domain.APIKey](ctx, tx, "SELECT * FROM api_keys WHERE tenant_id=$1 ORDER BY id", tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	records.Sessions, err = collectAll[domain.Session](ctx, tx, "SELECT * FROM sessions WHERE tenant_id=$1 ORDER BY id", tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	records.LoginAttempts, err = collectAll[domain.LoginAttempt](ctx, tx, "SELECT * FROM login_attempts WHERE tenant_id=$1 ORDER BY id", tenantID)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.TenantRecords{}, err
+	}
+	return records, nil
+}
+
+func collectOne[T any](ctx context.Context, tx pgx.Tx, query string, args ...any) (T, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[T])
+}
+
+func collectAll[T any](ctx context.Context, tx pgx.Tx, query string, args ...any) ([]T, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return []T{}, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[T])
+}
@@ -16,7 +16,84 @@ func buildUserUpdateQuery(tenantID int, userID int, updates domain.UserUpdate) (
 		"password":   updates.Password,
 		"role":       updates.Role,
 	}
+	return buildUpdateQuery("users", updatesMap, tenantID, userID)
+}
+
+func buildRoleUpdateQuery(tenantID int, roleID int, updates domain.RoleUpdate) (string, []any) {
+	updatesMap := map[string]any{
+		"name":        updates.Name,
+		"description": updates.Description,
+		"permissions": updates.Permissions,
+	}
+	return buildUpdateQuery("roles", updatesMap, tenantID, roleID)
+}
+
+func buildLocationUpdateQuery(tenantID int, locationID int, updates domain.LocationUpdate) (string, []any) {
+	updatesMap := map[string]any{
+		"name":          updates.Name,
+		"address":       updates.Address,
+		"city":          updates.City,
+		"postal_code":   updates.PostalCode,
+		"country":       updates.Country,
+		"timezone":      updates.Timezone,
+		"opening_hours": updates.OpeningHours,
+	}
+	return buildUpdateQuery("locations", updatesMap, tenantID, locationID)
+}
+
+func buildTenantUpdateQuery(tenantID int, updates domain.TenantUpdate) (string, []any) {
+	updatesMap := map[string]any{
+		"business_name":              updates.BusinessName,
+		"subdomain":                  updates.Subdomain,
+		"require_email_verification": updates.RequireEmailVerification,
+		"require_admin_two_factor":   updates.RequireAdminTwoFactor,
+	}
+
+	// tenants are not scoped to a tenant_id like every other table
+	cols, columnValues := buildSetClause(updatesMap)
+	columnValues = append(columnValues, tenantID)
+	query := "UPDATE tenants SET " + cols + " WHERE id=$" + strconv.Itoa(len(columnValues)) + " RETURNING *"
+	return query, columnValues
+}
+
+func buildTenantSettingsUpdateQuery(tenantID int, updates domain.TenantSettingsUpdate) (string, []any) {
+	updatesMap := map[string]any{
+		"timezone":      updates.Timezone,
+		"locale":        updates.Locale,
+		"currency":      updates.Currency,
+		"week_start":    updates.WeekStart,
+		"opening_hours": updates.OpeningHours,
+	}
+
+	cols, columnValues := buildSetClause(updatesMap)
+	columnValues = append(columnValues, tenantID)
+	query := "UPDATE tenant_settings SET " + cols + " WHERE tenant_id=$" + strconv.Itoa(len(columnValues)) + " RETURNING *"
+	return query, columnValues
+}
+
+// buildUpdateQuery builds an UPDATE statement for the row with the given id and
+// tenant_id in table. updatesMap maps column names to pointers; nil pointers are skipped.
+func buildUpdateQuery(table string, updatesMap map[string]any, tenantID int, id int) (string, []any) {
+	cols, columnValues := buildSetClause(updatesMap)
+	i := len(columnValues)
 
+	columnValues = append(columnValues, id, tenantID)
+
+	var builder strings.Builder
+	builder.WriteString(" WHERE id=$")
+	builder.WriteString(strconv.Itoa(i + 1))
+	builder.WriteString(" AND tenant_id=$")
+	builder.WriteString(strconv.Itoa(i + 2))
+	builder.WriteString(" RETURNING *")
+	cols += builder.String()
+
+	query := "UPDATE " + table + " SET " + cols
+	return query, columnValues
+}
+
+// buildSetClause builds the "col=$1, ..., updated_at=NOW()" part of an UPDATE
+// statement together with its values.
+func buildSetClause(updatesMap map[string]any) (string, []any) {
 	var builder strings.Builder
 	var i int
 	var columnValues []any
@@ -33,18 +110,6 @@ func buildUserUpdateQuery(tenantID int, userID int, updates domain.UserUpdate) (
 		builder.WriteString(", ")
 		columnValues = append(columnValues, colValue) // appends the value of each column name
 	}
-
-	columnValues = append(columnValues, userID, tenantID)
-	cols := builder.String()[:builder.Len()-2] // remove the trailing space and comma
-
-	builder.Reset()
-	builder.WriteString(" WHERE id=$")
-	builder.WriteString(strconv.Itoa(i + 1))
-	builder.WriteString(" AND tenant_id=$")
-	builder.WriteString(strconv.Itoa(i + 2))
-	builder.WriteString(" RETURNING *")
-	cols += builder.String()
-
-	query := "UPDATE users SET " + cols
-	return query, columnValues
+	builder.WriteString("updated_at=NOW()")
+	return builder.String(), columnValues
 }
@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Store) CreateInvitation(ctx context.Context, tenantID int, data domain.Invitation) (domain.Invitation, error) {
+	query :=
+		`INSERT INTO invitations (tenant_id, email, first_name, last_name, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING *`
+
+	return s.getInvitation(ctx, tenantID, query, tenantID, data.Email, data.FirstName, data.LastName, data.Role, data.TokenHash, data.InvitedBy, data.ExpiresAt)
+}
+
+func (s *Store) GetInvitationByID(ctx context.Context, tenantID int, invitationID int) (domain.Invitation, error) {
+	query := "SELECT * FROM invitations WHERE tenant_id=$1 AND id=$2"
+	return s.getInvitation(ctx, tenantID, query, tenantID, invitationID)
+}
+
+func (s *Store) GetAllInvitations(ctx context.Context, tenantID int) ([]domain.Invitation, error) {
+	query := "SELECT * FROM invitations WHERE tenant_id=$1 ORDER BY created_at DESC"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.Invitation{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID)
+	if err != nil {
+		return []domain.Invitation{}, err
+	}
+
+	invitations, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.Invitation])
+	if err != nil {
+		return []domain.Invitation{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Invitation{}, err
+	}
+	return invitations, nil
+}
+
+func (s *Store) RenewInvitation(ctx context.Context, tenantID int, invitationID int, tokenHash string, expiresAt time.Time) (domain.Invitation, error) {
+	query :=
+		`UPDATE invitations SET token_hash=$3, expires_at=$4, updated_at=NOW()
+		WHERE tenant_id=$1 AND id=$2 AND accepted_at IS NULL AND revoked_at IS NULL
+		RETURNING *`
+
+	return s.getInvitation(ctx, tenantID, query, tenantID, invitationID, tokenHash, expiresAt)
+}
+
+func (s *Store) RevokeInvitation(ctx context.Context, tenantID int, invitationID int) error {
+	query :=
+		`UPDATE invitations SET revoked_at=NOW(), updated_at=NOW()
+		WHERE tenant_id=$1 AND id=$2 AND accepted_at IS NULL AND revoked_at IS NULL`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, tenantID, invitationID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) AcceptInvitation(ctx context.Context, tenantID int, tokenHash string, data domain.User) (domain.User, error) {
+	query :=
+		`SELECT id, email, first_name, last_name, role FROM invitations
+		WHERE tenant_id=$1 AND token_hash=$2 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		FOR UPDATE`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var invitationID int
+	var firstName, lastName string
+	err = tx.QueryRow(ctx, query, tenantID, tokenHash).Scan(&invitationID, &data.Email, &firstName, &lastName, &data.Role)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	// names the invitee left out default to those of the invitation
+	if data.FirstName == "" {
+		data.FirstName = firstName
+	}
+	if data.LastName == "" {
+		data.LastName = lastName
+	}
+
+	// following the emailed link proves the address, so the user starts verified
+	query =
+		`INSERT INTO users (tenant_id, first_name, last_name, email, password, role, verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING *`
+	rows, err := tx.Query(ctx, query, tenantID, data.FirstName, data.LastName, data.Email, data.Password, data.Role)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.User])
+	if err != nil {
+		return domain.User{}, err
+	}
+	err = linkDefaultLocation(ctx, tx, userLocations, tenantID, user.ID)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	query = "UPDATE invitations SET accepted_at=NOW(), updated_at=NOW() WHERE id=$1"
+	_, err = tx.Exec(ctx, query, invitationID)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+// getInvitation runs query, which returns a single invitation row, in its own transaction.
+func (s *Store) getInvitation(ctx context.Context, tenantID int, query string, args ...any) (domain.Invitation, error) {
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Invitation{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return domain.Invitation{}, err
+	}
+
+	invitation, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Invitation])
+	if err != nil {
+		return domain.Invitation{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Invitation{}, err
+	}
+	return invitation, nil
+}
@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"slices"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Store) CreateLocation(ctx context.Context, tenantID int, data domain.Location) (domain.Location, error) {
+	query :=
+		`INSERT INTO locations (tenant_id, name, address, city, postal_code, country, timezone, opening_hours)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING *`
+
+	if data.OpeningHours == nil {
+		data.OpeningHours = []domain.OpeningHours{}
+	}
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Location{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, data.Name, data.Address, data.City,
+		data.PostalCode, data.Country, data.Timezone, data.OpeningHours)
+	if err != nil {
+		return domain.Location{}, err
+	}
+
+	location, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Location])
+	if err != nil {
+		return domain.Location{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Location{}, err
+	}
+	return location, nil
+}
+
+func (s *Store) GetLocationByID(ctx context.Context, tenantID int, locationID int) (domain.Location, error) {
+	query := "SELECT * FROM locations WHERE tenant_id=$1 AND id=$2"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Location{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, locationID)
+	if err != nil {
+		return domain.Location{}, err
+	}
+
+	location, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Location])
+	if err != nil {
+		return domain.Location{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Location{}, err
+	}
+	return location, nil
+}
+
+func (s *Store) GetAllLocations(ctx context.Context, tenantID int) ([]domain.Location, error) {
+	query := "SELECT * FROM locations WHERE tenant_id=$1 ORDER BY id"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+
+	locations, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.Location])
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	return locations, nil
+}
+
+func (s *Store) UpdateLocation(ctx context.Context, tenantID int, locationID int, updates domain.LocationUpdate) (domain.Location, error) {
+	query, columnValues := buildLocationUpdateQuery(tenantID, locationID, updates)
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Location{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, columnValues...)
+	if err != nil {
+		return domain.Location{}, err
+	}
+
+	location, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Location])
+	if err != nil {
+		return domain.Location{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Location{}, err
+	}
+	return location, nil
+}
+
+func (s *Store) DeleteLocationByID(ctx context.Context, tenantID int, locationID int) error {
+	query := "DELETE FROM locations WHERE tenant_id=$1 AND id=$2"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, tenantID, locationID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return tx.Commit(ctx)
+}
+
+// createDefaultLocation gives a new tenant the location its classes and
+// users are first assigned to. See linkDefaultLocation.
+func createDefaultLocation(ctx context.Context, tx pgx.Tx, tenantID int) error {
+	query := "INSERT INTO locations (tenant_id, name) VALUES ($1, $2)"
+	_, err := tx.Exec(ctx, query, tenantID, domain.DefaultLocationName)
+	return err
+}
+
+// locationLink describes a join table that associates rows of owner with locations.
+type locationLink struct {
+	owner     string // e.g. "classes"
+	joinTable string // e.g. "class_locations"
+	column    string // e.g. "class_id"
+}
+
+var (
+	classLocations = locationLink{owner: "classes", joinTable: "class_locations", column: "class_id"}
+	userLocations  = locationLink{owner: "users", joinTable: "user_locations", column: "user_id"}
+)
+
+// linkDefaultLocation assigns the owner row with the given id to the tenant's
+// oldest location, so new classes and users show up when filtering by location.
+// Tenants without any location are left alone.
+func linkDefaultLocation(ctx context.Context, tx pgx.Tx, link locationLink, tenantID int, id int) error {
+	query :=
+		`INSERT INTO ` + link.joinTable + ` (` + link.column + `, location_id)
+		SELECT $2, id FROM locations WHERE tenant_id=$1 ORDER BY id LIMIT 1`
+	_, err := tx.Exec(ctx, query, tenantID, id)
+	return err
+}
+
+// getLinkedLocations returns the locations of the owner row with the given id,
+// or pgx.ErrNoRows if no such row exists in the tenant.
+func getLinkedLocations(ctx context.Context, tx pgx.Tx, link locationLink, tenantID int, id int) ([]domain.Location, error) {
+	err := ownerExists(ctx, tx, link, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query :=
+		`SELECT locations.* FROM locations
+		JOIN ` + link.joinTable + ` ON ` + link.joinTable + `.location_id = locations.id
+		WHERE locations.tenant_id=$1 AND ` + link.joinTable + `.` + link.column + `=$2
+		ORDER BY locations.id`
+
+	rows, err := tx.Query(ctx, query, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[domain.Location])
+}
+
+// setLinkedLocations replaces the locations of the owner row with the given id.
+// Location ids that do not belong to the tenant fail with domain.ErrUnknownLocation.
+func setLinkedLocations(ctx context.Context, tx pgx.Tx, link locationLink, tenantID int, id int, locationIDs []int) ([]domain.Location, error) {
+	err := ownerExists(ctx, tx, link, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	locationIDs = slices.Clone(locationIDs)
+	slices.Sort(locationIDs)
+	locationIDs = slices.Compact(locationIDs)
+	query := "SELECT * FROM locations WHERE tenant_id=$1 AND id = ANY($2) ORDER BY id"
+	rows, err := tx.Query(ctx, query, tenantID, locationIDs)
+	if err != nil {
+		return nil, err
+	}
+	locations, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.Location])
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) != len(locationIDs) {
+		return nil, domain.ErrUnknownLocation
+	}
+
+	query = "DELETE FROM " + link.joinTable + " WHERE " + link.column + "=$1"
+	_, err = tx.Exec(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query = "INSERT INTO " + link.joinTable + " (" + link.column + ", location_id) SELECT $1, unnest($2::int[])"
+	_, err = tx.Exec(ctx, query, id, locationIDs)
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+func ownerExists(ctx context.Context, tx pgx.Tx, link locationLink, tenantID int, id int) error {
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM " + link.owner + " WHERE tenant_id=$1 AND id=$2)"
+	err := tx.QueryRow(ctx, query, tenantID, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Store) RecordLoginAttempt(ctx context.Context, tenantID int, data domain.LoginAttempt) error {
+	query :=
+		`INSERT INTO login_attempts (tenant_id, email, ip_address, outcome)
+		VALUES ($1, $2, $3, $4)`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, tenantID, data.Email, data.IPAddress, data.Outcome)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) CountAccountFailures(ctx context.Context, tenantID int, email string, since time.Time) (domain.LoginFailures, error) {
+	query :=
+		`SELECT COUNT(*), MAX(created_at) FROM login_attempts
+		WHERE tenant_id=$1 AND email=$2 AND outcome='failed' AND created_at > $3
+		AND created_at > COALESCE((
+			SELECT MAX(created_at) FROM login_attempts
+			WHERE tenant_id=$1 AND email=$2 AND outcome IN ('succeeded', 'unlocked')
+		), '-infinity')`
+
+	return s.countFailures(ctx, query, tenantID, email, since)
+}
+
+func (s *Store) CountIPFailures(ctx context.Context, ip string, since time.Time) (domain.LoginFailures, error) {
+	query :=
+		`SELECT COUNT(*), MAX(created_at) FROM login_attempts
+		WHERE ip_address=$1 AND outcome='failed' AND created_at > $2`
+
+	return s.countFailures(ctx, query, ip, since)
+}
+
+func (s *Store) countFailures(ctx context.Context, query string, args ...any) (domain.LoginFailures, error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return domain.LoginFailures{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var failures domain.LoginFailures
+	err = tx.QueryRow(ctx, query, args...).Scan(&failures.Count, &failures.LastAt)
+	if err != nil {
+		return domain.LoginFailures{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.LoginFailures{}, err
+	}
+	return failures, nil
+}
+
+func (s *Store) GetLoginAttempts(ctx context.Context, tenantID int, filter domain.LoginAttemptFilter) ([]domain.LoginAttempt, error) {
+	query := "SELECT * FROM login_attempts WHERE tenant_id=$1"
+	args := []any{tenantID}
+
+	if filter.Email != "" {
+		args = append(args, filter.Email)
+		query += fmt.Sprintf(" AND email=$%d", len(args))
+	}
+	if filter.Outcome != "" {
+		args = append(args, filter.Outcome)
+		query += fmt.Sprintf(" AND outcome=$%d", len(args))
+	}
+	query += " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.LoginAttempt{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return []domain.LoginAttempt{}, err
+	}
+
+	attempts, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.LoginAttempt])
+	if err != nil {
+		return []domain.LoginAttempt{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.LoginAttempt{}, err
+	}
+	return attempts, nil
+}
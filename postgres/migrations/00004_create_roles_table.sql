@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE roles (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR (50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT roles_tenant_id_name_key UNIQUE (tenant_id, name)
+);
+
+INSERT INTO roles (tenant_id, name, permissions)
+SELECT tenants.id, defaults.name, defaults.permissions
+FROM tenants CROSS JOIN (VALUES
+    ('admin', ARRAY['*']),
+    ('trainer', ARRAY['users:read', 'classes:read', 'classes:write']),
+    ('member', ARRAY['classes:read'])
+) AS defaults (name, permissions);
+
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_tenant_id_role_fkey
+    FOREIGN KEY (tenant_id, role) REFERENCES roles (tenant_id, name) ON UPDATE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP CONSTRAINT users_tenant_id_role_fkey;
+UPDATE users SET role = 'member' WHERE role NOT IN ('admin', 'trainer', 'member');
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('admin', 'trainer', 'member'));
+DROP TABLE roles;
+-- +goose StatementEnd
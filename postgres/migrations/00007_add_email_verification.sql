@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN verified_at TIMESTAMPTZ;
+ALTER TABLE tenants ADD COLUMN verified_at TIMESTAMPTZ;
+ALTER TABLE tenants ADD COLUMN require_email_verification BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- accounts created before verification existed are trusted as they are
+UPDATE users SET verified_at = created_at;
+UPDATE tenants SET verified_at = created_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tenants DROP COLUMN require_email_verification;
+ALTER TABLE tenants DROP COLUMN verified_at;
+ALTER TABLE users DROP COLUMN verified_at;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE login_attempts (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    ip_address TEXT NOT NULL,
+    outcome TEXT NOT NULL CHECK (outcome IN ('failed', 'succeeded', 'blocked', 'unlocked')),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX login_attempts_tenant_id_email_created_at_idx ON login_attempts (tenant_id, email, created_at);
+CREATE INDEX login_attempts_ip_address_created_at_idx ON login_attempts (ip_address, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE login_attempts;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE tenants SET status='active' WHERE status IS NULL;
+
+ALTER TABLE tenants
+    DROP CONSTRAINT tenants_status_check,
+    ALTER COLUMN status SET NOT NULL,
+    ADD CONSTRAINT tenants_status_check CHECK(status IN ('active', 'inactive', 'suspended')),
+    ADD COLUMN status_reason TEXT NOT NULL DEFAULT '',
+    ADD COLUMN status_changed_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE tenants SET status='inactive' WHERE status='suspended';
+
+ALTER TABLE tenants
+    DROP COLUMN status_changed_at,
+    DROP COLUMN status_reason,
+    DROP CONSTRAINT tenants_status_check,
+    ALTER COLUMN status DROP NOT NULL,
+    ADD CONSTRAINT tenants_status_check CHECK(status IN ('active', 'inactive'));
+-- +goose StatementEnd
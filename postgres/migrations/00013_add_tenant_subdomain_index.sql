@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- host names are case insensitive, so subdomains must be unique regardless of case
+UPDATE tenants SET subdomain=lower(subdomain);
+CREATE UNIQUE INDEX tenants_subdomain_lower_key ON tenants (lower(subdomain));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX tenants_subdomain_lower_key;
+-- +goose StatementEnd
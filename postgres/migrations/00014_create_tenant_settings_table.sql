@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tenant_settings (
+    tenant_id INT PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    timezone VARCHAR (64) NOT NULL DEFAULT 'UTC',
+    locale VARCHAR (35) NOT NULL DEFAULT 'en-US',
+    currency CHAR (3) NOT NULL DEFAULT 'USD',
+    week_start VARCHAR (9) NOT NULL DEFAULT 'monday',
+    opening_hours JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT tenant_settings_week_start_check CHECK (week_start IN
+        ('monday', 'tuesday', 'wednesday', 'thursday', 'friday', 'saturday', 'sunday'))
+);
+
+INSERT INTO tenant_settings (tenant_id) SELECT id FROM tenants;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE tenant_settings;
+-- +goose StatementEnd
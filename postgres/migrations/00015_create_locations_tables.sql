@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE locations (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR (100) NOT NULL,
+    address VARCHAR (255) NOT NULL DEFAULT '',
+    city VARCHAR (100) NOT NULL DEFAULT '',
+    postal_code VARCHAR (20) NOT NULL DEFAULT '',
+    country VARCHAR (2) NOT NULL DEFAULT '',
+    timezone VARCHAR (64) NOT NULL DEFAULT 'UTC',
+    opening_hours JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT locations_tenant_id_name_key UNIQUE (tenant_id, name)
+);
+
+CREATE TABLE class_locations (
+    class_id INT NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    PRIMARY KEY (class_id, location_id)
+);
+
+CREATE TABLE user_locations (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, location_id)
+);
+
+CREATE INDEX class_locations_location_id_idx ON class_locations (location_id);
+CREATE INDEX user_locations_location_id_idx ON user_locations (location_id);
+
+-- every existing tenant gets a default location that everything belongs to
+INSERT INTO locations (tenant_id, name, timezone, opening_hours)
+SELECT tenant_id, 'Main location', timezone, opening_hours FROM tenant_settings;
+
+INSERT INTO class_locations (class_id, location_id)
+SELECT classes.id, locations.id FROM classes JOIN locations ON locations.tenant_id = classes.tenant_id;
+
+INSERT INTO user_locations (user_id, location_id)
+SELECT users.id, locations.id FROM users JOIN locations ON locations.tenant_id = users.tenant_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_locations;
+DROP TABLE class_locations;
+DROP TABLE locations;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE platform_operators (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR (255) NOT NULL,
+    name VARCHAR (100) NOT NULL DEFAULT '',
+    password VARCHAR (255) NOT NULL,
+    role VARCHAR (50) NOT NULL DEFAULT 'platform_operator',
+    last_login_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT platform_operators_role_check CHECK (role IN ('platform_operator'))
+);
+
+CREATE UNIQUE INDEX platform_operators_email_key ON platform_operators (lower(email));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE platform_operators;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE plans (
+    code VARCHAR (50) PRIMARY KEY,
+    name VARCHAR (100) NOT NULL,
+    max_members INT CHECK (max_members >= 0),                   -- NULL for no limit
+    max_classes_per_week INT CHECK (max_classes_per_week >= 0), -- NULL for no limit
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+INSERT INTO plans (code, name, max_members, max_classes_per_week) VALUES
+    ('starter', 'Starter', 100, 20),
+    ('growth', 'Growth', 1000, 200),
+    ('unlimited', 'Unlimited', NULL, NULL);
+
+-- tenants that signed up before plans existed keep what they had
+ALTER TABLE tenants ADD COLUMN plan VARCHAR (50) NOT NULL DEFAULT 'unlimited'
+    CONSTRAINT tenants_plan_fkey REFERENCES plans (code) ON UPDATE CASCADE;
+ALTER TABLE tenants ALTER COLUMN plan SET DEFAULT 'starter';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tenants DROP COLUMN plan;
+DROP TABLE plans;
+-- +goose StatementEnd
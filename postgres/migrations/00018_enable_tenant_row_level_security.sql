@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Rows of users and classes are only visible to transactions that set
+-- app.tenant_id to their tenant, or app.all_tenants for the platform operator.
+-- Forcing it applies the policies to the owner of the tables too, which the
+-- app usually connects as; superusers and BYPASSRLS roles still skip them.
+-- Later migrations that change rows of these tables must set app.all_tenants.
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY users_tenant_isolation ON users
+    USING (
+        tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int
+        OR current_setting('app.all_tenants', true) = 'on'
+    )
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+
+ALTER TABLE classes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE classes FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY classes_tenant_isolation ON classes
+    USING (
+        tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int
+        OR current_setting('app.all_tenants', true) = 'on'
+    )
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY classes_tenant_isolation ON classes;
+ALTER TABLE classes NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE classes DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY users_tenant_isolation ON users;
+ALTER TABLE users NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+-- +goose StatementEnd
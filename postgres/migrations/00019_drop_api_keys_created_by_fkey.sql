@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- API keys stay in the shared schema even for tenants that have a schema
+-- of their own, where created_by refers to a user outside of public.users.
+-- DeleteUserByID clears it instead.
+ALTER TABLE api_keys DROP CONSTRAINT api_keys_created_by_fkey;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT set_config('app.all_tenants', 'on', true);
+
+UPDATE api_keys SET created_by = NULL
+WHERE created_by IS NOT NULL AND NOT EXISTS (SELECT FROM users WHERE users.id = api_keys.created_by);
+
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
+-- +goose StatementEnd
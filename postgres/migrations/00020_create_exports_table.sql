@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Exports stay in the shared schema, where the worker removing expired
+-- archives finds those of every tenant. requested_by has no foreign key,
+-- as the user may live in the schema of the tenant.
+CREATE TABLE exports (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    status VARCHAR (20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'completed', 'failed', 'expired')),
+    requested_by INT,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX exports_tenant_id_idx ON exports (tenant_id);
+CREATE INDEX exports_expires_at_idx ON exports (expires_at) WHERE status = 'completed';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE exports;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tenants
+    DROP CONSTRAINT tenants_status_check,
+    ADD CONSTRAINT tenants_status_check CHECK(status IN ('active', 'inactive', 'suspended', 'pending_deletion')),
+    ADD COLUMN deletion_requested_by VARCHAR (20)
+        CONSTRAINT tenants_deletion_requested_by_check CHECK (deletion_requested_by IN ('tenant', 'platform')),
+    ADD COLUMN deletion_scheduled_for TIMESTAMPTZ;
+
+CREATE INDEX tenants_deletion_scheduled_for_idx ON tenants (deletion_scheduled_for)
+    WHERE status = 'pending_deletion';
+
+-- Certificates outlive the tenant they prove was purged, so tenant_id has
+-- no foreign key.
+CREATE TABLE deletion_certificates (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT UNIQUE NOT NULL,
+    business_name VARCHAR (255) NOT NULL,
+    subdomain VARCHAR (255) NOT NULL,
+    requested_by VARCHAR (20) NOT NULL,
+    requested_at TIMESTAMPTZ NOT NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    purged_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_records JSONB NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE deletion_certificates;
+
+UPDATE tenants SET status='inactive' WHERE status='pending_deletion';
+
+ALTER TABLE tenants
+    DROP COLUMN deletion_scheduled_for,
+    DROP COLUMN deletion_requested_by,
+    DROP CONSTRAINT tenants_status_check,
+    ADD CONSTRAINT tenants_status_check CHECK(status IN ('active', 'inactive', 'suspended'));
+-- +goose StatementEnd
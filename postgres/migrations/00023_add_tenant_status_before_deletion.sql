@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Restoring a tenant puts it back in the status it had before its deletion
+-- was scheduled, so suspended tenants stay suspended.
+ALTER TABLE tenants
+    ADD COLUMN status_before_deletion VARCHAR (20)
+        CONSTRAINT tenants_status_before_deletion_check CHECK (status_before_deletion IN ('active', 'inactive', 'suspended')),
+    ADD COLUMN status_reason_before_deletion TEXT;
+
+-- restoring used to make tenants active, which the ones pending deletion keep
+UPDATE tenants SET status_before_deletion='active', status_reason_before_deletion=''
+WHERE status='pending_deletion';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tenants
+    DROP COLUMN status_reason_before_deletion,
+    DROP COLUMN status_before_deletion;
+-- +goose StatementEnd
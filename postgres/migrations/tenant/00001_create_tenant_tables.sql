@@ -0,0 +1,197 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tenant schemas are migrated with search_path set to the schema, followed
+-- by public, so these tables are created in the schema while tenants and
+-- other shared tables are still found in public. Changes to tenant tables
+-- need a migration here as well as in postgres/migrations.
+CREATE TABLE roles (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR (50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT roles_tenant_id_name_key UNIQUE (tenant_id, name)
+);
+
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT REFERENCES tenants(id) ON DELETE CASCADE,
+    first_name TEXT,
+    last_name TEXT,
+    email VARCHAR (255) UNIQUE NOT NULL,
+    password TEXT NOT NULL,
+    role VARCHAR (50),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    verified_at TIMESTAMPTZ,
+    CONSTRAINT users_tenant_id_role_fkey
+        FOREIGN KEY (tenant_id, role) REFERENCES roles (tenant_id, name) ON UPDATE CASCADE
+);
+
+CREATE TABLE classes (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT REFERENCES tenants(id) ON DELETE CASCADE,
+    trainer_id INT REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR (255),
+    description TEXT,
+    capacity INT,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE sessions (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ DEFAULT NOW(),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    session_id INT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    rotated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX sessions_tenant_id_user_id_idx ON sessions (tenant_id, user_id);
+
+CREATE TABLE password_reset_tokens (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE two_factor (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMPTZ,
+    last_used_step BIGINT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE recovery_codes (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (user_id, code_hash)
+);
+
+CREATE TABLE invitations (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    email VARCHAR (255) NOT NULL,
+    role VARCHAR (50) NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    invited_by INT REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT invitations_tenant_id_role_fkey
+        FOREIGN KEY (tenant_id, role) REFERENCES roles (tenant_id, name) ON UPDATE CASCADE
+);
+
+CREATE UNIQUE INDEX invitations_pending_email_key ON invitations (tenant_id, lower(email))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+
+CREATE TABLE tenant_settings (
+    tenant_id INT PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    timezone VARCHAR (64) NOT NULL DEFAULT 'UTC',
+    locale VARCHAR (35) NOT NULL DEFAULT 'en-US',
+    currency CHAR (3) NOT NULL DEFAULT 'USD',
+    week_start VARCHAR (9) NOT NULL DEFAULT 'monday',
+    opening_hours JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT tenant_settings_week_start_check CHECK (week_start IN
+        ('monday', 'tuesday', 'wednesday', 'thursday', 'friday', 'saturday', 'sunday'))
+);
+
+CREATE TABLE locations (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR (100) NOT NULL,
+    address VARCHAR (255) NOT NULL DEFAULT '',
+    city VARCHAR (100) NOT NULL DEFAULT '',
+    postal_code VARCHAR (20) NOT NULL DEFAULT '',
+    country VARCHAR (2) NOT NULL DEFAULT '',
+    timezone VARCHAR (64) NOT NULL DEFAULT 'UTC',
+    opening_hours JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT locations_tenant_id_name_key UNIQUE (tenant_id, name)
+);
+
+CREATE TABLE class_locations (
+    class_id INT NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    PRIMARY KEY (class_id, location_id)
+);
+
+CREATE TABLE user_locations (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, location_id)
+);
+
+CREATE INDEX class_locations_location_id_idx ON class_locations (location_id);
+CREATE INDEX user_locations_location_id_idx ON user_locations (location_id);
+
+-- the same policies as in the shared schema, in case a query reaches the
+-- schema of another tenant anyway
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY users_tenant_isolation ON users
+    USING (
+        tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int
+        OR current_setting('app.all_tenants', true) = 'on'
+    )
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+
+ALTER TABLE classes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE classes FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY classes_tenant_isolation ON classes
+    USING (
+        tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int
+        OR current_setting('app.all_tenants', true) = 'on'
+    )
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::int);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_locations;
+DROP TABLE class_locations;
+DROP TABLE locations;
+DROP TABLE tenant_settings;
+DROP TABLE invitations;
+DROP TABLE recovery_codes;
+DROP TABLE two_factor;
+DROP TABLE password_reset_tokens;
+DROP TABLE refresh_tokens;
+DROP TABLE sessions;
+DROP TABLE classes;
+DROP TABLE users;
+DROP TABLE roles;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- names known ahead, e.g. from an import, that the invitee can keep
+ALTER TABLE invitations
+    ADD COLUMN first_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN last_name TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE invitations
+    DROP COLUMN last_name,
+    DROP COLUMN first_name;
+-- +goose StatementEnd
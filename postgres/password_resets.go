@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+func (s *Store) CreatePasswordResetToken(ctx context.Context, tenantID int, userID int, tokenHash string, expiresAt time.Time) error {
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := "DELETE FROM password_reset_tokens WHERE tenant_id=$1 AND user_id=$2 AND used_at IS NULL"
+	_, err = tx.Exec(ctx, query, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	query =
+		`INSERT INTO password_reset_tokens (tenant_id, user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)`
+	_, err = tx.Exec(ctx, query, tenantID, userID, tokenHash, expiresAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) ConsumePasswordResetToken(ctx context.Context, tenantID int, tokenHash string) (int, error) {
+	query :=
+		`UPDATE password_reset_tokens SET used_at=NOW()
+		WHERE tenant_id=$1 AND token_hash=$2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID int
+	err = tx.QueryRow(ctx, query, tenantID, tokenHash).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Store) GetAllPlans(ctx context.Context) ([]domain.Plan, error) {
+	query := "SELECT * FROM plans ORDER BY max_members NULLS LAST, code"
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return []domain.Plan{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return []domain.Plan{}, err
+	}
+
+	plans, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.Plan])
+	if err != nil {
+		return []domain.Plan{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Plan{}, err
+	}
+	return plans, nil
+}
+
+func (s *Store) GetTenantPlan(ctx context.Context, tenantID int) (domain.Plan, error) {
+	query :=
+		`SELECT plans.* FROM plans
+		JOIN tenants ON tenants.plan = plans.code
+		WHERE tenants.id=$1`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Plan{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID)
+	if err != nil {
+		return domain.Plan{}, err
+	}
+
+	plan, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Plan])
+	if err != nil {
+		return domain.Plan{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Plan{}, err
+	}
+	return plan, nil
+}
+
+func (s *Store) SetTenantPlan(ctx context.Context, tenantID int, code string) (domain.Tenant, error) {
+	query := "UPDATE tenants SET plan=$1, updated_at=NOW() WHERE id=$2 RETURNING *"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, code, tenantID)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+
+	tenant, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Tenant])
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	return tenant, nil
+}
+
+func (s *Store) CountMembers(ctx context.Context, tenantID int) (int, error) {
+	// member roles grant nothing beyond $2, see domain.IsMemberRole
+	query :=
+		`SELECT
+			(SELECT COUNT(*) FROM users
+			JOIN roles ON roles.tenant_id = users.tenant_id AND roles.name = users.role
+			WHERE users.tenant_id=$1 AND roles.permissions <@ $2)
+			+
+			(SELECT COUNT(*) FROM invitations
+			JOIN roles ON roles.tenant_id = invitations.tenant_id AND roles.name = invitations.role
+			WHERE invitations.tenant_id=$1 AND roles.permissions <@ $2
+			AND invitations.accepted_at IS NULL AND invitations.revoked_at IS NULL
+			AND invitations.expires_at > NOW())`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var count int
+	err = tx.QueryRow(ctx, query, tenantID, domain.DefaultRolePermissions[domain.RoleMember]).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, tx.Commit(ctx)
+}
+
+func (s *Store) CountClassesBetween(ctx context.Context, tenantID int, from time.Time, to time.Time) (int, error) {
+	query := "SELECT COUNT(*) FROM classes WHERE tenant_id=$1 AND starts_at >= $2 AND starts_at < $3"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var count int
+	err = tx.QueryRow(ctx, query, tenantID, from, to).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, tx.Commit(ctx)
+}
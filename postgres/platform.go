@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+// tenantUsageQuery selects every tenant with its usage. Last activity is the
+// last time any of its sessions or API keys was used.
+const tenantUsageQuery = `SELECT tenants.*,
+	(SELECT COUNT(*) FROM users WHERE users.tenant_id = tenants.id) AS user_count,
+	(SELECT COUNT(*) FROM classes WHERE classes.tenant_id = tenants.id) AS class_count,
+	GREATEST(
+		(SELECT MAX(last_used_at) FROM sessions WHERE sessions.tenant_id = tenants.id),
+		(SELECT MAX(last_used_at) FROM api_keys WHERE api_keys.tenant_id = tenants.id)
+	) AS last_activity_at
+	FROM tenants`
+
+func (s *Store) CreatePlatformOperator(ctx context.Context, data domain.PlatformOperator) (domain.PlatformOperator, error) {
+	query :=
+		`INSERT INTO platform_operators (email, name, password)
+		VALUES ($1, $2, $3)
+		RETURNING *`
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return domain.PlatformOperator{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, data.Email, data.Name, data.Password)
+	if err != nil {
+		return domain.PlatformOperator{}, err
+	}
+
+	operator, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.PlatformOperator])
+	if err != nil {
+		return domain.PlatformOperator{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.PlatformOperator{}, err
+	}
+	return operator, nil
+}
+
+func (s *Store) GetPlatformOperatorByEmail(ctx context.Context, email string) (domain.PlatformOperator, error) {
+	query := "SELECT * FROM platform_operators WHERE lower(email)=lower($1)"
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return domain.PlatformOperator{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, email)
+	if err != nil {
+		return domain.PlatformOperator{}, err
+	}
+
+	operator, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.PlatformOperator])
+	if err != nil {
+		return domain.PlatformOperator{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.PlatformOperator{}, err
+	}
+	return operator, nil
+}
+
+func (s *Store) RecordPlatformLogin(ctx context.Context, operatorID int) error {
+	query := "UPDATE platform_operators SET last_login_at=NOW() WHERE id=$1"
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, operatorID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) GetTenantsUsage(ctx context.Context, filter domain.TenantFilter) ([]domain.TenantUsage, error) {
+	query := tenantUsageQuery + " WHERE TRUE"
+	var args []any
+
+	if filter.Search != "" {
+		args = append(args, "%"+escapeLike(filter.Search)+"%")
+		query += fmt.Sprintf(" AND (tenants.business_name ILIKE $%d OR tenants.subdomain ILIKE $%d)", len(args), len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND tenants.status=$%d", len(args))
+	}
+	query += " ORDER BY tenants.id"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	tx, err := s.beginAllTenantsTx(ctx)
+	if err != nil {
+		return []domain.TenantUsage{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return []domain.TenantUsage{}, err
+	}
+
+	tenants, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.TenantUsage])
+	if err != nil {
+		return []domain.TenantUsage{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.TenantUsage{}, err
+	}
+
+	// the query above only counts the shared tables, while tenants with a
+	// schema of their own can only be counted from within it
+	if s.schemas != nil {
+		for i, tenant := range tenants {
+			usage, err := s.GetTenantUsage(ctx, tenant.ID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue // deleted in the meantime
+			}
+			if err != nil {
+				return []domain.TenantUsage{}, err
+			}
+			tenants[i] = usage
+		}
+	}
+	return tenants, nil
+}
+
+func (s *Store) GetTenantUsage(ctx context.Context, tenantID int) (domain.TenantUsage, error) {
+	query := tenantUsageQuery + " WHERE tenants.id=$1"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.TenantUsage{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID)
+	if err != nil {
+		return domain.TenantUsage{}, err
+	}
+
+	tenant, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.TenantUsage])
+	if err != nil {
+		return domain.TenantUsage{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.TenantUsage{}, err
+	}
+	return tenant, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so s is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
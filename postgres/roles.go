@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Store) CreateRole(ctx context.Context, tenantID int, data domain.Role) (domain.Role, error) {
+	query :=
+		`INSERT INTO roles (tenant_id, name, description, permissions)
+		VALUES ($1, $2, $3, $4)
+		RETURNING *`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Role{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, data.Name, data.Description, data.Permissions)
+	if err != nil {
+		return domain.Role{}, err
+	}
+
+	role, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Role])
+	if err != nil {
+		return domain.Role{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Role{}, err
+	}
+	return role, nil
+}
+
+func (s *Store) GetRoleByID(ctx context.Context, tenantID int, roleID int) (domain.Role, error) {
+	query := "SELECT * FROM roles WHERE tenant_id=$1 AND id=$2"
+	return s.getRole(ctx, tenantID, query, tenantID, roleID)
+}
+
+func (s *Store) GetRoleByName(ctx context.Context, tenantID int, name string) (domain.Role, error) {
+	query := "SELECT * FROM roles WHERE tenant_id=$1 AND name=$2"
+	return s.getRole(ctx, tenantID, query, tenantID, name)
+}
+
+func (s *Store) getRole(ctx context.Context, tenantID int, query string, args ...any) (domain.Role, error) {
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Role{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return domain.Role{}, err
+	}
+
+	role, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Role])
+	if err != nil {
+		return domain.Role{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Role{}, err
+	}
+	return role, nil
+}
+
+func (s *Store) UpdateRole(ctx context.Context, tenantID int, roleID int, updates domain.RoleUpdate) (domain.Role, error) {
+	query, columnValues := buildRoleUpdateQuery(tenantID, roleID, updates)
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Role{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, columnValues...)
+	if err != nil {
+		return domain.Role{}, err
+	}
+
+	role, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Role])
+	if err != nil {
+		return domain.Role{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Role{}, err
+	}
+	return role, nil
+}
+
+func (s *Store) DeleteRoleByID(ctx context.Context, tenantID int, roleID int) error {
+	query := `DELETE FROM roles WHERE tenant_id=$1 AND id=$2`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, tenantID, roleID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) GetAllRoles(ctx context.Context, tenantID int) ([]domain.Role, error) {
+	query := "SELECT * FROM roles WHERE tenant_id=$1 ORDER BY id"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.Role{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID)
+	if err != nil {
+		return []domain.Role{}, err
+	}
+
+	roles, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.Role])
+	if err != nil {
+		return []domain.Role{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Role{}, err
+	}
+	return roles, nil
+}
+
+// createDefaultRoles seeds the built-in roles for a newly created tenant.
+func createDefaultRoles(ctx context.Context, tx pgx.Tx, tenantID int) error {
+	query :=
+		`INSERT INTO roles (tenant_id, name, permissions)
+		VALUES ($1, $2, $3)`
+
+	for name, permissions := range domain.DefaultRolePermissions {
+		_, err := tx.Exec(ctx, query, tenantID, name, permissions)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+)
+
+// tenantMigrationsDir holds the migrations of the tables that move into the
+// schema of a tenant, while the ones in postgres/migrations build public.
+const tenantMigrationsDir = "postgres/migrations/tenant"
+
+// tenantSchemas creates and migrates the schemas of tenants that keep their
+// data apart from the shared tables.
+type tenantSchemas struct {
+	connConfig *pgx.ConnConfig
+	migrations fs.FS
+}
+
+func newTenantSchemas(connConfig *pgx.ConnConfig, embedMigrations fs.FS) (*tenantSchemas, error) {
+	migrations, err := fs.Sub(embedMigrations, tenantMigrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading tenant migrations: %w", err)
+	}
+	return &tenantSchemas{connConfig: connConfig, migrations: migrations}, nil
+}
+
+// tenantSchema names the schema of tenantID. A tenant created before the
+// schema per tenant mode was enabled has none, and since search_path skips
+// schemas that don't exist, it keeps using the shared tables.
+func tenantSchema(tenantID int) string {
+	return "tenant_" + strconv.Itoa(tenantID)
+}
+
+// searchPath lets the tables of the tenant's schema shadow the shared ones,
+// which remain reachable for tenants, plans and other shared data.
+func searchPath(tenantID int) string {
+	return tenantSchema(tenantID) + ", public"
+}
+
+// migrate creates the schema unless it exists and brings it up to date. It
+// keeps its own goose version table, so every schema is migrated on its own.
+func (ts *tenantSchemas) migrate(ctx context.Context, schema string) error {
+	connConfig := ts.connConfig.Copy()
+	connConfig.RuntimeParams["search_path"] = schema + ", public"
+
+	db := stdlib.OpenDB(*connConfig)
+	defer db.Close()
+
+	_, err := db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+pgx.Identifier{schema}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("error creating schema %s: %w", schema, err)
+	}
+
+	store, err := database.NewStore(database.DialectPostgres, schema+".goose_db_version")
+	if err != nil {
+		return err
+	}
+	provider, err := goose.NewProvider("", db, ts.migrations, goose.WithStore(store))
+	if err != nil {
+		return err
+	}
+
+	_, err = provider.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("error migrating schema %s: %w", schema, err)
+	}
+	return nil
+}
+
+// drop removes schemas whose tenant was not created after all.
+func (ts *tenantSchemas) drop(ctx context.Context, schemas ...string) error {
+	if len(schemas) == 0 {
+		return nil
+	}
+	// the request that created them may be gone, their cleanup is not
+	ctx = context.WithoutCancel(ctx)
+
+	db := stdlib.OpenDB(*ts.connConfig)
+	defer db.Close()
+
+	var errs []error
+	for _, schema := range schemas {
+		_, err := db.ExecContext(ctx, "DROP SCHEMA IF EXISTS "+pgx.Identifier{schema}.Sanitize()+" CASCADE")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error dropping schema %s: %w", schema, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dropAfter drops schemas after err failed the creation of their tenant and
+// returns err, joined with the error of dropping them if that fails too.
+func (ts *tenantSchemas) dropAfter(ctx context.Context, err error, schemas ...string) error {
+	dropErr := ts.drop(ctx, schemas...)
+	if dropErr != nil {
+		return errors.Join(err, dropErr)
+	}
+	return err
+}
+
+// migrateAll migrates the schemas of every tenant that has one. Schemas left
+// behind by a tenant that was never created, e.g. as the server stopped in
+// between, are skipped; they are empty and can be dropped by hand.
+func (ts *tenantSchemas) migrateAll(ctx context.Context, db *sql.DB) error {
+	query :=
+		`SELECT nspname FROM pg_namespace
+		WHERE nspname ~ '^tenant_[0-9]+$'
+		AND EXISTS (SELECT 1 FROM tenants WHERE 'tenant_' || tenants.id = nspname)
+		ORDER BY nspname`
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("error listing tenant schemas: %w", err)
+	}
+	defer rows.Close()
+
+	var schemas []string
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return err
+		}
+		schemas = append(schemas, schema)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, schema := range schemas {
+		if err := ts.migrate(ctx, schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Store) CreateSession(ctx context.Context, tenantID int, data domain.Session, tokenHash string) (domain.Session, error) {
+	query :=
+		`INSERT INTO sessions (tenant_id, user_id, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, data.UserID, data.UserAgent, data.IPAddress, data.ExpiresAt)
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	session, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Session])
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	query = "INSERT INTO refresh_tokens (session_id, token_hash) VALUES ($1, $2)"
+	_, err = tx.Exec(ctx, query, session.ID, tokenHash)
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return session, nil
+}
+
+// RotateRefreshToken exchanges the refresh token with hash oldHash for one
+// with hash newHash. Presenting a token that was already rotated means it
+// leaked, so the whole session is revoked and ErrRefreshTokenReused returned.
+func (s *Store) RotateRefreshToken(ctx context.Context, tenantID int, oldHash string, newHash string, expiresAt time.Time) (domain.Session, error) {
+	query :=
+		`SELECT refresh_tokens.id, refresh_tokens.rotated_at IS NOT NULL, sessions.*
+		FROM refresh_tokens JOIN sessions ON sessions.id = refresh_tokens.session_id
+		WHERE refresh_tokens.token_hash=$1 AND sessions.tenant_id=$2
+		FOR UPDATE`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var tokenID int
+	var rotated bool
+	var session domain.Session
+	err = tx.QueryRow(ctx, query, oldHash, tenantID).Scan(&tokenID, &rotated,
+		&session.ID, &session.TenantID, &session.UserID, &session.UserAgent, &session.IPAddress,
+		&session.ExpiresAt, &session.RevokedAt, &session.LastUsedAt, &session.CreatedAt)
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return domain.Session{}, domain.ErrInvalidRefreshToken
+	}
+
+	if rotated {
+		_, err = tx.Exec(ctx, "UPDATE sessions SET revoked_at=NOW() WHERE id=$1", session.ID)
+		if err != nil {
+			return domain.Session{}, err
+		}
+		err = tx.Commit(ctx)
+		if err != nil {
+			return domain.Session{}, err
+		}
+		return domain.Session{}, domain.ErrRefreshTokenReused
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE refresh_tokens SET rotated_at=NOW() WHERE id=$1", tokenID)
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	query = "INSERT INTO refresh_tokens (session_id, token_hash) VALUES ($1, $2)"
+	_, err = tx.Exec(ctx, query, session.ID, newHash)
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	query =
+		`UPDATE sessions SET last_used_at=NOW(), expires_at=$1
+		WHERE id=$2
+		RETURNING last_used_at, expires_at`
+	err = tx.QueryRow(ctx, query, expiresAt, session.ID).Scan(&session.LastUsedAt, &session.ExpiresAt)
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return session, nil
+}
+
+func (s *Store) GetActiveSessions(ctx context.Context, tenantID int, userID int) ([]domain.Session, error) {
+	query :=
+		`SELECT * FROM sessions
+		WHERE tenant_id=$1 AND user_id=$2 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.Session{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, userID)
+	if err != nil {
+		return []domain.Session{}, err
+	}
+
+	sessions, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.Session])
+	if err != nil {
+		return []domain.Session{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Session{}, err
+	}
+	return sessions, nil
+}
+
+func (s *Store) GetSessionByID(ctx context.Context, tenantID int, sessionID int) (domain.Session, error) {
+	query := "SELECT * FROM sessions WHERE tenant_id=$1 AND id=$2"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, sessionID)
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	session, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Session])
+	if err != nil {
+		return domain.Session{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	return session, nil
+}
+
+func (s *Store) RevokeSession(ctx context.Context, tenantID int, userID int, sessionID int) error {
+	query :=
+		`UPDATE sessions SET revoked_at=NOW()
+		WHERE tenant_id=$1 AND user_id=$2 AND id=$3 AND revoked_at IS NULL`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, tenantID, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) RevokeAllSessions(ctx context.Context, tenantID int, userID int) error {
+	query :=
+		`UPDATE sessions SET revoked_at=NOW()
+		WHERE tenant_id=$1 AND user_id=$2 AND revoked_at IS NULL`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) RevokeOtherSessions(ctx context.Context, tenantID int, userID int, sessionID int) error {
+	query :=
+		`UPDATE sessions SET revoked_at=NOW()
+		WHERE tenant_id=$1 AND user_id=$2 AND id<>$3 AND revoked_at IS NULL`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, tenantID, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
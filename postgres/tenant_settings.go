@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Store) GetTenantSettings(ctx context.Context, tenantID int) (domain.TenantSettings, error) {
+	query := "SELECT * FROM tenant_settings WHERE tenant_id=$1"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.TenantSettings{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID)
+	if err != nil {
+		return domain.TenantSettings{}, err
+	}
+
+	settings, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.TenantSettings])
+	if err != nil {
+		return domain.TenantSettings{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.TenantSettings{}, err
+	}
+	return settings, nil
+}
+
+func (s *Store) UpdateTenantSettings(ctx context.Context, tenantID int, updates domain.TenantSettingsUpdate) (domain.TenantSettings, error) {
+	query, columnValues := buildTenantSettingsUpdateQuery(tenantID, updates)
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.TenantSettings{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, columnValues...)
+	if err != nil {
+		return domain.TenantSettings{}, err
+	}
+
+	settings, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.TenantSettings])
+	if err != nil {
+		return domain.TenantSettings{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.TenantSettings{}, err
+	}
+	return settings, nil
+}
+
+// createDefaultSettings gives a new tenant the column defaults, UTC and US English.
+func createDefaultSettings(ctx context.Context, tx pgx.Tx, tenantID int) error {
+	_, err := tx.Exec(ctx, "INSERT INTO tenant_settings (tenant_id) VALUES ($1)", tenantID)
+	return err
+}
@@ -2,32 +2,105 @@ package postgres
 
 import (
 	"context"
+	"time"
 
 	"github.com/emanuelquerty/gymulty/domain"
 	"github.com/jackc/pgx/v5"
 )
 
+// CreateTenant creates the tenant with its default roles, settings and
+// location. In the schema per tenant mode, the schema is created and
+// migrated first, on a connection of its own, and dropped again if the
+// tenant is not created after all: right away if CreateTenant fails, or when
+// the transaction of WithTx it runs in rolls back. Its tables reference
+// tenants, so CreateTenant must come before other writes to tenants in a
+// transaction.
 func (s *Store) CreateTenant(ctx context.Context, data domain.Tenant) (domain.Tenant, error) {
+	if s.schemas == nil {
+		return s.createTenant(ctx, data, nil)
+	}
+
+	tenantID, err := s.nextTenantID(ctx)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	schema := tenantSchema(tenantID)
+	err = s.schemas.migrate(ctx, schema)
+	if err != nil {
+		return domain.Tenant{}, s.schemas.dropAfter(ctx, err, schema)
+	}
+
+	tenant, err := s.createTenant(ctx, data, &tenantID)
+	if err != nil {
+		return domain.Tenant{}, s.schemas.dropAfter(ctx, err, schema)
+	}
+	if s.inTx {
+		*s.newSchemas = append(*s.newSchemas, schema)
+	}
+	return tenant, nil
+}
+
+// nextTenantID reserves the id of a tenant, which names its schema before
+// the tenant exists.
+func (s *Store) nextTenantID(ctx context.Context) (int, error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var tenantID int
+	err = tx.QueryRow(ctx, "SELECT nextval(pg_get_serial_sequence('tenants', 'id'))").Scan(&tenantID)
+	if err != nil {
+		return 0, err
+	}
+	return tenantID, tx.Commit(ctx)
+}
+
+// createTenant inserts the tenant, with tenantID unless it is nil, and
+// switches to the tenant's schema for its default rows when it has one.
+func (s *Store) createTenant(ctx context.Context, data domain.Tenant, tenantID *int) (domain.Tenant, error) {
 	query :=
-		`INSERT INTO tenants (business_name, subdomain) 
-		VALUES ($1, $2) 
-		RETURNING id, status, created_at, updated_at`
+		`INSERT INTO tenants (id, business_name, subdomain, require_email_verification, require_admin_two_factor)
+		VALUES (COALESCE($1, nextval(pg_get_serial_sequence('tenants', 'id'))), $2, $3, $4, $5)
+		RETURNING id, status, plan, created_at, updated_at`
 
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return domain.Tenant{}, err
 	}
 	defer tx.Rollback(ctx)
 
-	rows, err := tx.Query(ctx, query, data.BusinessName, data.Subdomain)
+	if tenantID != nil {
+		_, err = tx.Exec(ctx, "SELECT set_config('search_path', $1, true)", searchPath(*tenantID))
+		if err != nil {
+			return domain.Tenant{}, err
+		}
+	}
+
+	rows, err := tx.Query(ctx, query, tenantID, data.BusinessName, data.Subdomain, data.RequireEmailVerification, data.RequireAdminTwoFactor)
 	if err != nil {
 		return domain.Tenant{}, err
 	}
-	defer rows.Close()
 
 	tenant, err := pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[domain.Tenant])
 	tenant.BusinessName = data.BusinessName
 	tenant.Subdomain = data.Subdomain
+	tenant.RequireEmailVerification = data.RequireEmailVerification
+	tenant.RequireAdminTwoFactor = data.RequireAdminTwoFactor
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+
+	err = createDefaultRoles(ctx, tx, tenant.ID)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	err = createDefaultSettings(ctx, tx, tenant.ID)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	err = createDefaultLocation(ctx, tx, tenant.ID)
 	if err != nil {
 		return domain.Tenant{}, err
 	}
@@ -38,3 +111,156 @@ func (s *Store) CreateTenant(ctx context.Context, data domain.Tenant) (domain.Te
 
 	return tenant, nil
 }
+
+func (s *Store) GetTenantByID(ctx context.Context, tenantID int) (domain.Tenant, error) {
+	query := "SELECT * FROM tenants WHERE id=$1"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+
+	tenant, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Tenant])
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	return tenant, nil
+}
+
+// GetTenantBySubdomain looks a tenant up by its subdomain, ignoring case
+// since host names are case insensitive.
+func (s *Store) GetTenantBySubdomain(ctx context.Context, subdomain string) (domain.Tenant, error) {
+	query := "SELECT * FROM tenants WHERE lower(subdomain)=lower($1)"
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, subdomain)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+
+	tenant, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Tenant])
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	return tenant, nil
+}
+
+func (s *Store) VerifyTenant(ctx context.Context, tenantID int) error {
+	query := "UPDATE tenants SET verified_at=NOW() WHERE id=$1 AND verified_at IS NULL"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, tenantID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UpdateTenantStatus checks the current status in the same statement it
+// changes it in, so concurrent changes cannot both pass the check.
+func (s *Store) UpdateTenantStatus(ctx context.Context, tenantID int, status string, reason string, from []string) (domain.Tenant, error) {
+	query :=
+		`UPDATE tenants SET status=$1, status_reason=$2, status_changed_at=NOW(), updated_at=NOW(),
+		deletion_requested_by=NULL, deletion_scheduled_for=NULL,
+		status_before_deletion=NULL, status_reason_before_deletion=NULL
+		WHERE id=$3 AND status = ANY($4)
+		RETURNING *`
+	return s.updateTenantStatus(ctx, tenantID, query, status, reason, tenantID, from)
+}
+
+func (s *Store) ScheduleTenantDeletion(ctx context.Context, tenantID int, requestedBy string, reason string, scheduledFor time.Time) (domain.Tenant, error) {
+	// the right-hand sides see the row as it was, before the update
+	query :=
+		`UPDATE tenants SET status='pending_deletion', status_reason=$1, status_changed_at=NOW(), updated_at=NOW(),
+		deletion_requested_by=$2, deletion_scheduled_for=$3,
+		status_before_deletion=status, status_reason_before_deletion=status_reason
+		WHERE id=$4 AND status <> 'pending_deletion'
+		RETURNING *`
+	return s.updateTenantStatus(ctx, tenantID, query, reason, requestedBy, scheduledFor, tenantID)
+}
+
+func (s *Store) RestoreTenant(ctx context.Context, tenantID int, reason string, requestedBy ...string) (domain.Tenant, error) {
+	query :=
+		`UPDATE tenants SET status=COALESCE(status_before_deletion, 'active'),
+		status_reason=COALESCE(NULLIF($1, ''), status_reason_before_deletion, ''),
+		status_changed_at=NOW(), updated_at=NOW(),
+		deletion_requested_by=NULL, deletion_scheduled_for=NULL,
+		status_before_deletion=NULL, status_reason_before_deletion=NULL
+		WHERE id=$2 AND status='pending_deletion' AND deletion_requested_by = ANY($3)
+		RETURNING *`
+	return s.updateTenantStatus(ctx, tenantID, query, reason, tenantID, requestedBy)
+}
+
+// updateTenantStatus runs query, which updates the tenant and returns it. It
+// fails with pgx.ErrNoRows when the tenant is not in a status query moves it from.
+func (s *Store) updateTenantStatus(ctx context.Context, tenantID int, query string, args ...any) (domain.Tenant, error) {
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+
+	tenant, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Tenant])
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	return tenant, nil
+}
+
+func (s *Store) UpdateTenant(ctx context.Context, tenantID int, updates domain.TenantUpdate) (domain.Tenant, error) {
+	query, columnValues := buildTenantUpdateQuery(tenantID, updates)
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, columnValues...)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+
+	tenant, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.Tenant])
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.Tenant{}, err
+	}
+	return tenant, nil
+}
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Store) SaveTwoFactorSecret(ctx context.Context, tenantID int, userID int, secret string) error {
+	query :=
+		`INSERT INTO two_factor (tenant_id, user_id, secret)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET secret=EXCLUDED.secret, created_at=NOW()
+		WHERE two_factor.enabled_at IS NULL`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, query, tenantID, userID, secret)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) GetTwoFactor(ctx context.Context, tenantID int, userID int) (domain.TwoFactor, error) {
+	query := "SELECT * FROM two_factor WHERE tenant_id=$1 AND user_id=$2"
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.TwoFactor{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, userID)
+	if err != nil {
+		return domain.TwoFactor{}, err
+	}
+
+	twoFactor, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.TwoFactor])
+	if err != nil {
+		return domain.TwoFactor{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.TwoFactor{}, err
+	}
+	return twoFactor, nil
+}
+
+func (s *Store) EnableTwoFactor(ctx context.Context, tenantID int, userID int, recoveryCodeHashes []string) error {
+	query :=
+		`UPDATE two_factor SET enabled_at=NOW()
+		WHERE tenant_id=$1 AND user_id=$2 AND enabled_at IS NULL`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	err = replaceRecoveryCodes(ctx, tx, tenantID, userID, recoveryCodeHashes)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) DisableTwoFactor(ctx context.Context, tenantID int, userID int) error {
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "DELETE FROM recovery_codes WHERE tenant_id=$1 AND user_id=$2", tenantID, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "DELETE FROM two_factor WHERE tenant_id=$1 AND user_id=$2", tenantID, userID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) ReplaceRecoveryCodes(ctx context.Context, tenantID int, userID int, recoveryCodeHashes []string) error {
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = replaceRecoveryCodes(ctx, tx, tenantID, userID, recoveryCodeHashes)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) ConsumeRecoveryCode(ctx context.Context, tenantID int, userID int, codeHash string) error {
+	query :=
+		`UPDATE recovery_codes SET used_at=NOW()
+		WHERE tenant_id=$1 AND user_id=$2 AND code_hash=$3 AND used_at IS NULL`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, tenantID, userID, codeHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) UseTwoFactorStep(ctx context.Context, tenantID int, userID int, step int64) error {
+	query :=
+		`UPDATE two_factor SET last_used_step=$3
+		WHERE tenant_id=$1 AND user_id=$2 AND (last_used_step IS NULL OR last_used_step < $3)`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, tenantID, userID, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrTwoFactorCodeReused
+	}
+	return tx.Commit(ctx)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, tenantID int, userID int, hashes []string) error {
+	_, err := tx.Exec(ctx, "DELETE FROM recovery_codes WHERE tenant_id=$1 AND user_id=$2", tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO recovery_codes (tenant_id, user_id, code_hash) VALUES ($1, $2, $3)"
+	for _, hash := range hashes {
+		_, err = tx.Exec(ctx, query, tenantID, userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
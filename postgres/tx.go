@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/emanuelquerty/gymulty/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// maxTxAttempts bounds how often WithTx runs fn while its transaction
+// keeps failing to serialize with concurrent ones.
+const maxTxAttempts = 3
+
+// WithTx runs fn in one serializable transaction that is committed when fn
+// returns nil and rolled back otherwise. Transactions that fail to
+// serialize or deadlock are retried, so fn may run more than once and must
+// not have side effects outside of tx. The store passed to fn must not be
+// used concurrently or after fn returns.
+//
+// Calling WithTx on tx joins the surrounding transaction as a savepoint.
+func (s *Store) WithTx(ctx context.Context, fn func(tx domain.Store) error) error {
+	if s.inTx {
+		return s.runTx(ctx, pgx.TxOptions{}, fn)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := s.runTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, fn)
+		if !isSerializationFailure(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		// wait a little, with jitter, so the transactions that conflicted
+		// don't run into each other again right away
+		backoff := time.Duration(attempt)*10*time.Millisecond + rand.N(10*time.Millisecond)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Store) runTx(ctx context.Context, txOptions pgx.TxOptions, fn func(tx domain.Store) error) error {
+	tx, err := s.db.BeginTx(ctx, txOptions)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var newSchemas []string
+	err = fn(&Store{db: savepoints{tx: tx}, inTx: true, schemas: s.schemas, newSchemas: &newSchemas})
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		// the schemas can only be dropped once tx no longer locks their tables
+		tx.Rollback(ctx)
+		if s.schemas != nil {
+			return s.schemas.dropAfter(ctx, err, newSchemas...)
+		}
+		return err
+	}
+
+	// a savepoint is only kept if the transaction around it commits
+	if s.inTx {
+		*s.newSchemas = append(*s.newSchemas, newSchemas...)
+	}
+	return nil
+}
+
+// beginTx begins the transaction of a store method acting on tenantID. It
+// sets app.tenant_id until the transaction, or its savepoint, ends, and the row-level security
+// policies of tenant tables hide every row of other tenants from it, so a
+// query that forgets to filter by tenant_id still cannot reach them. In the
+// schema per tenant mode, it also puts the tenant's schema first in the
+// search_path.
+func (s *Store) beginTx(ctx context.Context, tenantID int) (pgx.Tx, error) {
+	return s.beginTxOptions(ctx, tenantID, pgx.TxOptions{})
+}
+
+// beginTxOptions is beginTx with txOptions, which savepoints ignore.
+func (s *Store) beginTxOptions(ctx context.Context, tenantID int, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	tx, err := s.db.BeginTx(ctx, txOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.schemas != nil {
+		_, err = tx.Exec(ctx, "SELECT set_config('app.tenant_id', $1, true), set_config('search_path', $2, true)",
+			strconv.Itoa(tenantID), searchPath(tenantID))
+	} else {
+		_, err = tx.Exec(ctx, "SELECT set_config('app.tenant_id', $1, true)", strconv.Itoa(tenantID))
+	}
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// beginAllTenantsTx begins a transaction that sees the rows of every tenant.
+// It is reserved for the cross-tenant queries of the platform operator.
+func (s *Store) beginAllTenantsTx(ctx context.Context) (pgx.Tx, error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, "SELECT set_config('app.all_tenants', 'on', true)")
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// isSerializationFailure reports whether err aborted the transaction only
+// because of concurrent ones, so running it again may succeed.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01" // serialization_failure, deadlock_detected
+}
+
+// savepoints runs the transaction of each store method as a savepoint of
+// tx, so a failed statement only undoes its own method while committing
+// a method merely releases its savepoint.
+type savepoints struct {
+	tx pgx.Tx
+}
+
+func (sp savepoints) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	tx, err := sp.tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return savepoint{Tx: tx}, nil
+}
+
+// resetTxSettings undoes the settings of beginTx and beginAllTenantsTx.
+const resetTxSettings = `SELECT set_config('app.tenant_id', '', true), set_config('app.all_tenants', '', true),
+	set_config('search_path', (SELECT reset_val FROM pg_settings WHERE name = 'search_path'), true)`
+
+// savepoint resets the settings of the store method it runs when it is
+// released. They are local to the surrounding transaction, not to the
+// savepoint, so they would otherwise carry over to the methods run after it,
+// e.g. letting them see the rows of every tenant. Rolling back a savepoint
+// undoes them already.
+type savepoint struct {
+	pgx.Tx
+}
+
+func (sp savepoint) Commit(ctx context.Context) error {
+	_, err := sp.Tx.Exec(ctx, resetTxSettings)
+	if err != nil {
+		return err
+	}
+	return sp.Tx.Commit(ctx)
+}
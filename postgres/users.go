@@ -13,19 +13,24 @@ func (s *Store) CreateUser(ctx context.Context, tenantID int, data domain.User)
 		VALUES ($1, $2, $3, $4, $5, $6) 
 		RETURNING id, created_at, updated_at`
 
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.beginTx(ctx, tenantID)
 	if err != nil {
 		return domain.User{}, err
 	}
 	defer tx.Rollback(ctx)
 
-	row := tx.QueryRow(ctx, query, data.TenantID, data.FirstName, data.LastName, data.Email, data.Password, data.Role)
+	row := tx.QueryRow(ctx, query, tenantID, data.FirstName, data.LastName, data.Email, data.Password, data.Role)
 
 	user := data
+	user.TenantID = tenantID
 	err = row.Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return domain.User{}, err
 	}
+	err = linkDefaultLocation(ctx, tx, userLocations, tenantID, user.ID)
+	if err != nil {
+		return domain.User{}, err
+	}
 
 	err = tx.Commit(ctx)
 	if err != nil {
@@ -36,7 +41,7 @@ func (s *Store) CreateUser(ctx context.Context, tenantID int, data domain.User)
 
 func (s *Store) GetUserByID(ctx context.Context, tenantID int, userID int) (domain.User, error) {
 	query := "SELECT * FROM users WHERE tenant_id=$1 AND id=$2"
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.beginTx(ctx, tenantID)
 	if err != nil {
 		return domain.User{}, err
 	}
@@ -59,15 +64,111 @@ func (s *Store) GetUserByID(ctx context.Context, tenantID int, userID int) (doma
 	return user, nil
 }
 
+func (s *Store) GetUserByEmail(ctx context.Context, tenantID int, email string) (domain.User, error) {
+	query := "SELECT * FROM users WHERE tenant_id=$1 AND email=$2"
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, email)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.User])
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+func (s *Store) GetTakenEmails(ctx context.Context, tenantID int, emails []string) ([]string, error) {
+	query := "SELECT lower(email) FROM users WHERE lower(email) = ANY($1)"
+
+	// users_email_key spans the users of all tenants sharing the table
+	var tx pgx.Tx
+	var err error
+	if s.schemas != nil {
+		tx, err = s.beginTx(ctx, tenantID)
+	} else {
+		tx, err = s.beginAllTenantsTx(ctx)
+	}
+	if err != nil {
+		return []string{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, emails)
+	if err != nil {
+		return []string{}, err
+	}
+
+	taken, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return []string{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []string{}, err
+	}
+	return taken, nil
+}
+
+func (s *Store) VerifyUserEmail(ctx context.Context, tenantID int, userID int) (domain.User, error) {
+	query :=
+		`UPDATE users SET verified_at=COALESCE(verified_at, NOW())
+		WHERE tenant_id=$1 AND id=$2
+		RETURNING *`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return domain.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, userID)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[domain.User])
+	if err != nil {
+		return domain.User{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
 func (s *Store) UpdateUser(ctx context.Context, tenantID int, userID int, updates domain.UserUpdate) (domain.User, error) {
 	query, columnValues := buildUserUpdateQuery(tenantID, userID, updates)
 
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.beginTx(ctx, tenantID)
 	if err != nil {
 		return domain.User{}, err
 	}
 	defer tx.Rollback(ctx)
 
+	// a new email address has to be verified again
+	if updates.Email != nil {
+		_, err = tx.Exec(ctx,
+			"UPDATE users SET verified_at=NULL WHERE tenant_id=$1 AND id=$2 AND lower(email) <> lower($3)",
+			tenantID, userID, *updates.Email)
+		if err != nil {
+			return domain.User{}, err
+		}
+	}
+
 	rows, err := tx.Query(ctx, query, columnValues...)
 	if err != nil {
 		return domain.User{}, err
@@ -87,7 +188,7 @@ func (s *Store) UpdateUser(ctx context.Context, tenantID int, userID int, update
 
 func (s *Store) DeleteUserByID(ctx context.Context, tenantID int, userID int) error {
 	query := `DELETE FROM users WHERE tenant_id=$1 AND id=$2`
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.beginTx(ctx, tenantID)
 	if err != nil {
 		return err
 	}
@@ -99,12 +200,19 @@ func (s *Store) DeleteUserByID(ctx context.Context, tenantID int, userID int) er
 	}
 	rows.Close()
 
+	// api keys stay shared while the user may live in the tenant's schema,
+	// so no foreign key clears their creator
+	_, err = tx.Exec(ctx, "UPDATE api_keys SET created_by=NULL WHERE tenant_id=$1 AND created_by=$2", tenantID, userID)
+	if err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
 
 func (s *Store) GetAllUsers(ctx context.Context, tenantID int) ([]domain.User, error) {
 	query := "SELECT * FROM users WHERE tenant_id=$1"
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := s.beginTx(ctx, tenantID)
 	if err != nil {
 		return []domain.User{}, err
 	}
@@ -125,3 +233,67 @@ func (s *Store) GetAllUsers(ctx context.Context, tenantID int) ([]domain.User, e
 	}
 	return users, nil
 }
+
+func (s *Store) GetUsersByLocation(ctx context.Context, tenantID int, locationID int) ([]domain.User, error) {
+	query :=
+		`SELECT users.* FROM users
+		JOIN user_locations ON user_locations.user_id = users.id
+		WHERE users.tenant_id=$1 AND user_locations.location_id=$2`
+
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, query, tenantID, locationID)
+	if err != nil {
+		return []domain.User{}, err
+	}
+	users, err := pgx.CollectRows(rows, pgx.RowToStructByName[domain.User])
+	if err != nil {
+		return []domain.User{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.User{}, err
+	}
+	return users, nil
+}
+
+func (s *Store) GetUserLocations(ctx context.Context, tenantID int, userID int) ([]domain.Location, error) {
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	locations, err := getLinkedLocations(ctx, tx, userLocations, tenantID, userID)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	return locations, nil
+}
+
+func (s *Store) SetUserLocations(ctx context.Context, tenantID int, userID int, locationIDs []int) ([]domain.Location, error) {
+	tx, err := s.beginTx(ctx, tenantID)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	locations, err := setLinkedLocations(ctx, tx, userLocations, tenantID, userID, locationIDs)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return []domain.Location{}, err
+	}
+	return locations, nil
+}